@@ -0,0 +1,377 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/config"
+)
+
+// Report formats selectable with report.formats in the config file.
+const (
+	formatJSON     = "json"
+	formatMarkdown = "markdown"
+	formatHTML     = "html"
+	formatJUnit    = "junit"
+	formatSARIF    = "sarif"
+)
+
+// knownFormats lists the supported report formats.
+var knownFormats = []string{formatJSON, formatMarkdown, formatHTML, formatJUnit, formatSARIF}
+
+// defaultFormats are written when the config file does not select any.
+var defaultFormats = []string{formatJSON, formatMarkdown, formatHTML}
+
+// Sources of effective settings, shown by `flakehunt config show`.
+const (
+	sourceDefault = "default"
+	sourceFile    = "config file"
+	sourceFlag    = "flag"
+)
+
+// configSetting binds a config file key to its flag and cliConfig field.
+type configSetting struct {
+	key   string // key in the config file
+	flag  string // equivalent flag name, empty if the setting is file-only
+	isSet func(s config.Settings) bool
+	apply func(s config.Settings, cfg *cliConfig)
+}
+
+// configSettings lists the settings applied from the config file, in display order.
+var configSettings = []configSetting{
+	{"runs", "runs",
+		func(s config.Settings) bool { return s.Runs != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.runs = *s.Runs }},
+	{"timeout", "timeout",
+		func(s config.Settings) bool { return s.Timeout != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.timeout = *s.Timeout }},
+	{"test-timeout", "test-timeout",
+		func(s config.Settings) bool { return s.TestTimeout != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.testTimeout = *s.TestTimeout }},
+	{"out", "out",
+		func(s config.Settings) bool { return s.Out != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.outDir = *s.Out }},
+	{"keep-runs", "keep-runs",
+		func(s config.Settings) bool { return s.KeepRuns != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.keepRuns = *s.KeepRuns }},
+	{"json", "json",
+		func(s config.Settings) bool { return s.JSON != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.jsonOutput = *s.JSON }},
+	{"fail-on-flake", "fail-on-flake",
+		func(s config.Settings) bool { return s.FailOnFlake != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.failOnFlake = *s.FailOnFlake }},
+	{"target", "target",
+		func(s config.Settings) bool { return s.Target != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.target = *s.Target }},
+	{"tui", "tui",
+		func(s config.Settings) bool { return s.TUI != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.tui = *s.TUI }},
+	{"tool", "tool",
+		func(s config.Settings) bool { return s.Tool != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.tool = *s.Tool }},
+	{"hooks.before-session", "before-session",
+		func(s config.Settings) bool { return s.Hooks.BeforeSession != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.hooks.BeforeSession = *s.Hooks.BeforeSession }},
+	{"hooks.before-run", "before-run",
+		func(s config.Settings) bool { return s.Hooks.BeforeRun != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.hooks.BeforeRun = *s.Hooks.BeforeRun }},
+	{"hooks.after-run", "after-run",
+		func(s config.Settings) bool { return s.Hooks.AfterRun != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.hooks.AfterRun = *s.Hooks.AfterRun }},
+	{"hooks.after-session", "after-session",
+		func(s config.Settings) bool { return s.Hooks.AfterSession != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.hooks.AfterSession = *s.Hooks.AfterSession }},
+	{"signatures", "",
+		func(s config.Settings) bool { return len(s.Signatures) > 0 },
+		func(s config.Settings, cfg *cliConfig) { cfg.signatures = s.Signatures }},
+	{"adapters", "",
+		func(s config.Settings) bool { return len(s.Adapters) > 0 },
+		func(s config.Settings, cfg *cliConfig) {
+			cfg.adapterArgs = make(map[string][]string, len(s.Adapters))
+			for tool, a := range s.Adapters {
+				cfg.adapterArgs[tool] = a.Args
+			}
+		}},
+	{"report.top-n", "",
+		func(s config.Settings) bool { return s.Report.TopN != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.topN = *s.Report.TopN }},
+	{"report.formats", "",
+		func(s config.Settings) bool { return len(s.Report.Formats) > 0 },
+		func(s config.Settings, cfg *cliConfig) { cfg.formats = s.Report.Formats }},
+	{"notify.provider", "notify",
+		func(s config.Settings) bool { return s.Notify.Provider != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.notify.Provider = *s.Notify.Provider }},
+	{"notify.url", "",
+		func(s config.Settings) bool { return s.Notify.URL != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.notify.BaseURL = *s.Notify.URL }},
+	{"notify.repo", "",
+		func(s config.Settings) bool { return s.Notify.Repo != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.notify.Repo = *s.Notify.Repo }},
+	{"notify.pr", "notify-pr",
+		func(s config.Settings) bool { return s.Notify.PR != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.notify.Number = *s.Notify.PR }},
+	{"notify.token-env", "",
+		func(s config.Settings) bool { return s.Notify.TokenEnv != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.notifyTokenEnv = *s.Notify.TokenEnv }},
+	{"notify.artifacts-url", "",
+		func(s config.Settings) bool { return s.Notify.ArtifactsURL != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.notify.ArtifactsURL = *s.Notify.ArtifactsURL }},
+	{"issues.provider", "",
+		func(s config.Settings) bool { return s.Issues.Provider != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.issues.Provider = *s.Issues.Provider }},
+	{"issues.url", "",
+		func(s config.Settings) bool { return s.Issues.URL != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.issues.BaseURL = *s.Issues.URL }},
+	{"issues.repo", "",
+		func(s config.Settings) bool { return s.Issues.Repo != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.issues.Repo = *s.Issues.Repo }},
+	{"issues.project", "",
+		func(s config.Settings) bool { return s.Issues.Project != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.issues.Project = *s.Issues.Project }},
+	{"issues.issue-type", "",
+		func(s config.Settings) bool { return s.Issues.IssueType != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.issues.IssueType = *s.Issues.IssueType }},
+	{"issues.labels", "",
+		func(s config.Settings) bool { return len(s.Issues.Labels) > 0 },
+		func(s config.Settings, cfg *cliConfig) { cfg.issues.Labels = s.Issues.Labels }},
+	{"issues.token-env", "",
+		func(s config.Settings) bool { return s.Issues.TokenEnv != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.issuesTokenEnv = *s.Issues.TokenEnv }},
+	{"issues.user-env", "",
+		func(s config.Settings) bool { return s.Issues.UserEnv != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.issuesUserEnv = *s.Issues.UserEnv }},
+	{"issues.limit", "",
+		func(s config.Settings) bool { return s.Issues.Limit != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.issuesLimit = *s.Issues.Limit }},
+	{"issues.dry-run", "dry-run",
+		func(s config.Settings) bool { return s.Issues.DryRun != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.issuesDryRun = *s.Issues.DryRun }},
+	{"webhooks", "",
+		func(s config.Settings) bool { return len(s.Webhooks) > 0 },
+		func(s config.Settings, cfg *cliConfig) { cfg.webhooks = s.Webhooks }},
+	{"quarantine.file", "file",
+		func(s config.Settings) bool { return s.Quarantine.File != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.quarantineFile = *s.Quarantine.File }},
+	{"quarantine.days", "days",
+		func(s config.Settings) bool { return s.Quarantine.Days != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.quarantineDays = *s.Quarantine.Days }},
+	{"quarantine.owner", "owner",
+		func(s config.Settings) bool { return s.Quarantine.Owner != nil },
+		func(s config.Settings, cfg *cliConfig) { cfg.quarantineOwner = *s.Quarantine.Owner }},
+}
+
+// loadConfig applies the config file and selected profile to cfg.
+// Flags set on the command line take precedence over file values.
+// It returns nil if no config file is found.
+func loadConfig(fs *flag.FlagSet, cfg *cliConfig) (*config.File, error) {
+	cfg.sources = make(map[string]string, len(configSettings))
+	if cfg.formats == nil {
+		cfg.formats = defaultFormats
+	}
+
+	flagsSet := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		flagsSet[f.Name] = true
+	})
+
+	path := cfg.configPath
+	if path == "" {
+		found, err := config.Find(".")
+		if err != nil {
+			return nil, err
+		}
+		path = found
+	}
+
+	var file *config.File
+	var resolved, profile config.Settings
+	if path != "" {
+		loaded, err := config.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		resolved, err = loaded.Resolve(cfg.profile)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.profile != "" {
+			profile = loaded.Profiles[cfg.profile]
+		}
+		file = loaded
+
+		// A relative output directory is relative to the config file, so that
+		// sessions started from a subdirectory still run from the project root
+		if resolved.Out != nil && !filepath.IsAbs(*resolved.Out) {
+			out := resolveRelative(filepath.Dir(path), *resolved.Out)
+			resolved.Out = &out
+		}
+		if resolved.Quarantine.File != nil && !filepath.IsAbs(*resolved.Quarantine.File) {
+			file := resolveRelative(filepath.Dir(path), *resolved.Quarantine.File)
+			resolved.Quarantine.File = &file
+		}
+	} else if cfg.profile != "" {
+		return nil, fmt.Errorf("--profile %q requires a config file, but no %s was found", cfg.profile, config.FileNames[0])
+	}
+
+	for _, setting := range configSettings {
+		switch {
+		case setting.flag != "" && flagsSet[setting.flag]:
+			cfg.sources[setting.key] = sourceFlag
+		case setting.isSet(resolved):
+			setting.apply(resolved, cfg)
+			if setting.isSet(profile) {
+				cfg.sources[setting.key] = "profile " + cfg.profile
+			} else {
+				cfg.sources[setting.key] = sourceFile
+			}
+		default:
+			cfg.sources[setting.key] = sourceDefault
+		}
+	}
+
+	for _, f := range cfg.formats {
+		if !hasFormat(knownFormats, f) {
+			return nil, fmt.Errorf("unknown report format %q in report.formats. Supported formats: %s", f, strings.Join(knownFormats, ", "))
+		}
+	}
+
+	if p := cfg.notify.Provider; p != "" && !slices.Contains(config.NotifyProviders, p) {
+		return nil, fmt.Errorf("unknown notify provider %q. Supported providers: %s", p, strings.Join(config.NotifyProviders, ", "))
+	}
+
+	return file, nil
+}
+
+// resolveRelative joins dir and path, returning the result relative to the
+// working directory when possible.
+func resolveRelative(dir, path string) string {
+	joined := filepath.Join(dir, path)
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, joined); err == nil {
+			return rel
+		}
+	}
+	return joined
+}
+
+// hasFormat reports whether format is in formats.
+func hasFormat(formats []string, format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// runConfig implements the `flakehunt config` command.
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "show" {
+		fmt.Fprintln(os.Stderr, "Usage: flakehunt config show [--config <path>] [--profile <name>] [flags]")
+		return exitError
+	}
+
+	fs, cfg := newFlagSet("flakehunt config show")
+	if err := fs.Parse(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	file, err := loadConfig(fs, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	data, err := config.MarshalAnnotated(effectiveSettings(cfg), cfg.sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to render configuration: %v\n", err)
+		return exitError
+	}
+
+	fmt.Println("# Effective flakehunt configuration")
+	if file != nil {
+		fmt.Printf("# Config file: %s\n", file.Path)
+	} else {
+		fmt.Println("# Config file: none found")
+	}
+	if cfg.profile != "" {
+		fmt.Printf("# Profile: %s\n", cfg.profile)
+	}
+	fmt.Println("# Precedence: flags > profile > config file > defaults")
+	fmt.Print(string(data))
+
+	return exitSuccess
+}
+
+// effectiveSettings converts the merged CLI configuration back to config settings.
+func effectiveSettings(cfg *cliConfig) config.Settings {
+	s := config.Settings{
+		Runs:        &cfg.runs,
+		Timeout:     &cfg.timeout,
+		TestTimeout: &cfg.testTimeout,
+		Out:         &cfg.outDir,
+		KeepRuns:    &cfg.keepRuns,
+		JSON:        &cfg.jsonOutput,
+		FailOnFlake: &cfg.failOnFlake,
+		Target:      &cfg.target,
+		TUI:         &cfg.tui,
+		Tool:        &cfg.tool,
+		Hooks: config.Hooks{
+			BeforeSession: &cfg.hooks.BeforeSession,
+			BeforeRun:     &cfg.hooks.BeforeRun,
+			AfterRun:      &cfg.hooks.AfterRun,
+			AfterSession:  &cfg.hooks.AfterSession,
+		},
+		Signatures: cfg.signatures,
+		Webhooks:   cfg.webhooks,
+		Report: config.ReportSettings{
+			TopN:    &cfg.topN,
+			Formats: cfg.formats,
+		},
+		Quarantine: config.QuarantineSettings{
+			File:  &cfg.quarantineFile,
+			Days:  &cfg.quarantineDays,
+			Owner: &cfg.quarantineOwner,
+		},
+	}
+
+	if cfg.notify.Provider != "" {
+		s.Notify = config.NotifySettings{
+			Provider:     &cfg.notify.Provider,
+			URL:          &cfg.notify.BaseURL,
+			Repo:         &cfg.notify.Repo,
+			PR:           &cfg.notify.Number,
+			TokenEnv:     &cfg.notifyTokenEnv,
+			ArtifactsURL: &cfg.notify.ArtifactsURL,
+		}
+	}
+
+	if cfg.issues.Provider != "" {
+		s.Issues = config.IssueSettings{
+			Provider:  &cfg.issues.Provider,
+			URL:       &cfg.issues.BaseURL,
+			Repo:      &cfg.issues.Repo,
+			Project:   &cfg.issues.Project,
+			IssueType: &cfg.issues.IssueType,
+			Labels:    cfg.issues.Labels,
+			TokenEnv:  &cfg.issuesTokenEnv,
+			UserEnv:   &cfg.issuesUserEnv,
+			Limit:     &cfg.issuesLimit,
+			DryRun:    &cfg.issuesDryRun,
+		}
+	}
+
+	if len(cfg.adapterArgs) > 0 {
+		s.Adapters = make(map[string]config.AdapterSettings, len(cfg.adapterArgs))
+		for tool, args := range cfg.adapterArgs {
+			s.Adapters[tool] = config.AdapterSettings{Args: args}
+		}
+	}
+
+	return s
+}
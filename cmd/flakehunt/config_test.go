@@ -0,0 +1,186 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/config"
+)
+
+const testConfig = `
+runs: 20
+timeout: 5m
+keep-runs: 3
+out: build/flakehunt
+quarantine:
+  file: ci/quarantine.json
+profiles:
+  quick:
+    runs: 5
+    timeout: 1m
+`
+
+// writeTestConfig writes a config file to a new project directory and
+// returns the directory.
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, config.FileNames[0]), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return dir
+}
+
+// parseConfig parses args and applies the config file as flakehunt does.
+func parseConfig(t *testing.T, args ...string) *cliConfig {
+	t.Helper()
+	fs, cfg := newFlagSet("flakehunt")
+	if err := fs.Parse(args); err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	if _, err := loadConfig(fs, cfg); err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	return cfg
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	dir := writeTestConfig(t, testConfig)
+	path := filepath.Join(dir, config.FileNames[0])
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantRuns    int
+		wantTimeout time.Duration
+		wantSources map[string]string
+	}{
+		{
+			name:        "config file over defaults",
+			args:        []string{"--config", path},
+			wantRuns:    20,
+			wantTimeout: 5 * time.Minute,
+			wantSources: map[string]string{"runs": sourceFile, "timeout": sourceFile, "tool": sourceDefault},
+		},
+		{
+			name:        "profile over config file",
+			args:        []string{"--config", path, "--profile", "quick"},
+			wantRuns:    5,
+			wantTimeout: time.Minute,
+			wantSources: map[string]string{"runs": "profile quick", "timeout": "profile quick", "keep-runs": sourceFile},
+		},
+		{
+			name:        "flag over profile",
+			args:        []string{"--config", path, "--profile", "quick", "--runs", "7"},
+			wantRuns:    7,
+			wantTimeout: time.Minute,
+			wantSources: map[string]string{"runs": sourceFlag, "timeout": "profile quick"},
+		},
+		{
+			name:        "flag set to its default over config file",
+			args:        []string{"--config", path, "--timeout", "0s"},
+			wantRuns:    20,
+			wantTimeout: 0,
+			wantSources: map[string]string{"runs": sourceFile, "timeout": sourceFlag},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parseConfig(t, tt.args...)
+			if cfg.runs != tt.wantRuns {
+				t.Errorf("runs = %d, want %d", cfg.runs, tt.wantRuns)
+			}
+			if cfg.timeout != tt.wantTimeout {
+				t.Errorf("timeout = %v, want %v", cfg.timeout, tt.wantTimeout)
+			}
+			if cfg.keepRuns != 3 {
+				t.Errorf("keepRuns = %d, want 3 from the config file", cfg.keepRuns)
+			}
+			for key, want := range tt.wantSources {
+				if got := cfg.sources[key]; got != want {
+					t.Errorf("sources[%q] = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	// An empty config file leaves the flag defaults in place
+	dir := writeTestConfig(t, "{}\n")
+	cfg := parseConfig(t, "--config", filepath.Join(dir, config.FileNames[0]))
+
+	if cfg.outDir != ".flakehunt" {
+		t.Errorf("outDir = %q, want the default .flakehunt", cfg.outDir)
+	}
+	if !cfg.failOnFlake {
+		t.Error("failOnFlake = false, want the default true")
+	}
+	if strings.Join(cfg.formats, ",") != strings.Join(defaultFormats, ",") {
+		t.Errorf("formats = %v, want %v", cfg.formats, defaultFormats)
+	}
+	for _, setting := range configSettings {
+		if got := cfg.sources[setting.key]; got != sourceDefault {
+			t.Errorf("sources[%q] = %q, want %q", setting.key, got, sourceDefault)
+		}
+	}
+}
+
+func TestLoadConfigResolvesPathsFromConfigDir(t *testing.T) {
+	dir := writeTestConfig(t, testConfig)
+	nested := filepath.Join(dir, "packages", "web")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Sessions started from a subdirectory find the config file upward
+	t.Chdir(nested)
+
+	abs := func(path string) string {
+		t.Helper()
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return abs
+	}
+
+	t.Run("relative paths in the config file", func(t *testing.T) {
+		cfg := parseConfig(t)
+		if got, want := abs(cfg.outDir), filepath.Join(dir, "build", "flakehunt"); got != want {
+			t.Errorf("outDir = %q, want %q", got, want)
+		}
+		if got, want := abs(cfg.quarantineFile), filepath.Join(dir, "ci", "quarantine.json"); got != want {
+			t.Errorf("quarantineFile = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("relative flags stay relative to the working directory", func(t *testing.T) {
+		cfg := parseConfig(t, "--out", "tmp")
+		if cfg.outDir != "tmp" {
+			t.Errorf("outDir = %q, want tmp", cfg.outDir)
+		}
+	})
+}
+
+func TestLoadConfigAbsolutePathsUnchanged(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	dir := writeTestConfig(t, "out: "+out+"\n")
+	cfg := parseConfig(t, "--config", filepath.Join(dir, config.FileNames[0]))
+	if cfg.outDir != out {
+		t.Errorf("outDir = %q, want %q", cfg.outDir, out)
+	}
+}
+
+func TestLoadConfigProfileWithoutFile(t *testing.T) {
+	t.Chdir(t.TempDir())
+	fs, cfg := newFlagSet("flakehunt")
+	if err := fs.Parse([]string{"--profile", "quick"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(fs, cfg); err == nil || !strings.Contains(err.Error(), "requires a config file") {
+		t.Errorf("loadConfig() error = %v, want a missing config file error", err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+	"github.com/boyarskiy/flakehunt/internal/notify"
+)
+
+// defaultIssuesLimit caps the tickets filed per session unless configured.
+const defaultIssuesLimit = 10
+
+// runIssues implements the `flakehunt issues` command, which files tickets
+// for the flaky tests of the last session.
+func runIssues(args []string) int {
+	fs, cfg := newFlagSet("flakehunt issues")
+	fs.BoolVar(&cfg.issuesDryRun, "dry-run", false, "Write the tickets to disk instead of filing them")
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if _, err := loadConfig(fs, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if cfg.issues.Provider == "" && !cfg.issuesDryRun {
+		fmt.Fprintln(os.Stderr, "Error: no issue tracker configured: set issues.provider in .flakehunt.yaml")
+		return exitError
+	}
+
+	latestDir := filepath.Join(cfg.outDir, "latest")
+	rpt, err := readLastReport(latestDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	if err := fileIssues(cfg, rpt, latestDir); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitSuccess
+}
+
+// readLastReport reads the report.json of the session in latestDir.
+func readLastReport(latestDir string) (*model.Report, error) {
+	path := filepath.Join(latestDir, "report.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the last session's report (run a session with the json report format first): %w", err)
+	}
+	var rpt model.Report
+	if err := json.Unmarshal(data, &rpt); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &rpt, nil
+}
+
+// fileIssues files a ticket for each flaky test without one, recording the
+// filed tickets in the known flakes file. In a dry run the tickets are
+// written under latestDir/issues instead.
+func fileIssues(cfg *cliConfig, rpt *model.Report, latestDir string) error {
+	statePath := filepath.Join(cfg.outDir, notify.KnownFlakesFile)
+	known, err := notify.LoadKnownFlakes(statePath)
+	if err != nil {
+		return fmt.Errorf("failed to load filed issues: %w", err)
+	}
+
+	provider := cfg.issues.Provider
+	tickets := notify.Tickets(rpt, provider, known, cfg.issuesLimit)
+	if len(tickets) == 0 {
+		fmt.Fprintln(os.Stderr, "No new flaky tests to file issues for.")
+		return nil
+	}
+
+	if cfg.issuesDryRun {
+		dir := filepath.Join(latestDir, "issues")
+		paths, err := notify.WriteTickets(dir, provider, tickets)
+		for _, path := range paths {
+			fmt.Fprintf(os.Stderr, "Wrote issue ticket %s\n", path)
+		}
+		return err
+	}
+
+	icfg := notify.IssuesFromEnv(cfg.issues, os.Getenv)
+	tokenEnv := cmp.Or(cfg.issuesTokenEnv, notify.DefaultIssuesTokenEnv[provider])
+	icfg.Token = os.Getenv(tokenEnv)
+	if icfg.Token == "" {
+		return fmt.Errorf("not filing issues: $%s is not set", tokenEnv)
+	}
+	if cfg.issuesUserEnv != "" {
+		icfg.User = os.Getenv(cfg.issuesUserEnv)
+	}
+	tracker, err := notify.NewTracker(icfg)
+	if err != nil {
+		return fmt.Errorf("not filing issues: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	filed, fileErr := notify.FileIssues(ctx, tracker, tickets, known, time.Now())
+	for _, issue := range filed {
+		fmt.Fprintf(os.Stderr, "Filed issue %s for %s %s\n", issue.Key, issue.TestID, issue.URL)
+	}
+	if len(filed) > 0 {
+		if err := known.Save(statePath); err != nil {
+			return fmt.Errorf("failed to record filed issues: %w", err)
+		}
+	}
+	return fileErr
+}
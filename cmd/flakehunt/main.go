@@ -2,11 +2,14 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
@@ -14,9 +17,15 @@ import (
 	"github.com/boyarskiy/flakehunt/internal/adapters/cypress"
 	"github.com/boyarskiy/flakehunt/internal/adapters/jest"
 	"github.com/boyarskiy/flakehunt/internal/classify"
+	"github.com/boyarskiy/flakehunt/internal/codeowners"
+	"github.com/boyarskiy/flakehunt/internal/config"
+	"github.com/boyarskiy/flakehunt/internal/dashboard"
 	"github.com/boyarskiy/flakehunt/internal/model"
+	"github.com/boyarskiy/flakehunt/internal/notify"
+	"github.com/boyarskiy/flakehunt/internal/quarantine"
 	"github.com/boyarskiy/flakehunt/internal/report"
 	"github.com/boyarskiy/flakehunt/internal/runner"
+	"github.com/boyarskiy/flakehunt/internal/source"
 )
 
 const (
@@ -40,19 +49,18 @@ func run(args []string) int {
 		case "-v", "--version", "version":
 			fmt.Println("flakehunt v1.0.0")
 			return exitSuccess
+		case "config":
+			return runConfig(args[1:])
+		case "signatures":
+			return runSignatures(args[1:])
+		case "issues":
+			return runIssues(args[1:])
+		case "quarantine":
+			return runQuarantine(args[1:])
 		}
 	}
 
-	// Define flags
-	fs := flag.NewFlagSet("flakehunt", flag.ContinueOnError)
-	cfg := &cliConfig{}
-	fs.IntVar(&cfg.runs, "runs", 0, "Number of repetitions (required)")
-	fs.DurationVar(&cfg.timeout, "timeout", 0, "Max total runtime (e.g., \"5m\", \"1h\")")
-	fs.StringVar(&cfg.outDir, "out", ".flakehunt", "Output directory")
-	fs.IntVar(&cfg.keepRuns, "keep-runs", 0, "Number of run directories to keep (0 = keep all)")
-	fs.BoolVar(&cfg.jsonOutput, "json", false, "Print report JSON to stdout")
-	fs.BoolVar(&cfg.failOnFlake, "fail-on-flake", true, "Exit with code 2 if flakes detected")
-	fs.StringVar(&cfg.target, "target", "", "Target description (for reporting)")
+	fs, cfg := newFlagSet("flakehunt")
 
 	// Find the -- separator
 	cmdIdx := findSeparator(args)
@@ -75,22 +83,77 @@ func run(args []string) int {
 		return exitError
 	}
 
+	// Apply the project config file; flags set on the command line take precedence
+	if _, err := loadConfig(fs, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
 	// Validate required flags
 	if cfg.runs <= 0 {
-		fmt.Fprintln(os.Stderr, "Error: --runs is required and must be a positive integer")
+		fmt.Fprintln(os.Stderr, "Error: --runs is required and must be a positive integer (set it with --runs or in .flakehunt.yaml)")
 		return exitError
 	}
 
-	// Auto-detect tool from command
-	tool, adapter, err := detectTool(userCmd)
+	// Use the configured tool, or auto-detect it from the command
+	tool, adapter, err := selectTool(cfg.tool, userCmd)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return exitError
 	}
 
+	// Append configured adapter arguments without mutating the original args
+	if extra := cfg.adapterArgs[string(tool)]; len(extra) > 0 {
+		userCmd = append(append([]string{}, userCmd...), extra...)
+	}
+
 	return execute(cfg, tool, adapter, userCmd)
 }
 
+// newFlagSet defines the session flags on a new FlagSet.
+func newFlagSet(name string) (*flag.FlagSet, *cliConfig) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	cfg := &cliConfig{
+		topN:           5,
+		issuesLimit:    defaultIssuesLimit,
+		quarantineFile: quarantine.DefaultPath,
+		quarantineDays: defaultQuarantineDays,
+	}
+	fs.IntVar(&cfg.runs, "runs", 0, "Number of repetitions (required)")
+	fs.DurationVar(&cfg.timeout, "timeout", 0, "Max total runtime (e.g., \"5m\", \"1h\")")
+	fs.DurationVar(&cfg.testTimeout, "test-timeout", 0, "Per-test timeout of the test tool, used to flag timeout risks (discovered from the tool config if unset)")
+	fs.StringVar(&cfg.outDir, "out", ".flakehunt", "Output directory")
+	fs.IntVar(&cfg.keepRuns, "keep-runs", 0, "Number of run directories to keep (0 = keep all)")
+	fs.BoolVar(&cfg.jsonOutput, "json", false, "Print report JSON to stdout")
+	fs.BoolVar(&cfg.failOnFlake, "fail-on-flake", true, "Exit with code 2 if flakes detected")
+	fs.StringVar(&cfg.target, "target", "", "Target description (for reporting)")
+	fs.BoolVar(&cfg.tui, "tui", true, "Show live progress dashboard (disabled when stdout is not a terminal)")
+	fs.StringVar(&cfg.hooks.BeforeSession, "before-session", "", "Shell command to run once before the first run")
+	fs.StringVar(&cfg.hooks.BeforeRun, "before-run", "", "Shell command to run before each run")
+	fs.StringVar(&cfg.hooks.AfterRun, "after-run", "", "Shell command to run after each run")
+	fs.StringVar(&cfg.hooks.AfterSession, "after-session", "", "Shell command to run once after the last run")
+	fs.StringVar(&cfg.notify.Provider, "notify", "", "Post results as a pull request comment (github or gitlab)")
+	fs.IntVar(&cfg.notify.Number, "notify-pr", 0, "Pull request or merge request to comment on (detected in CI if unset)")
+	fs.StringVar(&cfg.tool, "tool", "", "Test tool (jest or cypress); auto-detected from the command if unset")
+	fs.StringVar(&cfg.configPath, "config", "", "Path to config file (default: search for .flakehunt.yaml upward)")
+	fs.StringVar(&cfg.profile, "profile", "", "Config file profile to apply")
+	return fs, cfg
+}
+
+// selectTool returns the adapter for the configured tool, or detects it from the command.
+func selectTool(name string, cmd []string) (model.Tool, model.Adapter, error) {
+	switch model.Tool(name) {
+	case "":
+		return detectTool(cmd)
+	case model.ToolJest:
+		return model.ToolJest, jest.New(), nil
+	case model.ToolCypress:
+		return model.ToolCypress, cypress.New(), nil
+	default:
+		return "", nil, fmt.Errorf("unknown tool %q. Supported tools: jest, cypress", name)
+	}
+}
+
 // detectTool analyzes the command to determine which test tool is being used.
 func detectTool(cmd []string) (model.Tool, model.Adapter, error) {
 	cmdStr := strings.ToLower(strings.Join(cmd, " "))
@@ -109,11 +172,47 @@ func detectTool(cmd []string) (model.Tool, model.Adapter, error) {
 type cliConfig struct {
 	runs        int
 	timeout     time.Duration
+	testTimeout time.Duration
 	outDir      string
 	keepRuns    int
 	jsonOutput  bool
 	failOnFlake bool
 	target      string
+	tui         bool
+	hooks       runner.Hooks
+	tool        string
+
+	// Config file selection
+	configPath string
+	profile    string
+
+	// Config file only settings
+	signatures  []config.Signature
+	adapterArgs map[string][]string
+	topN        int
+	formats     []string
+
+	// Pull request comment; the token is read from notifyTokenEnv
+	notify         notify.Config
+	notifyTokenEnv string
+	webhooks       []config.Webhook
+
+	// Issue tracker tickets; the credentials are read from the named variables
+	issues         notify.IssuesConfig
+	issuesTokenEnv string
+	issuesUserEnv  string
+	issuesLimit    int
+	issuesDryRun   bool
+
+	// Quarantine file; the reason and output are set by flags only
+	quarantineFile   string
+	quarantineDays   int
+	quarantineOwner  string
+	quarantineReason string
+	quarantineOutput string
+
+	// sources records where each effective setting came from, by config key
+	sources map[string]string
 }
 
 func execute(cfg *cliConfig, tool model.Tool, adapter model.Adapter, userCmd []string) int {
@@ -138,26 +237,63 @@ func execute(cfg *cliConfig, tool model.Tool, adapter model.Adapter, userCmd []s
 		Tool:     tool,
 		Command:  userCmd,
 		Adapter:  adapter,
+		Hooks:    cfg.hooks,
 	}
 
-	// Execute runs
-	fmt.Printf("Running %d iterations with %s...\n\n", cfg.runs, tool)
-	result, err := runner.Run(ctx, runnerCfg)
+	// Merge user-defined failure signatures with the built-in rules
+	rules, err := buildRuleSet(cfg.signatures)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return exitError
 	}
+	runnerCfg.Classify = classify.Options{Rules: rules, Tool: tool}
 
-	// Convert runner results to model.RunResult for aggregation
-	var runResults []model.RunResult
-	for _, rr := range result.RunResults {
-		if rr != nil {
-			runResults = append(runResults, *rr)
+	webhooks, err := buildWebhooks(cfg.webhooks)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	// Tests run from the project root, so stack frames are attributed relative to it
+	if root, err := filepath.Abs(runnerCfg.ProjectDir()); err == nil {
+		runnerCfg.Classify.ProjectRoot = root
+	}
+
+	// Without an explicit test timeout, use the one configured in the test tool
+	testTimeout, timeoutSource := cfg.testTimeout, ""
+	if testTimeout > 0 {
+		timeoutSource = "test-timeout " + cmp.Or(cfg.sources["test-timeout"], sourceFlag)
+	} else if discoverer, ok := adapter.(model.TimeoutDiscoverer); ok {
+		testTimeout, timeoutSource, err = discoverer.DiscoverTimeout(runnerCfg.Classify.ProjectRoot, userCmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to discover test timeout: %v\n", err)
+			testTimeout, timeoutSource = 0, ""
 		}
 	}
+	runnerCfg.Classify.TestTimeout = testTimeout
+
+	// The live dashboard replaces the raw test output, which is still
+	// captured to each run's stdout.txt and stderr.txt
+	var dash *dashboard.Dashboard
+	if cfg.tui && dashboard.IsTerminal(os.Stdout) {
+		dash = dashboard.New(os.Stdout, tool, cfg.runs)
+		runnerCfg.Stdout = io.Discard
+		runnerCfg.Stderr = io.Discard
+		runnerCfg.Observers = append(runnerCfg.Observers, dash)
+		dash.Start()
+	} else {
+		fmt.Printf("Running %d iterations with %s...\n\n", cfg.runs, tool)
+	}
 
-	// Aggregate and classify
-	aggregatedTests := classify.Aggregate(runResults)
+	// Execute runs
+	result, err := runner.Run(ctx, runnerCfg)
+	if dash != nil {
+		dash.Stop()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
 
 	// Build report
 	target := cfg.target
@@ -165,23 +301,91 @@ func execute(cfg *cliConfig, tool model.Tool, adapter model.Adapter, userCmd []s
 		target = fmt.Sprintf("%v", userCmd)
 	}
 
-	rpt := buildReport(string(tool), target, result.RunsExecuted, aggregatedTests)
+	// Show the code around each failure location in the reports
+	if root := runnerCfg.Classify.ProjectRoot; root != "" {
+		source.AttachSnippets(result.Tests, root, source.DefaultContext)
+
+		// Attribute tests to their owners in CODEOWNERS
+		warnings, err := codeowners.Attach(result.Tests, root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to read test owners: %v\n", err)
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+	}
+
+	rpt := buildReport(string(tool), target, result.RunsExecuted, result.Tests)
+	rpt.InfraErrors = result.InfraErrors
+	rpt.TestTimeout = testTimeout
+	rpt.TestTimeoutSource = timeoutSource
+	rpt.ProjectRoot = runnerCfg.Classify.ProjectRoot
+	rpt.Command = userCmd
 
 	// Write reports
-	if err := report.WriteJSON(result.LatestDir, rpt); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to write JSON report: %v\n", err)
+	if hasFormat(cfg.formats, formatJSON) {
+		if err := report.WriteJSON(result.LatestDir, rpt); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write JSON report: %v\n", err)
+		}
 	}
 
-	if err := report.WriteMarkdown(result.LatestDir, rpt); err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to write Markdown report: %v\n", err)
+	if hasFormat(cfg.formats, formatMarkdown) {
+		if err := report.WriteMarkdown(result.LatestDir, rpt); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write Markdown report: %v\n", err)
+		}
+	}
+
+	if hasFormat(cfg.formats, formatHTML) {
+		if err := report.WriteHTML(result.LatestDir, rpt); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write HTML report: %v\n", err)
+		}
+	}
+
+	if hasFormat(cfg.formats, formatJUnit) {
+		if err := report.WriteJUnit(result.LatestDir, rpt); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write JUnit report: %v\n", err)
+		}
+	}
+
+	if hasFormat(cfg.formats, formatSARIF) {
+		if err := report.WriteSARIF(result.LatestDir, rpt); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to write SARIF report: %v\n", err)
+		}
 	}
 
 	// Render terminal output
 	termCfg := report.DefaultTerminalConfig(os.Stdout)
+	if cfg.topN > 0 {
+		termCfg.TopN = cfg.topN
+	}
 	if err := report.RenderTerminal(termCfg, rpt, result.LatestDir); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to render terminal output: %v\n", err)
 	}
 
+	// Annotate the workflow when running in GitHub Actions. Annotations go to
+	// stderr when stdout carries JSON; the runner reads commands from both.
+	if report.InGitHubActions() {
+		annotations := os.Stdout
+		if cfg.jsonOutput {
+			annotations = os.Stderr
+		}
+		if err := report.WriteGitHubActions(annotations, rpt); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to report to GitHub Actions: %v\n", err)
+		}
+	}
+
+	if cfg.notify.Provider != "" {
+		postComment(cfg, rpt)
+	}
+	if len(webhooks) > 0 {
+		sendWebhooks(cfg, webhooks, rpt)
+	}
+	if cfg.issues.Provider != "" {
+		if err := fileIssues(cfg, rpt, result.LatestDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
 	// Print JSON to stdout if requested
 	if cfg.jsonOutput {
 		data, err := report.MarshalJSON(rpt)
@@ -205,7 +409,7 @@ func buildReport(tool, target string, runsExecuted int, tests []model.Aggregated
 	stableCount := 0
 	detFailCount := 0
 
-	for _, t := range tests {
+	for _, t := range classify.FilterTests(tests) {
 		switch t.Classification {
 		case model.ClassificationFlaky:
 			flakyCount++
@@ -216,6 +420,10 @@ func buildReport(tool, target string, runsExecuted int, tests []model.Aggregated
 		}
 	}
 
+	suites := classify.FilterSuites(tests)
+	flakySuiteCount := len(classify.FilterByClassification(suites, model.ClassificationFlaky))
+	detFailSuiteCount := len(classify.FilterByClassification(suites, model.ClassificationDeterministicFail))
+
 	// Get top flakes
 	topFlakes := classify.TopFlakes(tests, 10)
 
@@ -223,15 +431,19 @@ func buildReport(tool, target string, runsExecuted int, tests []model.Aggregated
 	signatureSummary := classify.SignatureSummary(tests)
 
 	return &model.Report{
-		Tool:             tool,
-		Target:           target,
-		RunsExecuted:     runsExecuted,
-		FlakyCount:       flakyCount,
-		StableCount:      stableCount,
-		DetFailCount:     detFailCount,
-		Tests:            tests,
-		TopFlakes:        topFlakes,
-		SignatureSummary: signatureSummary,
+		Tool:              tool,
+		Target:            target,
+		RunsExecuted:      runsExecuted,
+		FlakyCount:        flakyCount,
+		StableCount:       stableCount,
+		DetFailCount:      detFailCount,
+		FlakySuiteCount:   flakySuiteCount,
+		DetFailSuiteCount: detFailSuiteCount,
+		Tests:             tests,
+		TopFlakes:         topFlakes,
+		SignatureSummary:  signatureSummary,
+		CoFailureGroups:   classify.CoFailureGroups(tests),
+		Owners:            codeowners.Summarize(tests),
 	}
 }
 
@@ -249,22 +461,74 @@ func printUsage() {
 
 Usage:
   flakehunt [flags] -- <test command>
+  flakehunt config show [flags]
+  flakehunt signatures test [flags] <message>
+  flakehunt signatures list [flags]
+  flakehunt issues [--dry-run] [flags]
+  flakehunt quarantine <add|remove|list|apply> [flags]
 
 The test tool (Jest or Cypress) is auto-detected from the command.
 
+Defaults for every flag can be set in a .flakehunt.yaml file, found by
+searching from the working directory upward. Flags override the config file.
+
 Flags:
   --runs <n>        Number of repetitions (required)
   --timeout <dur>   Max total runtime (e.g., "5m", "1h")
+  --test-timeout <dur>
+                    Per-test timeout of the test tool; tests whose p95 duration
+                    is within 20% of it are reported as timeout risks. Discovered
+                    from the Jest or Cypress config if unset
   --out <path>      Output directory (default: .flakehunt)
   --keep-runs <n>   Number of run directories to keep (0 = keep all)
   --json            Print report JSON to stdout
   --fail-on-flake   Exit with code 2 if flakes detected (default: true)
   --target <desc>   Target description for reporting
+  --tui             Show live progress dashboard (default: true, disabled when
+                    stdout is not a terminal)
+  --tool <name>     Test tool (jest or cypress), skipping auto-detection
+  --config <path>   Config file (default: search for .flakehunt.yaml upward)
+  --profile <name>  Config file profile to apply
+  --notify <name>   Post results as a pull request comment (github or gitlab)
+  --notify-pr <n>   Pull request or merge request to comment on (detected in
+                    CI if unset)
+
+Hooks (shell commands run from the project root):
+  --before-session <cmd>  Run once before the first run
+  --before-run <cmd>      Run before each run
+  --after-run <cmd>       Run after each run
+  --after-session <cmd>   Run once after the last run
+
+  Hooks receive FLAKEHUNT_TOTAL_RUNS and FLAKEHUNT_OUT_DIR; run hooks also
+  receive FLAKEHUNT_RUN_INDEX and FLAKEHUNT_RUN_DIR. Hook output is saved under
+  hooks/ in the session or run directory. Hook failures are reported as
+  infrastructure errors, not test failures.
+
+Commands:
+  config show       Print the effective configuration after merging the
+                    config file, the selected profile and flags
+  signatures test   Show which signature rule matches a failure message
+                    (use - to read the message from stdin)
+  signatures list   List signature rules in the order they are checked
+  issues            File issue tracker tickets for the flaky tests of the
+                    last session that have none (--dry-run writes them under
+                    latest/issues instead)
+  quarantine add    Quarantine the given tests, or the flaky tests of the last
+                    session, in .flakehunt-quarantine.yaml for --days (default
+                    30); adding a test again renews it
+  quarantine remove Release the given tests from quarantine
+  quarantine list   List quarantined tests
+  quarantine apply  Generate the Jest setup file or Cypress support file that
+                    skips quarantined tests outside flakehunt (--tool jest or
+                    cypress). list and apply fail if an entry has expired
 
 Examples:
   flakehunt --runs 10 -- npx jest src/utils.test.ts
   flakehunt --runs 5 -- npx cypress run --spec "cypress/e2e/login.cy.js"
   flakehunt --runs 20 --timeout 5m -- npm test
+  flakehunt --runs 10 --before-run "npm run db:reset" -- npx cypress run
+  flakehunt --profile quick -- npx jest
+  flakehunt config show --profile ci-like
 
 Exit codes:
   0  No flakes detected
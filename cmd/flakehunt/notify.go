@@ -0,0 +1,106 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/config"
+	"github.com/boyarskiy/flakehunt/internal/model"
+	"github.com/boyarskiy/flakehunt/internal/notify"
+)
+
+// notifyTimeout bounds posting the pull request comment and webhooks.
+const notifyTimeout = 30 * time.Second
+
+// postComment posts the report as a pull request comment. Failures are only
+// warnings, so that they never change the exit code.
+func postComment(cfg *cliConfig, rpt *model.Report) {
+	ncfg := notify.FromEnv(cfg.notify, os.Getenv)
+	tokenEnv := cmp.Or(cfg.notifyTokenEnv, notify.DefaultTokenEnv[ncfg.Provider])
+	ncfg.Token = os.Getenv(tokenEnv)
+	if ncfg.Token == "" {
+		fmt.Fprintf(os.Stderr, "Warning: not posting a pull request comment: $%s is not set\n", tokenEnv)
+		return
+	}
+
+	notifier, err := notify.New(ncfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: not posting a pull request comment: %v\n", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	if err := notifier.Notify(ctx, rpt); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to post pull request comment: %v\n", err)
+	}
+}
+
+// buildWebhooks creates the configured webhooks, so that invalid templates
+// are reported before the session starts. Webhooks whose URL variable is
+// unset are skipped with a warning.
+func buildWebhooks(hooks []config.Webhook) ([]*notify.Webhook, error) {
+	var webhooks []*notify.Webhook
+	for i, hook := range hooks {
+		url := hook.URL
+		if hook.URLEnv != "" {
+			url = os.Getenv(hook.URLEnv)
+			if url == "" {
+				fmt.Fprintf(os.Stderr, "Warning: skipping webhooks[%d]: $%s is not set\n", i, hook.URLEnv)
+				continue
+			}
+		}
+		webhook, err := notify.NewWebhook(notify.WebhookConfig{
+			URL:      url,
+			Format:   hook.Format,
+			When:     hook.When,
+			Template: hook.Template,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("webhooks[%d]: %w", i, err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// sendWebhooks sends the session summary to the webhooks, marking flakes
+// missing from the known flakes file as new, and then records them in it.
+// Sending is best effort: the flakes are recorded even if a webhook failed,
+// so that a broken webhook does not make every later session report them as
+// new to the others. Failures are only warnings, so that they never change
+// the exit code.
+func sendWebhooks(cfg *cliConfig, webhooks []*notify.Webhook, rpt *model.Report) {
+	statePath := filepath.Join(cfg.outDir, notify.KnownFlakesFile)
+	known, err := notify.LoadKnownFlakes(statePath)
+	if err != nil {
+		// Without the state no flake counts as new; keep the file for inspection
+		fmt.Fprintf(os.Stderr, "Warning: failed to load known flakes: %v\n", err)
+	}
+	summary := notify.Summarize(rpt, known)
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	failed := false
+	for _, webhook := range webhooks {
+		if err := webhook.Send(ctx, summary); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			failed = true
+		}
+	}
+
+	if known == nil {
+		return
+	}
+	if failed && summary.NewFlakyCount > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: recording %d new flakes as known although a webhook failed; later sessions will not report them as new\n", summary.NewFlakyCount)
+	}
+	known.Update(rpt, time.Now())
+	if err := known.Save(statePath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save known flakes: %v\n", err)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/boyarskiy/flakehunt/internal/config"
+	"github.com/boyarskiy/flakehunt/internal/model"
+	"github.com/boyarskiy/flakehunt/internal/notify"
+)
+
+func TestSendWebhooksRecordsFlakesWhenAWebhookFails(t *testing.T) {
+	var received int
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer ok.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "token revoked", http.StatusForbidden)
+	}))
+	defer broken.Close()
+
+	webhooks, err := buildWebhooks([]config.Webhook{{URL: broken.URL}, {URL: ok.URL}})
+	if err != nil {
+		t.Fatalf("buildWebhooks() error = %v", err)
+	}
+
+	flake := model.AggregatedTest{
+		TestID:         "/work/shop/src/a.test.js::A works",
+		Classification: model.ClassificationFlaky,
+		PassCount:      1,
+		FailCount:      1,
+		TotalRuns:      2,
+		FlakeRate:      0.5,
+	}
+	rpt := &model.Report{
+		Tool:         "jest",
+		RunsExecuted: 2,
+		FlakyCount:   1,
+		ProjectRoot:  "/work/shop",
+		Tests:        []model.AggregatedTest{flake},
+		TopFlakes:    []model.AggregatedTest{flake},
+	}
+	cfg := &cliConfig{outDir: t.TempDir()}
+	statePath := filepath.Join(cfg.outDir, notify.KnownFlakesFile)
+
+	sendWebhooks(cfg, webhooks, rpt)
+	if received != 1 {
+		t.Fatalf("working webhook received %d messages, want 1", received)
+	}
+	known, err := notify.LoadKnownFlakes(statePath)
+	if err != nil {
+		t.Fatalf("LoadKnownFlakes() error = %v", err)
+	}
+	if !known.IsKnown(flake.TestID, rpt.ProjectRoot) {
+		t.Fatal("flake not recorded as known after a webhook failed")
+	}
+
+	// The next session has no new flakes, so new-flakes webhooks stay quiet
+	sendWebhooks(cfg, webhooks, rpt)
+	if received != 1 {
+		t.Errorf("working webhook received %d messages, want the known flake not to be sent again", received)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+	"github.com/boyarskiy/flakehunt/internal/quarantine"
+)
+
+// defaultQuarantineDays is how long tests stay quarantined unless configured.
+const defaultQuarantineDays = 30
+
+const quarantineUsage = `Usage:
+  flakehunt quarantine add [--reason <text>] [--owner <name>] [--days <n>] [test IDs...]
+  flakehunt quarantine remove <test IDs...>
+  flakehunt quarantine list
+  flakehunt quarantine apply --tool <jest|cypress> [--output <path>]`
+
+// runQuarantine implements the `flakehunt quarantine` command, which
+// maintains the quarantine file and generates the files that make the test
+// tool skip quarantined tests.
+func runQuarantine(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, quarantineUsage)
+		return exitError
+	}
+
+	var run func(cfg *cliConfig, args []string) error
+	fs, cfg := newFlagSet("flakehunt quarantine " + args[0])
+	fs.StringVar(&cfg.quarantineFile, "file", cfg.quarantineFile, "Quarantine file")
+	switch args[0] {
+	case "add":
+		fs.StringVar(&cfg.quarantineOwner, "owner", "", "Owner recorded on the entries")
+		fs.IntVar(&cfg.quarantineDays, "days", cfg.quarantineDays, "Days until the entries expire")
+		fs.StringVar(&cfg.quarantineReason, "reason", "", "Reason recorded on the entries (default: the flake rate and failure)")
+		run = quarantineAdd
+	case "remove":
+		run = quarantineRemove
+	case "list":
+		run = quarantineList
+	case "apply":
+		fs.StringVar(&cfg.quarantineOutput, "output", "", "Generated file (default: flakehunt-quarantine.<tool>.js next to the quarantine file)")
+		run = quarantineApply
+	default:
+		fmt.Fprintln(os.Stderr, quarantineUsage)
+		return exitError
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if _, err := loadConfig(fs, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if err := run(cfg, fs.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitSuccess
+}
+
+// quarantineAdd quarantines the given tests, or the flaky tests of the last
+// session if none are given. Quarantining a test again renews its entry.
+func quarantineAdd(cfg *cliConfig, ids []string) error {
+	if cfg.quarantineDays <= 0 {
+		return fmt.Errorf("--days must be a positive integer, got %d", cfg.quarantineDays)
+	}
+	file, err := quarantine.Load(cfg.quarantineFile)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	ttl := time.Duration(cfg.quarantineDays) * 24 * time.Hour
+	var entries []quarantine.Entry
+	if len(ids) == 0 {
+		rpt, err := readLastReport(filepath.Join(cfg.outDir, "latest"))
+		if err != nil {
+			return err
+		}
+		entries = quarantine.FlakyEntries(rpt, cfg.quarantineReason, cfg.quarantineOwner, now, ttl)
+		if len(entries) == 0 {
+			fmt.Fprintln(os.Stderr, "The last session found no flaky tests to quarantine.")
+			return nil
+		}
+	} else {
+		for _, id := range ids {
+			entries = append(entries, quarantine.NewEntry(id, cfg.quarantineReason, cfg.quarantineOwner, now, ttl))
+		}
+	}
+
+	for _, e := range entries {
+		if file.Add(e) {
+			fmt.Fprintf(os.Stderr, "Quarantined %s until %s\n", e.TestID, e.Expires)
+		} else {
+			fmt.Fprintf(os.Stderr, "Renewed %s until %s\n", e.TestID, e.Expires)
+		}
+	}
+	return file.Save(cfg.quarantineFile)
+}
+
+// quarantineRemove releases the given tests from quarantine.
+func quarantineRemove(cfg *cliConfig, ids []string) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("no test IDs given\n%s", quarantineUsage)
+	}
+	file, err := quarantine.Load(cfg.quarantineFile)
+	if err != nil {
+		return err
+	}
+	if removed := file.Remove(ids...); removed < len(ids) {
+		fmt.Fprintf(os.Stderr, "Warning: %d of %d tests were not quarantined\n", len(ids)-removed, len(ids))
+	}
+	return file.Save(cfg.quarantineFile)
+}
+
+// quarantineList prints the quarantined tests. It fails if any entry has
+// expired.
+func quarantineList(cfg *cliConfig, _ []string) error {
+	file, err := quarantine.Load(cfg.quarantineFile)
+	if err != nil {
+		return err
+	}
+	if len(file.Tests) == 0 {
+		fmt.Printf("No tests quarantined in %s\n", cfg.quarantineFile)
+		return nil
+	}
+
+	expired := make(map[string]bool)
+	for _, e := range file.Expired(time.Now()) {
+		expired[e.TestID] = true
+	}
+	fmt.Printf("%-10s %-12s %-16s %s\n", "EXPIRES", "ADDED", "OWNER", "TEST")
+	for _, e := range file.Tests {
+		expires := e.Expires
+		if expired[e.TestID] {
+			expires = "expired"
+		}
+		fmt.Printf("%-10s %-12s %-16s %s\n", expires, e.Added, e.Owner, e.TestID)
+		if e.Reason != "" {
+			fmt.Printf("%41s%s\n", "", e.Reason)
+		}
+	}
+	return checkExpired(file)
+}
+
+// quarantineApply writes the file that makes the test tool skip the
+// quarantined tests. It fails if any entry has expired.
+func quarantineApply(cfg *cliConfig, _ []string) error {
+	if cfg.tool == "" {
+		return fmt.Errorf("--tool is required (jest or cypress)")
+	}
+	tool, adapter, err := selectTool(cfg.tool, nil)
+	if err != nil {
+		return err
+	}
+	renderer, ok := adapter.(model.QuarantineRenderer)
+	if !ok {
+		return fmt.Errorf("%s does not support quarantine files", tool)
+	}
+
+	file, err := quarantine.Load(cfg.quarantineFile)
+	if err != nil {
+		return err
+	}
+	if err := checkExpired(file); err != nil {
+		return err
+	}
+
+	output := cfg.quarantineOutput
+	if output == "" {
+		output = filepath.Join(filepath.Dir(cfg.quarantineFile), fmt.Sprintf("flakehunt-quarantine.%s.js", tool))
+	}
+	content, usage, err := renderer.RenderQuarantine(file.Expiries(), filepath.Base(cfg.quarantineFile), output)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(output, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s skipping %d quarantined %s\n", output, len(file.Tests), pluralTests(len(file.Tests)))
+	fmt.Fprintln(os.Stderr, usage)
+	return nil
+}
+
+// checkExpired returns an error listing the expired entries of file, so
+// that quarantined tests are re-evaluated instead of skipped forever.
+func checkExpired(file *quarantine.File) error {
+	expired := file.Expired(time.Now())
+	if len(expired) == 0 {
+		return nil
+	}
+	lines := make([]string, len(expired))
+	for i, e := range expired {
+		lines[i] = fmt.Sprintf("  %s (expired %s)", e.TestID, e.Expires)
+	}
+	return fmt.Errorf("%d quarantined %s expired; re-evaluate them with flakehunt, then renew them with `flakehunt quarantine add <id>` or release them with `flakehunt quarantine remove <id>`:\n%s",
+		len(expired), pluralTests(len(expired)), strings.Join(lines, "\n"))
+}
+
+// pluralTests returns "test" or "tests" for n.
+func pluralTests(n int) string {
+	if n == 1 {
+		return "test"
+	}
+	return "tests"
+}
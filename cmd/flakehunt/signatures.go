@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/classify"
+	"github.com/boyarskiy/flakehunt/internal/config"
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// buildRuleSet compiles the configured signatures and merges them with the built-in rules.
+func buildRuleSet(signatures []config.Signature) (*classify.RuleSet, error) {
+	rules := make([]classify.Rule, 0, len(signatures))
+	for _, sig := range signatures {
+		rule, err := classify.NewRule(sig.Name, sig.Patterns, sig.Contains, sig.Priority, sig.Tools)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return classify.NewRuleSet(rules), nil
+}
+
+// runSignatures implements the `flakehunt signatures` command.
+func runSignatures(args []string) int {
+	if len(args) == 0 || (args[0] != "test" && args[0] != "list") {
+		fmt.Fprintln(os.Stderr, "Usage: flakehunt signatures test [--tool <name>] [--profile <name>] <message|->")
+		fmt.Fprintln(os.Stderr, "       flakehunt signatures list [--tool <name>] [--profile <name>]")
+		return exitError
+	}
+	subcommand := args[0]
+
+	fs, cfg := newFlagSet("flakehunt signatures " + subcommand)
+	if err := fs.Parse(args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	if _, err := loadConfig(fs, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	rules, err := buildRuleSet(cfg.signatures)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	tool := model.Tool(cfg.tool)
+
+	if subcommand == "list" {
+		printRules(os.Stdout, rules.Rules(), tool)
+		return exitSuccess
+	}
+
+	message := strings.Join(fs.Args(), " ")
+	if message == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to read message from stdin: %v\n", err)
+			return exitError
+		}
+		message = string(data)
+	}
+	if strings.TrimSpace(message) == "" {
+		fmt.Fprintln(os.Stderr, "Error: failure message required")
+		return exitError
+	}
+
+	matches := rules.Matches(message, tool)
+	if len(matches) == 0 {
+		fmt.Printf("Signature: %s (no rule matched)\n", model.SignatureUnknown)
+		return exitSuccess
+	}
+
+	winner := matches[0]
+	fmt.Printf("Signature: %s\n", winner.Rule.Signature)
+	fmt.Printf("Matched by: %s rule, priority %d, %s\n", ruleOrigin(winner.Rule), winner.Rule.Priority, describeMatcher(winner))
+
+	if len(matches) > 1 {
+		fmt.Println()
+		fmt.Println("Also matched (lower precedence):")
+		for _, m := range matches[1:] {
+			fmt.Printf("  %-16s %s rule, priority %d, %s\n", m.Rule.Signature, ruleOrigin(m.Rule), m.Rule.Priority, describeMatcher(m))
+		}
+	}
+
+	return exitSuccess
+}
+
+// printRules lists rules in the order they are checked.
+func printRules(w io.Writer, rules []classify.Rule, tool model.Tool) {
+	fmt.Fprintf(w, "%-4s %-16s %-8s %-9s %-14s %s\n", "#", "SIGNATURE", "PRIORITY", "ORIGIN", "TOOLS", "MATCHERS")
+	n := 0
+	for _, rule := range rules {
+		if !rule.AppliesTo(tool) {
+			continue
+		}
+		n++
+
+		tools := "all"
+		if len(rule.Tools) > 0 {
+			names := make([]string, len(rule.Tools))
+			for i, t := range rule.Tools {
+				names[i] = string(t)
+			}
+			tools = strings.Join(names, ",")
+		}
+
+		var matchers []string
+		for _, p := range rule.Patterns {
+			matchers = append(matchers, "/"+p.String()+"/")
+		}
+		for _, c := range rule.Contains {
+			matchers = append(matchers, fmt.Sprintf("%q", c))
+		}
+
+		fmt.Fprintf(w, "%-4d %-16s %-8d %-9s %-14s %s\n", n, rule.Signature, rule.Priority, ruleOrigin(rule), tools, strings.Join(matchers, " "))
+	}
+}
+
+// ruleOrigin describes whether a rule is built in or user-defined.
+func ruleOrigin(rule classify.Rule) string {
+	if rule.Builtin {
+		return "built-in"
+	}
+	return "custom"
+}
+
+// describeMatcher describes the pattern or substring that matched.
+func describeMatcher(m classify.Match) string {
+	for _, p := range m.Rule.Patterns {
+		if p.String() == m.Matcher {
+			return fmt.Sprintf("pattern /%s/", m.Matcher)
+		}
+	}
+	return fmt.Sprintf("substring %q", m.Matcher)
+}
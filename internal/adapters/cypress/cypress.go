@@ -2,14 +2,20 @@
 package cypress
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/boyarskiy/flakehunt/internal/adapters/jsconfig"
 	"github.com/boyarskiy/flakehunt/internal/model"
 )
 
@@ -61,6 +67,24 @@ type JUnitSkipped struct {
 	Message string `xml:"message,attr"`
 }
 
+// defaultCommandTimeout is Cypress's default defaultCommandTimeout.
+const defaultCommandTimeout = 4 * time.Second
+
+// Run subdirectories that Cypress writes screenshots and videos to.
+const (
+	screenshotsDirName = "screenshots"
+	videosDirName      = "videos"
+)
+
+// configFiles are the Cypress configuration files, in the order Cypress resolves them.
+var configFiles = []string{
+	"cypress.config.ts",
+	"cypress.config.js",
+	"cypress.config.mjs",
+	"cypress.config.cjs",
+	"cypress.json",
+}
+
 // Adapter implements the model.Adapter interface for Cypress.
 type Adapter struct{}
 
@@ -88,11 +112,78 @@ func (a *Adapter) BuildCommand(runDir string, userCmd []string) []string {
 	result = append(result, "--reporter", "junit")
 	result = append(result, "--reporter-options", reporterOpts)
 
-	return result
+	// Keep screenshots and videos of each run instead of overwriting them
+	result = withMediaFolders(result, runDir)
+
+	// Run quarantined tests too
+	return withFlakehuntEnv(result)
+}
+
+// withMediaFolders points screenshotsFolder and videosFolder into runDir,
+// merging them into a --config flag the user already passed.
+func withMediaFolders(args []string, runDir string) []string {
+	return withFlagValues(args, "--config", "-c", [][2]string{
+		{"screenshotsFolder", filepath.Join(runDir, screenshotsDirName)},
+		{"videosFolder", filepath.Join(runDir, videosDirName)},
+	})
+}
+
+// withFlakehuntEnv sets the FLAKEHUNT Cypress environment variable, which
+// tells quarantine support files to run every test, merging it into an
+// --env flag the user already passed.
+func withFlakehuntEnv(args []string) []string {
+	return withFlagValues(args, "--env", "-e", [][2]string{{"FLAKEHUNT", "1"}})
+}
+
+// withFlagValues sets the key/value pairs in the last long or short flag of
+// args, which holds comma-separated pairs or a JSON object, appending the
+// flag if the user did not pass it.
+func withFlagValues(args []string, long, short string, values [][2]string) []string {
+	pairs := make([]string, len(values))
+	for i, kv := range values {
+		pairs[i] = kv[0] + "=" + kv[1]
+	}
+	joined := strings.Join(pairs, ",")
+
+	for i := len(args) - 1; i >= 0; i-- {
+		var value string
+		var set func(string)
+		switch {
+		case (args[i] == long || args[i] == short) && i+1 < len(args):
+			value, set = args[i+1], func(v string) { args[i+1] = v }
+		case strings.HasPrefix(args[i], long+"="):
+			value, set = strings.TrimPrefix(args[i], long+"="), func(v string) { args[i] = long + "=" + v }
+		default:
+			continue
+		}
+
+		if strings.HasPrefix(strings.TrimSpace(value), "{") {
+			// JSON object
+			var object map[string]any
+			if err := json.Unmarshal([]byte(value), &object); err != nil {
+				// Leave a value we cannot parse to Cypress to report
+				return args
+			}
+			for _, kv := range values {
+				object[kv[0]] = kv[1]
+			}
+			data, err := json.Marshal(object)
+			if err != nil {
+				return args
+			}
+			set(string(data))
+		} else {
+			set(value + "," + joined)
+		}
+		return args
+	}
+
+	return append(args, long, joined)
 }
 
 // Parse reads all XML files from runDir and returns aggregated test results.
-// If the same test appears multiple times (retries), the last occurrence wins.
+// If the same test appears multiple times (retries), every attempt is kept
+// and the last one determines the outcome.
 func (a *Adapter) Parse(runDir string) (*model.RunResult, error) {
 	// Find all XML files in runDir
 	xmlFiles, err := filepath.Glob(filepath.Join(runDir, "*.xml"))
@@ -107,9 +198,9 @@ func (a *Adapter) Parse(runDir string) (*model.RunResult, error) {
 	// Sort files for deterministic processing order
 	sort.Strings(xmlFiles)
 
-	// Map to track test results, using TestID as key
-	// Last occurrence wins for handling retries
-	testMap := make(map[string]model.TestResult)
+	// Attempts of each test in order of appearance; retried tests appear
+	// once per attempt
+	attempts := make(map[string][]model.Attempt)
 
 	for _, xmlFile := range xmlFiles {
 		testCases, err := parseXMLFile(xmlFile)
@@ -123,34 +214,38 @@ func (a *Adapter) Parse(runDir string) (*model.RunResult, error) {
 				return nil, fmt.Errorf("invalid test case in %s: missing classname or name attribute", xmlFile)
 			}
 
-			result := model.TestResult{
-				TestID:   testID,
+			attempt := model.Attempt{
 				Outcome:  determineOutcome(tc),
 				Duration: time.Duration(tc.Time * float64(time.Second)),
 			}
 
 			// Extract failure message if present
 			if tc.Failure != nil {
-				result.FailureMessage = extractFailureMessage(tc.Failure.Message, tc.Failure.Content)
+				attempt.FailureMessage = extractFailureMessage(tc.Failure.Message, tc.Failure.Content)
+				attempt.StackTrace = strings.TrimSpace(tc.Failure.Content)
 			} else if tc.Error != nil {
-				result.FailureMessage = extractFailureMessage(tc.Error.Message, tc.Error.Content)
+				attempt.FailureMessage = extractFailureMessage(tc.Error.Message, tc.Error.Content)
+				attempt.StackTrace = strings.TrimSpace(tc.Error.Content)
 			}
 
-			// Last occurrence wins (handles retries)
-			testMap[testID] = result
+			attempts[testID] = append(attempts[testID], attempt)
 		}
 	}
 
 	// Build results in deterministic order (sorted by TestID)
-	testIDs := make([]string, 0, len(testMap))
-	for id := range testMap {
+	testIDs := make([]string, 0, len(attempts))
+	for id := range attempts {
 		testIDs = append(testIDs, id)
 	}
 	sort.Strings(testIDs)
 
 	tests := make([]model.TestResult, 0, len(testIDs))
 	for _, id := range testIDs {
-		tests = append(tests, testMap[id])
+		tests = append(tests, buildResult(id, attempts[id]))
+	}
+
+	if err := attachMedia(runDir, tests); err != nil {
+		return nil, err
 	}
 
 	return &model.RunResult{
@@ -158,6 +253,150 @@ func (a *Adapter) Parse(runDir string) (*model.RunResult, error) {
 	}, nil
 }
 
+// buildResult combines the attempts of a test into its result. The last
+// attempt is the final outcome, since Cypress stops retrying once a test
+// passes.
+func buildResult(testID string, attempts []model.Attempt) model.TestResult {
+	last := attempts[len(attempts)-1]
+	result := model.TestResult{
+		TestID:         testID,
+		Outcome:        last.Outcome,
+		Duration:       last.Duration,
+		FailureMessage: last.FailureMessage,
+		StackTrace:     last.StackTrace,
+	}
+	if len(attempts) == 1 {
+		return result
+	}
+
+	result.Attempts = attempts
+	for _, attempt := range attempts {
+		if attempt.Outcome != last.Outcome && attempt.Outcome != model.OutcomeSkip {
+			result.FlakyInRun = true
+			break
+		}
+	}
+	return result
+}
+
+// failedScreenshotPattern matches the file names Cypress gives screenshots
+// taken on failure: "<titles> (failed).png" or "<titles> (failed) (attempt 2).png".
+var failedScreenshotPattern = regexp.MustCompile(`^(.*) \(failed\)(?: \(attempt \d+\))?\.png$`)
+
+// attachMedia associates the screenshots and videos Cypress wrote to runDir
+// with the tests that failed in the run. Screenshots are stored under
+// screenshots/<spec>/ and named after the test's titles joined by " -- ";
+// videos are stored as videos/<spec>.mp4. Paths are set relative to runDir.
+func attachMedia(runDir string, tests []model.TestResult) error {
+	screenshots := make(map[string][]string) // spec -> screenshot paths
+	screenshotsDir := filepath.Join(runDir, screenshotsDirName)
+	err := filepath.WalkDir(screenshotsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || !failedScreenshotPattern.MatchString(d.Name()) {
+			return nil
+		}
+		spec, err := filepath.Rel(screenshotsDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		screenshots[filepath.ToSlash(spec)] = append(screenshots[filepath.ToSlash(spec)], path)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read screenshots in %s: %w", screenshotsDir, err)
+	}
+
+	videosDir := filepath.Join(runDir, videosDirName)
+	videos, _ := filepath.Glob(filepath.Join(videosDir, "*.mp4"))
+	nested, _ := filepath.Glob(filepath.Join(videosDir, "*", "*.mp4"))
+	videos = append(videos, nested...)
+
+	for i := range tests {
+		test := &tests[i]
+		if test.Outcome != model.OutcomeFail && !test.FlakyInRun {
+			continue
+		}
+		spec, name, _ := strings.Cut(test.TestID, "::")
+
+		for dir, paths := range screenshots {
+			if !specMatches(spec, dir) {
+				continue
+			}
+			for _, path := range paths {
+				m := failedScreenshotPattern.FindStringSubmatch(filepath.Base(path))
+				if titleMatches(name, m[1]) {
+					rel, _ := filepath.Rel(runDir, path)
+					test.Screenshots = append(test.Screenshots, filepath.ToSlash(rel))
+				}
+			}
+		}
+		sort.Strings(test.Screenshots)
+
+		for _, path := range videos {
+			rel, _ := filepath.Rel(videosDir, path)
+			if specMatches(spec, strings.TrimSuffix(filepath.ToSlash(rel), ".mp4")) {
+				rel, _ = filepath.Rel(runDir, path)
+				test.Video = filepath.ToSlash(rel)
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// specMatches reports whether a media path derived from a spec, which Cypress
+// makes relative to the common ancestor of all specs, refers to spec.
+func specMatches(spec, mediaSpec string) bool {
+	spec = filepath.ToSlash(spec)
+	return spec == mediaSpec || strings.HasSuffix(spec, "/"+mediaSpec)
+}
+
+// truncatedTitleLen is the screenshot title length from which Cypress may
+// have truncated the title.
+const truncatedTitleLen = 200
+
+// titleMatches reports whether a screenshot title belongs to the test name.
+// Cypress joins the titles of the test and its suites with " -- " and strips
+// characters that are unsafe in file names, so only letters and digits are
+// compared. The title may include suite titles the name lacks, so the name
+// must match the title from one of its " -- " boundaries to the end; long
+// titles may be truncated.
+func titleMatches(name, title string) bool {
+	n := alphanumeric(name)
+	if n == "" {
+		return false
+	}
+	truncated := len(title) >= truncatedTitleLen
+	segments := strings.Split(title, " -- ")
+	for i := range segments {
+		t := alphanumeric(strings.Join(segments[i:], " -- "))
+		if t == "" {
+			continue
+		}
+		if t == n || (truncated && strings.HasPrefix(n, t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// alphanumeric returns the lowercase letters and digits of s.
+func alphanumeric(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
 // ExpectedArtifact returns the run directory path.
 // Cypress produces multiple XML files, so we verify the directory exists
 // and contains at least one XML file during parsing.
@@ -165,6 +404,92 @@ func (a *Adapter) ExpectedArtifact(runDir string) string {
 	return runDir
 }
 
+// runningPattern matches the line Cypress prints before running each spec.
+var runningPattern = regexp.MustCompile(`^\s*Running:\s+(\S+)`)
+
+// DiscoverTimeout returns the defaultCommandTimeout Cypress applies to the
+// command: the --config flag, then the CYPRESS_defaultCommandTimeout
+// environment variable, then the config file given by --config-file or found
+// in projectDir, then Cypress's default. Cypress has no per-test timeout, so
+// the source describes the result as a per-command limit.
+func (a *Adapter) DiscoverTimeout(projectDir string, userCmd []string) (time.Duration, string, error) {
+	if v, ok := jsconfig.FlagValue(userCmd, "--config", "-c"); ok {
+		// --config accepts key=value pairs or a JSON object
+		if ms, ok := jsconfig.Number(v, "defaultCommandTimeout"); ok {
+			return milliseconds(ms), perCommand("--config flag"), nil
+		}
+	}
+
+	if v := os.Getenv("CYPRESS_defaultCommandTimeout"); v != "" {
+		ms, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid CYPRESS_defaultCommandTimeout value %q: %w", v, err)
+		}
+		return milliseconds(ms), perCommand("CYPRESS_defaultCommandTimeout"), nil
+	}
+
+	path := jsconfig.FindFile(projectDir, configFiles...)
+	if v, ok := jsconfig.FlagValue(userCmd, "--config-file", "-C"); ok {
+		path = v
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectDir, path)
+		}
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to read Cypress config %s: %w", path, err)
+		}
+		if ms, ok := jsconfig.Number(string(data), "defaultCommandTimeout"); ok {
+			return milliseconds(ms), perCommand(filepath.Base(path)), nil
+		}
+	}
+
+	return defaultCommandTimeout, perCommand("Cypress default"), nil
+}
+
+// perCommand describes a defaultCommandTimeout found in source. It limits
+// each Cypress command rather than the whole test.
+func perCommand(source string) string {
+	return "per-command defaultCommandTimeout, " + source
+}
+
+// milliseconds converts a millisecond count to a duration.
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// SliceOutput returns the console output of the spec containing test, from
+// its "Running:" line to the next spec or the run summary.
+func (a *Adapter) SliceOutput(test model.TestResult, stdout, stderr string) string {
+	spec, _, ok := strings.Cut(test.TestID, "::")
+	if !ok {
+		return ""
+	}
+	spec = filepath.ToSlash(spec)
+
+	lines := strings.Split(stdout, "\n")
+	start := -1
+	for i, line := range lines {
+		m := runningPattern.FindStringSubmatch(line)
+		if start >= 0 {
+			if m != nil || strings.Contains(line, "(Run Finished)") {
+				return strings.TrimRight(strings.Join(lines[start:i], "\n"), "\n ")
+			}
+			continue
+		}
+		if m != nil && (spec == m[1] || strings.HasSuffix(spec, "/"+m[1])) {
+			start = i
+		}
+	}
+
+	if start < 0 {
+		return ""
+	}
+	return strings.TrimRight(strings.Join(lines[start:], "\n"), "\n ")
+}
+
 // parseXMLFile parses a single JUnit XML file and returns all test cases.
 func parseXMLFile(xmlPath string) ([]JUnitTestCase, error) {
 	data, err := os.ReadFile(xmlPath)
@@ -220,6 +545,7 @@ func determineOutcome(tc JUnitTestCase) model.Outcome {
 }
 
 // extractFailureMessage extracts a clean failure message from JUnit failure/error.
+// The full message is kept; reports truncate it for display.
 func extractFailureMessage(message, content string) string {
 	// Prefer message attribute, fall back to content
 	msg := strings.TrimSpace(message)
@@ -227,11 +553,5 @@ func extractFailureMessage(message, content string) string {
 		msg = strings.TrimSpace(content)
 	}
 
-	// Truncate very long messages
-	const maxLen = 500
-	if len(msg) > maxLen {
-		msg = msg[:maxLen] + "..."
-	}
-
 	return msg
 }
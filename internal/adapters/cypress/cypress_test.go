@@ -3,6 +3,7 @@ package cypress
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -23,13 +24,13 @@ func TestBuildCommand(t *testing.T) {
 			name:     "basic cypress run",
 			runDir:   "/tmp/runs/001",
 			userCmd:  []string{"npx", "cypress", "run"},
-			expected: []string{"npx", "cypress", "run", "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/001/[hash].xml"},
+			expected: []string{"npx", "cypress", "run", "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/001/[hash].xml", "--config", "screenshotsFolder=/tmp/runs/001/screenshots,videosFolder=/tmp/runs/001/videos", "--env", "FLAKEHUNT=1"},
 		},
 		{
 			name:     "cypress with spec",
 			runDir:   "/tmp/runs/002",
 			userCmd:  []string{"npx", "cypress", "run", "--spec", "cypress/e2e/login.cy.js"},
-			expected: []string{"npx", "cypress", "run", "--spec", "cypress/e2e/login.cy.js", "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/002/[hash].xml"},
+			expected: []string{"npx", "cypress", "run", "--spec", "cypress/e2e/login.cy.js", "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/002/[hash].xml", "--config", "screenshotsFolder=/tmp/runs/002/screenshots,videosFolder=/tmp/runs/002/videos", "--env", "FLAKEHUNT=1"},
 		},
 		{
 			name:     "empty user command",
@@ -37,11 +38,35 @@ func TestBuildCommand(t *testing.T) {
 			userCmd:  []string{},
 			expected: nil,
 		},
+		{
+			name:     "merges into existing config flag",
+			runDir:   "/tmp/runs/005",
+			userCmd:  []string{"npx", "cypress", "run", "--config", "video=true"},
+			expected: []string{"npx", "cypress", "run", "--config", "video=true,screenshotsFolder=/tmp/runs/005/screenshots,videosFolder=/tmp/runs/005/videos", "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/005/[hash].xml", "--env", "FLAKEHUNT=1"},
+		},
+		{
+			name:     "merges into existing config assignment",
+			runDir:   "/tmp/runs/006",
+			userCmd:  []string{"npx", "cypress", "run", "--config=video=true"},
+			expected: []string{"npx", "cypress", "run", "--config=video=true,screenshotsFolder=/tmp/runs/006/screenshots,videosFolder=/tmp/runs/006/videos", "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/006/[hash].xml", "--env", "FLAKEHUNT=1"},
+		},
+		{
+			name:     "merges into JSON config",
+			runDir:   "/tmp/runs/007",
+			userCmd:  []string{"npx", "cypress", "run", "-c", `{"video":true}`},
+			expected: []string{"npx", "cypress", "run", "-c", `{"screenshotsFolder":"/tmp/runs/007/screenshots","video":true,"videosFolder":"/tmp/runs/007/videos"}`, "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/007/[hash].xml", "--env", "FLAKEHUNT=1"},
+		},
+		{
+			name:     "merges into existing env flag",
+			runDir:   "/tmp/runs/008",
+			userCmd:  []string{"npx", "cypress", "run", "--env", "host=staging"},
+			expected: []string{"npx", "cypress", "run", "--env", "host=staging,FLAKEHUNT=1", "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/008/[hash].xml", "--config", "screenshotsFolder=/tmp/runs/008/screenshots,videosFolder=/tmp/runs/008/videos"},
+		},
 		{
 			name:     "cypress with browser option",
 			runDir:   "/tmp/runs/004",
 			userCmd:  []string{"npx", "cypress", "run", "--browser", "chrome"},
-			expected: []string{"npx", "cypress", "run", "--browser", "chrome", "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/004/[hash].xml"},
+			expected: []string{"npx", "cypress", "run", "--browser", "chrome", "--reporter", "junit", "--reporter-options", "mochaFile=/tmp/runs/004/[hash].xml", "--config", "screenshotsFolder=/tmp/runs/004/screenshots,videosFolder=/tmp/runs/004/videos", "--env", "FLAKEHUNT=1"},
 		},
 	}
 
@@ -72,7 +97,7 @@ func TestBuildCommand(t *testing.T) {
 
 func TestBuildCommandDoesNotMutateInput(t *testing.T) {
 	adapter := New()
-	original := []string{"npx", "cypress", "run"}
+	original := []string{"npx", "cypress", "run", "--config", "video=true"}
 	userCmd := make([]string, len(original))
 	copy(userCmd, original)
 
@@ -133,6 +158,7 @@ func TestParse(t *testing.T) {
 					Outcome:        model.OutcomeFail,
 					Duration:       1200 * time.Millisecond,
 					FailureMessage: "AssertionError: expected button to be visible",
+					StackTrace:     "at Context.eval (cypress/e2e/checkout.cy.js:15:10)",
 				},
 			},
 		},
@@ -272,6 +298,9 @@ func TestParse(t *testing.T) {
 				if expected.FailureMessage != "" && actual.FailureMessage != expected.FailureMessage {
 					t.Errorf("test[%d].FailureMessage: expected %q, got %q", i, expected.FailureMessage, actual.FailureMessage)
 				}
+				if expected.StackTrace != "" && !strings.Contains(actual.StackTrace, expected.StackTrace) {
+					t.Errorf("test[%d].StackTrace: expected to contain %q, got %q", i, expected.StackTrace, actual.StackTrace)
+				}
 			}
 		})
 	}
@@ -336,6 +365,74 @@ func TestParseRetriesFromMultipleFiles(t *testing.T) {
 }
 
 // TestMain sets up the test fixtures
+func TestParseRetryAttempts(t *testing.T) {
+	result, err := New().Parse(filepath.Join("testdata", "retries"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	byID := make(map[string]model.TestResult)
+	for _, test := range result.Tests {
+		byID[test.TestID] = test
+	}
+
+	flaky := byID["cypress/e2e/flaky.cy.js::flaky test"]
+	if !flaky.FlakyInRun {
+		t.Error("expected test that failed then passed to be flaky in run")
+	}
+	if len(flaky.Attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %d", len(flaky.Attempts))
+	}
+	first := flaky.Attempts[0]
+	if first.Outcome != model.OutcomeFail || first.FailureMessage != "Timeout waiting for element" {
+		t.Errorf("first attempt = %+v, want failure with message", first)
+	}
+	if first.Duration != time.Second {
+		t.Errorf("first attempt duration = %v, want 1s", first.Duration)
+	}
+	if flaky.Attempts[1].Outcome != model.OutcomePass {
+		t.Errorf("second attempt outcome = %s, want pass", flaky.Attempts[1].Outcome)
+	}
+
+	stable := byID["cypress/e2e/flaky.cy.js::stable test"]
+	if stable.FlakyInRun || stable.Attempts != nil {
+		t.Errorf("expected single-attempt test to have no attempts recorded, got %+v", stable)
+	}
+}
+
+func TestParseRetriesAllFailed(t *testing.T) {
+	dir := t.TempDir()
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="cypress/e2e/broken.cy.js" tests="2" failures="2">
+    <testcase name="broken" classname="cypress/e2e/broken.cy.js" time="1.0">
+      <failure message="first" type="Error">first</failure>
+    </testcase>
+    <testcase name="broken" classname="cypress/e2e/broken.cy.js" time="1.0">
+      <failure message="second" type="Error">second</failure>
+    </testcase>
+  </testsuite>
+</testsuites>`
+	if err := os.WriteFile(filepath.Join(dir, "results.xml"), []byte(xml), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New().Parse(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	test := result.Tests[0]
+	if test.Outcome != model.OutcomeFail || test.FailureMessage != "second" {
+		t.Errorf("expected final failure from the last attempt, got %+v", test)
+	}
+	if test.FlakyInRun {
+		t.Error("attempts that all failed should not be flaky in run")
+	}
+	if len(test.Attempts) != 2 {
+		t.Errorf("expected 2 attempts, got %d", len(test.Attempts))
+	}
+}
+
 func TestMain(m *testing.M) {
 	// Create testdata directory and fixtures
 	if err := setupTestFixtures(); err != nil {
@@ -474,3 +571,209 @@ func setupTestFixtures() error {
 
 	return nil
 }
+
+func TestSliceOutput(t *testing.T) {
+	stdout := `
+  Running:  login.cy.js                                                    (1 of 2)
+
+  Login
+    ✓ should log in (812ms)
+
+  Running:  checkout.cy.js                                                 (2 of 2)
+
+  Checkout
+    1) should complete purchase
+
+  (Run Finished)
+
+       Spec                                              Tests  Passing  Failing
+`
+
+	adapter := New()
+	got := adapter.SliceOutput(model.TestResult{TestID: "cypress/e2e/checkout.cy.js::should complete purchase"}, stdout, "")
+
+	if !strings.Contains(got, "Running:  checkout.cy.js") || !strings.Contains(got, "should complete purchase") {
+		t.Errorf("slice missing checkout spec output:\n%s", got)
+	}
+	if strings.Contains(got, "login.cy.js") || strings.Contains(got, "Run Finished") {
+		t.Errorf("slice should only contain the checkout spec:\n%s", got)
+	}
+
+	if got := adapter.SliceOutput(model.TestResult{TestID: "cypress/e2e/other.cy.js::x"}, stdout, ""); got != "" {
+		t.Errorf("expected no slice for unknown spec, got %q", got)
+	}
+}
+
+func TestDiscoverTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		env        string
+		userCmd    []string
+		want       time.Duration
+		wantSource string
+	}{
+		{
+			name:       "default",
+			userCmd:    []string{"npx", "cypress", "run"},
+			want:       4 * time.Second,
+			wantSource: "per-command defaultCommandTimeout, Cypress default",
+		},
+		{
+			name:       "config flag",
+			files:      map[string]string{"cypress.config.js": "module.exports = { e2e: { defaultCommandTimeout: 8000 } };"},
+			userCmd:    []string{"npx", "cypress", "run", "--config", "video=false,defaultCommandTimeout=12000"},
+			want:       12 * time.Second,
+			wantSource: "per-command defaultCommandTimeout, --config flag",
+		},
+		{
+			name:       "environment variable",
+			files:      map[string]string{"cypress.config.js": "module.exports = { e2e: { defaultCommandTimeout: 8000 } };"},
+			env:        "6000",
+			userCmd:    []string{"npx", "cypress", "run"},
+			want:       6 * time.Second,
+			wantSource: "per-command defaultCommandTimeout, CYPRESS_defaultCommandTimeout",
+		},
+		{
+			name:       "config file",
+			files:      map[string]string{"cypress.config.ts": "export default defineConfig({\n  defaultCommandTimeout: 10_000,\n});"},
+			userCmd:    []string{"npx", "cypress", "run"},
+			want:       10 * time.Second,
+			wantSource: "per-command defaultCommandTimeout, cypress.config.ts",
+		},
+		{
+			name:       "explicit config file",
+			files:      map[string]string{"ci.config.js": "module.exports = { defaultCommandTimeout: 15000 };"},
+			userCmd:    []string{"npx", "cypress", "run", "-C", "ci.config.js"},
+			want:       15 * time.Second,
+			wantSource: "per-command defaultCommandTimeout, ci.config.js",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("CYPRESS_defaultCommandTimeout", tt.env)
+
+			got, source, err := New().DiscoverTimeout(dir, tt.userCmd)
+			if err != nil {
+				t.Fatalf("DiscoverTimeout() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("timeout = %v, want %v", got, tt.want)
+			}
+			if source != tt.wantSource {
+				t.Errorf("source = %q, want %q", source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestParseAttachesMedia(t *testing.T) {
+	dir := t.TempDir()
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="cypress/e2e/auth/login.cy.js" tests="4" failures="3">
+    <testcase name="logs in" classname="cypress/e2e/auth/login.cy.js" time="1.0">
+      <failure message="Timed out" type="Error">Timed out</failure>
+    </testcase>
+    <testcase name="logs in" classname="cypress/e2e/auth/login.cy.js" time="1.0"/>
+    <testcase name="logs in with sso" classname="cypress/e2e/auth/login.cy.js" time="1.0">
+      <failure message="Timed out" type="Error">Timed out</failure>
+    </testcase>
+    <testcase name="logs out" classname="cypress/e2e/auth/login.cy.js" time="1.0"/>
+    <testcase name="admin logs in" classname="cypress/e2e/auth/login.cy.js" time="1.0">
+      <failure message="Timed out" type="Error">Timed out</failure>
+    </testcase>
+  </testsuite>
+</testsuites>`
+	files := map[string]string{
+		"results.xml": xml,
+		"screenshots/auth/login.cy.js/Login -- logs in (failed).png":                      "png",
+		"screenshots/auth/login.cy.js/Login -- logs in with sso (failed).png":             "png",
+		"screenshots/auth/login.cy.js/Login -- logs in with sso (failed) (attempt 2).png": "png",
+		"screenshots/auth/login.cy.js/Login -- logs out.png":                              "png",
+		"screenshots/auth/login.cy.js/Login -- admin logs in (failed).png":                "png",
+		"videos/auth/login.cy.js.mp4":                                                     "mp4",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := New().Parse(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byID := make(map[string]model.TestResult)
+	for _, test := range result.Tests {
+		byID[test.TestID] = test
+	}
+
+	retried := byID["cypress/e2e/auth/login.cy.js::logs in"]
+	if want := []string{"screenshots/auth/login.cy.js/Login -- logs in (failed).png"}; !slices.Equal(retried.Screenshots, want) {
+		t.Errorf("retried test screenshots = %v, want %v", retried.Screenshots, want)
+	}
+	if retried.Video != "videos/auth/login.cy.js.mp4" {
+		t.Errorf("retried test video = %q", retried.Video)
+	}
+
+	sso := byID["cypress/e2e/auth/login.cy.js::logs in with sso"]
+	if len(sso.Screenshots) != 2 {
+		t.Errorf("expected 2 screenshots for failing test, got %v", sso.Screenshots)
+	}
+
+	// A sibling whose title ends with the same words keeps its own screenshot
+	admin := byID["cypress/e2e/auth/login.cy.js::admin logs in"]
+	if want := []string{"screenshots/auth/login.cy.js/Login -- admin logs in (failed).png"}; !slices.Equal(admin.Screenshots, want) {
+		t.Errorf("sibling test screenshots = %v, want %v", admin.Screenshots, want)
+	}
+
+	passed := byID["cypress/e2e/auth/login.cy.js::logs out"]
+	if len(passed.Screenshots) != 0 || passed.Video != "" {
+		t.Errorf("passing test should have no media, got %+v", passed)
+	}
+}
+
+func TestParseWithoutMedia(t *testing.T) {
+	result, err := New().Parse(filepath.Join("testdata", "failing"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, test := range result.Tests {
+		if len(test.Screenshots) != 0 || test.Video != "" {
+			t.Errorf("expected no media for %s, got %+v", test.TestID, test)
+		}
+	}
+}
+
+func TestRenderQuarantine(t *testing.T) {
+	var _ model.QuarantineRenderer = New()
+
+	content, usage, err := New().RenderQuarantine(map[string]string{"cypress/e2e/login.cy.js::logs in": "2026-11-01"}, ".flakehunt-quarantine.yaml", "cypress/support/quarantine.js")
+	if err != nil {
+		t.Fatalf("RenderQuarantine failed: %v", err)
+	}
+	for _, want := range []string{
+		"from .flakehunt-quarantine.yaml",
+		`"cypress/e2e/login.cy.js::logs in": "2026-11-01"`,
+		"Cypress.env('FLAKEHUNT')",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content missing %q:\n%s", want, content)
+		}
+	}
+	if !strings.Contains(usage, "cypress/support/quarantine.js") {
+		t.Errorf("usage = %q, want the generated file", usage)
+	}
+}
@@ -0,0 +1,33 @@
+package cypress
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed quarantine.js.tmpl
+var quarantineTemplateText string
+
+var quarantineTemplate = template.Must(template.New("quarantine").Parse(quarantineTemplateText))
+
+// RenderQuarantine returns a support file module that skips the tests in
+// expiries until they expire, matching specs relative to the project and
+// test titles or full titles.
+func (a *Adapter) RenderQuarantine(expiries map[string]string, source, path string) (string, string, error) {
+	tests, err := json.MarshalIndent(expiries, "", "  ")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode quarantined tests: %w", err)
+	}
+
+	var sb strings.Builder
+	data := struct{ Source, Expiries string }{source, string(tests)}
+	if err := quarantineTemplate.Execute(&sb, data); err != nil {
+		return "", "", fmt.Errorf("failed to render quarantine file: %w", err)
+	}
+	usage := fmt.Sprintf("Import %s from your support file, e.g. cypress/support/e2e.js.", filepath.ToSlash(path))
+	return sb.String(), usage, nil
+}
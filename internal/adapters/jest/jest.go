@@ -6,19 +6,37 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/boyarskiy/flakehunt/internal/adapters/jsconfig"
 	"github.com/boyarskiy/flakehunt/internal/model"
 )
 
 const (
 	artifactFilename = "jest.json"
+
+	// defaultTestTimeout is Jest's default per-test timeout.
+	defaultTestTimeout = 5 * time.Second
 )
 
+// configFiles are the Jest configuration files, in the order Jest resolves them.
+var configFiles = []string{
+	"jest.config.js",
+	"jest.config.ts",
+	"jest.config.mjs",
+	"jest.config.cjs",
+	"jest.config.json",
+}
+
 // Adapter implements model.Adapter for Jest.
-type Adapter struct{}
+type Adapter struct {
+	// fileTimeouts caches jest.setTimeout overrides by test file; zero means none.
+	fileTimeouts map[string]time.Duration
+}
 
 // New creates a new Jest adapter.
 func New() *Adapter {
@@ -104,6 +122,8 @@ func (a *Adapter) Parse(runDir string) (*model.RunResult, error) {
 		return nil, err
 	}
 
+	a.applyFileTimeouts(tests)
+
 	// Sort tests by TestID for deterministic output
 	sort.Slice(tests, func(i, j int) bool {
 		return tests[i].TestID < tests[j].TestID
@@ -119,6 +139,119 @@ func (a *Adapter) ExpectedArtifact(runDir string) string {
 	return filepath.Join(runDir, artifactFilename)
 }
 
+// DiscoverTimeout returns the testTimeout Jest applies to the command: the
+// --testTimeout flag, then testTimeout in the config file given by --config
+// or found in projectDir, then package.json, then Jest's default.
+func (a *Adapter) DiscoverTimeout(projectDir string, userCmd []string) (time.Duration, string, error) {
+	if v, ok := jsconfig.FlagValue(userCmd, "--testTimeout"); ok {
+		ms, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid --testTimeout value %q: %w", v, err)
+		}
+		return milliseconds(ms), "--testTimeout flag", nil
+	}
+
+	path := jsconfig.FindFile(projectDir, configFiles...)
+	if v, ok := jsconfig.FlagValue(userCmd, "--config", "-c"); ok && !strings.HasPrefix(strings.TrimSpace(v), "{") {
+		path = v
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectDir, path)
+		}
+	}
+	if path == "" {
+		path = jsconfig.FindFile(projectDir, "package.json")
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to read Jest config %s: %w", path, err)
+		}
+		if ms, ok := jsconfig.Number(string(data), "testTimeout"); ok {
+			return milliseconds(ms), filepath.Base(path) + " testTimeout", nil
+		}
+	}
+
+	return defaultTestTimeout, "Jest default", nil
+}
+
+// applyFileTimeouts sets the timeout of tests in files that call jest.setTimeout.
+func (a *Adapter) applyFileTimeouts(tests []model.TestResult) {
+	if a.fileTimeouts == nil {
+		a.fileTimeouts = make(map[string]time.Duration)
+	}
+
+	for i := range tests {
+		file, _, _ := strings.Cut(tests[i].TestID, "::")
+		timeout, cached := a.fileTimeouts[file]
+		if !cached {
+			// Unreadable files have no override
+			if data, err := os.ReadFile(file); err == nil {
+				if ms, ok := jsconfig.Call(string(data), "jest.setTimeout"); ok {
+					timeout = milliseconds(ms)
+				}
+			}
+			a.fileTimeouts[file] = timeout
+		}
+		tests[i].Timeout = timeout
+	}
+}
+
+// milliseconds converts a millisecond count to a duration.
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// SliceOutput returns the reporter output of the test file containing test,
+// including its console output, from the "PASS"/"FAIL" header of the file to
+// the next file or the summary. Jest reports to stderr, so stdout is only
+// searched if stderr has no matching section.
+func (a *Adapter) SliceOutput(test model.TestResult, stdout, stderr string) string {
+	file, _, ok := strings.Cut(test.TestID, "::")
+	if !ok {
+		return ""
+	}
+	if section := sliceFileSection(stderr, file); section != "" {
+		return section
+	}
+	return sliceFileSection(stdout, file)
+}
+
+var (
+	// fileHeaderPattern matches the line Jest prints before each test file's results.
+	fileHeaderPattern = regexp.MustCompile(`^\s*(PASS|FAIL)\s+(\S+)`)
+	// ansiPattern matches ANSI escape sequences.
+	ansiPattern = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+)
+
+// sliceFileSection returns the section of output for the given test file.
+func sliceFileSection(output, file string) string {
+	file = filepath.ToSlash(file)
+	lines := strings.Split(output, "\n")
+
+	start := -1
+	for i, line := range lines {
+		plain := ansiPattern.ReplaceAllString(line, "")
+		if start >= 0 {
+			if fileHeaderPattern.MatchString(plain) || strings.HasPrefix(plain, "Test Suites:") ||
+				strings.HasPrefix(plain, "Summary of all failing tests") {
+				return strings.TrimRight(strings.Join(lines[start:i], "\n"), "\n ")
+			}
+			continue
+		}
+
+		m := fileHeaderPattern.FindStringSubmatch(plain)
+		if m != nil && (file == m[2] || strings.HasSuffix(file, "/"+strings.TrimPrefix(m[2], "./"))) {
+			start = i
+		}
+	}
+
+	if start < 0 {
+		return ""
+	}
+	return strings.TrimRight(strings.Join(lines[start:], "\n"), "\n ")
+}
+
 // extractTests converts Jest output to model.TestResult slice.
 func extractTests(output *JestOutput, artifactPath string) ([]model.TestResult, error) {
 	var tests []model.TestResult
@@ -133,6 +266,11 @@ func extractTests(output *JestOutput, artifactPath string) ([]model.TestResult,
 			}
 		}
 
+		if suite, ok := suiteSetupResult(testResult); ok {
+			tests = append(tests, suite)
+		}
+		_, hookFailed := beforeAllFailure(testResult)
+
 		for j, assertion := range testResult.AssertionResults {
 			// Validate required field: fullName
 			if assertion.FullName == "" {
@@ -174,6 +312,13 @@ func extractTests(output *JestOutput, artifactPath string) ([]model.TestResult,
 				failureMsg = strings.Join(assertion.FailureMessages, "\n")
 			}
 
+			// Tests failed by a beforeAll hook did not run; the failure is
+			// recorded once, on the suite setup
+			if outcome == model.OutcomeFail && hookFailed {
+				outcome = model.OutcomeSkip
+				failureMsg = ""
+			}
+
 			tests = append(tests, model.TestResult{
 				TestID:         testID,
 				Outcome:        outcome,
@@ -186,6 +331,103 @@ func extractTests(output *JestOutput, artifactPath string) ([]model.TestResult,
 	return tests, nil
 }
 
+// suiteSetupResult returns a synthetic result for the file-level setup of a
+// test file: loading it and running its beforeAll and afterAll hooks. The
+// setup fails when Jest reports an execution error for the file, a beforeAll
+// hook failed its tests, or Jest marks the file failed although none of its
+// tests failed. Files without a status, as in older Jest versions, have no
+// setup result.
+func suiteSetupResult(file TestResult) (model.TestResult, bool) {
+	if file.Status != "passed" && file.Status != "failed" {
+		return model.TestResult{}, false
+	}
+
+	result := model.TestResult{
+		TestID:  fmt.Sprintf("%s::%s", file.Name, model.SuiteSetupName),
+		Outcome: model.OutcomePass,
+		Suite:   true,
+	}
+	if file.EndTime > file.StartTime && file.StartTime > 0 {
+		result.Duration = time.Duration(file.EndTime-file.StartTime) * time.Millisecond
+	}
+
+	testFailed := false
+	for _, assertion := range file.AssertionResults {
+		if assertion.Status == "failed" {
+			testFailed = true
+			break
+		}
+	}
+
+	hookErr, hookFailed := beforeAllFailure(file)
+	switch {
+	case file.TestExecError != nil:
+		result.Outcome = model.OutcomeFail
+		result.FailureMessage = file.TestExecError.Message
+		result.StackTrace = file.TestExecError.Stack
+		if result.FailureMessage == "" {
+			result.FailureMessage = file.Message
+		}
+	case file.Status == "failed" && hookFailed:
+		result.Outcome = model.OutcomeFail
+		result.FailureMessage = hookErr
+	case file.Status == "failed" && !testFailed:
+		result.Outcome = model.OutcomeFail
+		result.FailureMessage = file.Message
+	}
+	if result.Outcome == model.OutcomeFail && strings.TrimSpace(result.FailureMessage) == "" {
+		result.FailureMessage = "Test suite failed to run"
+	}
+
+	return result, true
+}
+
+// beforeAllFailure returns the error of a failed beforeAll hook of a test
+// file. jest-circus does not fail the file when a beforeAll hook throws: it
+// fails each test with the hook's error instead. A hook is taken to have
+// failed when no test passed, every failed test has the same first error and
+// that error shows it came from a hook. Tests failing their own assertions
+// with the same error stay failed.
+func beforeAllFailure(file TestResult) (string, bool) {
+	var hookErr string
+	failed := 0
+	for _, assertion := range file.AssertionResults {
+		switch assertion.Status {
+		case "passed":
+			return "", false
+		case "failed":
+			if len(assertion.FailureMessages) == 0 {
+				return "", false
+			}
+			if failed > 0 && assertion.FailureMessages[0] != hookErr {
+				return "", false
+			}
+			hookErr = assertion.FailureMessages[0]
+			failed++
+		}
+	}
+
+	if failed == 0 || !isHookError(hookErr) {
+		return "", false
+	}
+	return hookErr, true
+}
+
+// hookErrorMarkers are the parts of a Jest failure message that only appear
+// when a hook failed: the hook's name, the hook timeout message and the
+// jest-circus frame that calls hooks.
+var hookErrorMarkers = []string{"beforeAll", "for a hook", "_callCircusHook"}
+
+// isHookError reports whether a failure message was raised by a hook.
+func isHookError(msg string) bool {
+	for _, marker := range hookErrorMarkers {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
 // mapStatus converts Jest status strings to model.Outcome.
 func mapStatus(status string) (model.Outcome, error) {
 	switch status {
@@ -218,9 +460,19 @@ type JestOutput struct {
 type TestResult struct {
 	Name             string            `json:"name"`
 	Status           string            `json:"status"`
+	Message          string            `json:"message"`
+	StartTime        int64             `json:"startTime"` // Unix milliseconds
+	EndTime          int64             `json:"endTime"`   // Unix milliseconds
+	TestExecError    *TestExecError    `json:"testExecError"`
 	AssertionResults []AssertionResult `json:"assertionResults"`
 }
 
+// TestExecError represents an error that prevented a Jest test file from running.
+type TestExecError struct {
+	Message string `json:"message"`
+	Stack   string `json:"stack"`
+}
+
 // AssertionResult represents a single Jest test assertion.
 type AssertionResult struct {
 	FullName        string   `json:"fullName"`
@@ -127,6 +127,11 @@ func TestParse(t *testing.T) {
 			name:    "passing tests",
 			fixture: "passing.json",
 			wantTests: []model.TestResult{
+				{
+					TestID:  "/project/src/math.test.js::<suite setup>",
+					Outcome: model.OutcomePass,
+					Suite:   true,
+				},
 				{
 					TestID:   "/project/src/math.test.js::math add adds two numbers",
 					Outcome:  model.OutcomePass,
@@ -143,6 +148,11 @@ func TestParse(t *testing.T) {
 			name:    "failing tests",
 			fixture: "failing.json",
 			wantTests: []model.TestResult{
+				{
+					TestID:  "/project/src/api.test.js::<suite setup>",
+					Outcome: model.OutcomePass,
+					Suite:   true,
+				},
 				{
 					TestID:         "/project/src/api.test.js::api fetchData handles errors",
 					Outcome:        model.OutcomeFail,
@@ -160,6 +170,11 @@ func TestParse(t *testing.T) {
 			name:    "skipped tests",
 			fixture: "skipped.json",
 			wantTests: []model.TestResult{
+				{
+					TestID:  "/project/src/feature.test.js::<suite setup>",
+					Outcome: model.OutcomePass,
+					Suite:   true,
+				},
 				{
 					TestID:   "/project/src/feature.test.js::feature disabled functionality is disabled",
 					Outcome:  model.OutcomeSkip,
@@ -177,6 +192,84 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:    "suite fails to run",
+			fixture: "suite_exec_error.json",
+			wantTests: []model.TestResult{
+				{
+					TestID:         "/project/src/db.test.js::<suite setup>",
+					Outcome:        model.OutcomeFail,
+					Duration:       1200 * time.Millisecond,
+					FailureMessage: "connect ECONNREFUSED 127.0.0.1:5432",
+					Suite:          true,
+				},
+			},
+		},
+		{
+			name:    "suite fails after passing tests",
+			fixture: "suite_after_all.json",
+			wantTests: []model.TestResult{
+				{
+					TestID:         "/project/src/db.test.js::<suite setup>",
+					Outcome:        model.OutcomeFail,
+					Duration:       300 * time.Millisecond,
+					FailureMessage: "● Test suite failed to run\n\n    afterAll: connection pool did not drain",
+					Suite:          true,
+				},
+				{
+					TestID:   "/project/src/db.test.js::db reads rows",
+					Outcome:  model.OutcomePass,
+					Duration: 40 * time.Millisecond,
+				},
+			},
+		},
+		{
+			name:    "beforeAll hook fails every test",
+			fixture: "suite_before_all.json",
+			wantTests: []model.TestResult{
+				{
+					TestID:         "/project/src/db.test.js::<suite setup>",
+					Outcome:        model.OutcomeFail,
+					Duration:       900 * time.Millisecond,
+					FailureMessage: "Error: connect ECONNREFUSED 127.0.0.1:5432\n    at Object.<anonymous> (/project/src/db.test.js:5:11)\n    at _callCircusHook (/project/node_modules/jest-circus/build/run.js:281:40)",
+					Suite:          true,
+				},
+				{
+					TestID:   "/project/src/db.test.js::db reads rows",
+					Outcome:  model.OutcomeSkip,
+					Duration: 2 * time.Millisecond,
+				},
+				{
+					TestID:   "/project/src/db.test.js::db writes rows",
+					Outcome:  model.OutcomeSkip,
+					Duration: 1 * time.Millisecond,
+				},
+			},
+		},
+		{
+			name:    "tests failing with the same assertion stay failed",
+			fixture: "same_assertion_failures.json",
+			wantTests: []model.TestResult{
+				{
+					TestID:   "/project/src/api.test.js::<suite setup>",
+					Outcome:  model.OutcomePass,
+					Duration: 900 * time.Millisecond,
+					Suite:    true,
+				},
+				{
+					TestID:         "/project/src/api.test.js::api creates users",
+					Outcome:        model.OutcomeFail,
+					Duration:       2 * time.Millisecond,
+					FailureMessage: "Error: expect(received).toBe(expected) // Object.is equality\n\nExpected: 200\nReceived: 500\n    at Object.<anonymous> (/project/src/api.test.js:9:27)\n    at Promise.then.completed (/project/node_modules/jest-circus/build/utils.js:298:28)\n    at _callCircusTest (/project/node_modules/jest-circus/build/run.js:316:40)",
+				},
+				{
+					TestID:         "/project/src/api.test.js::api updates users",
+					Outcome:        model.OutcomeFail,
+					Duration:       1 * time.Millisecond,
+					FailureMessage: "Error: expect(received).toBe(expected) // Object.is equality\n\nExpected: 200\nReceived: 500\n    at Object.<anonymous> (/project/src/api.test.js:9:27)\n    at Promise.then.completed (/project/node_modules/jest-circus/build/utils.js:298:28)\n    at _callCircusTest (/project/node_modules/jest-circus/build/run.js:316:40)",
+				},
+			},
+		},
 		{
 			name:        "malformed JSON",
 			fixture:     "malformed.json",
@@ -266,6 +359,43 @@ func TestParse(t *testing.T) {
 				if wantTest.FailureMessage != "" && gotTest.FailureMessage != wantTest.FailureMessage {
 					t.Errorf("Test[%d].FailureMessage = %q, want %q", i, gotTest.FailureMessage, wantTest.FailureMessage)
 				}
+				if gotTest.Suite != wantTest.Suite {
+					t.Errorf("Test[%d].Suite = %v, want %v", i, gotTest.Suite, wantTest.Suite)
+				}
+			}
+		})
+	}
+}
+
+func TestBeforeAllFailure(t *testing.T) {
+	failed := func(msg string) AssertionResult {
+		return AssertionResult{FullName: "t", Status: "failed", FailureMessages: []string{msg}}
+	}
+	hookTimeout := "thrown: \"Exceeded timeout of 5000 ms for a hook.\""
+	hookThrew := "Error: boom\n    at Object.<anonymous> (/p/a.test.js:3:9)\n    at _callCircusHook (/p/node_modules/jest-circus/build/run.js:281:40)"
+	assertion := "Error: expect(received).toBe(expected)\n\nExpected: 200\nReceived: 500\n    at Object.<anonymous> (/p/a.test.js:8:20)\n    at _callCircusTest (/p/node_modules/jest-circus/build/run.js:316:40)"
+
+	tests := []struct {
+		name       string
+		assertions []AssertionResult
+		want       bool
+	}{
+		{"tests share a hook error", []AssertionResult{failed(hookThrew), failed(hookThrew)}, true},
+		{"skipped tests are ignored", []AssertionResult{failed(hookThrew), {FullName: "t", Status: "pending"}, failed(hookThrew)}, true},
+		{"single test with hook timeout", []AssertionResult{failed(hookTimeout)}, true},
+		{"error names beforeAll", []AssertionResult{failed("Error: beforeAll setup failed")}, true},
+		{"single failed test", []AssertionResult{failed("Error: boom")}, false},
+		{"tests share an assertion error", []AssertionResult{failed(assertion), failed(assertion)}, false},
+		{"tests share an error without hook frames", []AssertionResult{failed("Error: connect ECONNREFUSED"), failed("Error: connect ECONNREFUSED")}, false},
+		{"different errors", []AssertionResult{failed(hookThrew), failed(hookTimeout)}, false},
+		{"a test passed", []AssertionResult{failed(hookThrew), failed(hookThrew), {FullName: "t", Status: "passed"}}, false},
+		{"no tests", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := beforeAllFailure(TestResult{Name: "a.test.js", Status: "failed", AssertionResults: tt.assertions})
+			if got != tt.want {
+				t.Errorf("beforeAllFailure() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -355,3 +485,194 @@ func TestParseDeterministicOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestSliceOutput(t *testing.T) {
+	stderr := "\x1b[1mPASS\x1b[22m src/math.test.js\n" +
+		"  ✓ adds (2 ms)\n" +
+		"\n" +
+		"FAIL src/api.test.js\n" +
+		"  ● Console\n" +
+		"\n" +
+		"    console.log\n" +
+		"      fetching /users\n" +
+		"\n" +
+		"  ● api › fetchData handles errors\n" +
+		"\n" +
+		"    expect(received).toBe(expected)\n" +
+		"\n" +
+		"Test Suites: 1 failed, 1 passed, 2 total\n"
+
+	adapter := New()
+
+	tests := []struct {
+		name     string
+		testID   string
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "failing file section",
+			testID:   "/home/ci/app/src/api.test.js::api fetchData handles errors",
+			contains: []string{"FAIL src/api.test.js", "fetching /users", "fetchData handles errors"},
+			excludes: []string{"src/math.test.js", "Test Suites:"},
+		},
+		{
+			name:     "section ends at next file",
+			testID:   "/home/ci/app/src/math.test.js::math adds",
+			contains: []string{"adds (2 ms)"},
+			excludes: []string{"api.test.js"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := adapter.SliceOutput(model.TestResult{TestID: tt.testID}, "", stderr)
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("slice missing %q:\n%s", want, got)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("slice should not contain %q:\n%s", unwanted, got)
+				}
+			}
+		})
+	}
+
+	if got := adapter.SliceOutput(model.TestResult{TestID: "/app/src/other.test.js::x"}, "", stderr); got != "" {
+		t.Errorf("expected no slice for unknown file, got %q", got)
+	}
+}
+
+func TestDiscoverTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		userCmd    []string
+		want       time.Duration
+		wantSource string
+	}{
+		{
+			name:       "default",
+			userCmd:    []string{"npx", "jest"},
+			want:       5 * time.Second,
+			wantSource: "Jest default",
+		},
+		{
+			name:       "cli flag wins over config",
+			files:      map[string]string{"jest.config.js": "module.exports = { testTimeout: 20000 };"},
+			userCmd:    []string{"npx", "jest", "--testTimeout=15000"},
+			want:       15 * time.Second,
+			wantSource: "--testTimeout flag",
+		},
+		{
+			name:       "config file",
+			files:      map[string]string{"jest.config.ts": "export default {\n  testTimeout: 30 * 1000,\n};"},
+			userCmd:    []string{"npx", "jest"},
+			want:       30 * time.Second,
+			wantSource: "jest.config.ts testTimeout",
+		},
+		{
+			name:       "explicit config path",
+			files:      map[string]string{"ci/jest.ci.json": `{"testTimeout": 60000}`},
+			userCmd:    []string{"npx", "jest", "--config", "ci/jest.ci.json"},
+			want:       time.Minute,
+			wantSource: "jest.ci.json testTimeout",
+		},
+		{
+			name:       "package.json",
+			files:      map[string]string{"package.json": `{"jest": {"testTimeout": 10000}}`},
+			userCmd:    []string{"npx", "jest"},
+			want:       10 * time.Second,
+			wantSource: "package.json testTimeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, source, err := New().DiscoverTimeout(dir, tt.userCmd)
+			if err != nil {
+				t.Fatalf("DiscoverTimeout() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("timeout = %v, want %v", got, tt.want)
+			}
+			if source != tt.wantSource {
+				t.Errorf("source = %q, want %q", source, tt.wantSource)
+			}
+		})
+	}
+
+	if _, _, err := New().DiscoverTimeout(t.TempDir(), []string{"jest", "--testTimeout", "soon"}); err == nil {
+		t.Error("expected error for invalid --testTimeout")
+	}
+}
+
+func TestParseFileTimeouts(t *testing.T) {
+	dir := t.TempDir()
+	slowFile := filepath.Join(dir, "slow.test.js")
+	if err := os.WriteFile(slowFile, []byte("jest.setTimeout(20000);\ntest('x', () => {});\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fastFile := filepath.Join(dir, "fast.test.js")
+	if err := os.WriteFile(fastFile, []byte("test('y', () => {});\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	artifact := `{"testResults": [
+		{"name": "` + slowFile + `", "assertionResults": [{"fullName": "x", "status": "passed", "duration": 10}]},
+		{"name": "` + fastFile + `", "assertionResults": [{"fullName": "y", "status": "passed", "duration": 10}]}
+	]}`
+	if err := os.WriteFile(filepath.Join(dir, "jest.json"), []byte(artifact), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New().Parse(dir)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	timeouts := make(map[string]time.Duration)
+	for _, test := range result.Tests {
+		timeouts[test.TestID] = test.Timeout
+	}
+	if got := timeouts[slowFile+"::x"]; got != 20*time.Second {
+		t.Errorf("slow file timeout = %v, want 20s", got)
+	}
+	if got := timeouts[fastFile+"::y"]; got != 0 {
+		t.Errorf("fast file timeout = %v, want 0", got)
+	}
+}
+
+func TestRenderQuarantine(t *testing.T) {
+	var _ model.QuarantineRenderer = New()
+
+	content, usage, err := New().RenderQuarantine(map[string]string{"src/a.test.js::A `works`": "2026-11-01"}, ".flakehunt-quarantine.yaml", "test/quarantine.js")
+	if err != nil {
+		t.Fatalf("RenderQuarantine failed: %v", err)
+	}
+	for _, want := range []string{
+		"from .flakehunt-quarantine.yaml",
+		`"src/a.test.js::A ` + "`works`" + `": "2026-11-01"`,
+		"process.env.FLAKEHUNT_RUN_INDEX",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content missing %q:\n%s", want, content)
+		}
+	}
+	if !strings.Contains(usage, `"<rootDir>/test/quarantine.js"`) {
+		t.Errorf("usage = %q, want the setupFilesAfterEnv entry", usage)
+	}
+}
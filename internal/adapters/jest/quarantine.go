@@ -0,0 +1,33 @@
+package jest
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed quarantine.js.tmpl
+var quarantineTemplateText string
+
+var quarantineTemplate = template.Must(template.New("quarantine").Parse(quarantineTemplateText))
+
+// RenderQuarantine returns a setupFilesAfterEnv module that skips the tests
+// in expiries until they expire, matching test files relative to the working
+// directory and full test names. Tests defined with test.each are not matched.
+func (a *Adapter) RenderQuarantine(expiries map[string]string, source, path string) (string, string, error) {
+	tests, err := json.MarshalIndent(expiries, "", "  ")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode quarantined tests: %w", err)
+	}
+
+	var sb strings.Builder
+	data := struct{ Source, Expiries string }{source, string(tests)}
+	if err := quarantineTemplate.Execute(&sb, data); err != nil {
+		return "", "", fmt.Errorf("failed to render quarantine file: %w", err)
+	}
+	usage := fmt.Sprintf("Add \"<rootDir>/%s\" to setupFilesAfterEnv in your Jest config.", filepath.ToSlash(path))
+	return sb.String(), usage, nil
+}
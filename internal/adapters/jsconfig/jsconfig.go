@@ -0,0 +1,78 @@
+// Package jsconfig reads simple values from JavaScript and JSON tool configuration files.
+package jsconfig
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberExpr matches a number or a product of numbers, such as 30 * 1000.
+const numberExpr = `([\d_]+(?:\.\d+)?(?:\s*\*\s*[\d_]+(?:\.\d+)?)*)`
+
+// Number returns the numeric value assigned to key in a JavaScript object
+// literal or JSON document, as in `key: 30 * 1000` or `"key": 30000`.
+// Only literal numbers and products of literal numbers are understood.
+// The first assignment found wins.
+func Number(content, key string) (float64, bool) {
+	re := regexp.MustCompile(`["']?\b` + regexp.QuoteMeta(key) + `\b["']?\s*[:=]\s*` + numberExpr)
+	m := re.FindStringSubmatch(content)
+	if m == nil {
+		return 0, false
+	}
+	return evalProduct(m[1])
+}
+
+// Call returns the numeric argument of the first call to fn, as in
+// `jest.setTimeout(10000)`.
+func Call(content, fn string) (float64, bool) {
+	re := regexp.MustCompile(regexp.QuoteMeta(fn) + `\(\s*` + numberExpr + `\s*\)`)
+	m := re.FindStringSubmatch(content)
+	if m == nil {
+		return 0, false
+	}
+	return evalProduct(m[1])
+}
+
+// evalProduct evaluates a product of numbers such as "30 * 1_000".
+func evalProduct(expr string) (float64, bool) {
+	result := 1.0
+	for _, factor := range strings.Split(expr, "*") {
+		v, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(factor), "_", ""), 64)
+		if err != nil {
+			return 0, false
+		}
+		result *= v
+	}
+	return result, true
+}
+
+// FindFile returns the first of names that exists in dir, or an empty string.
+func FindFile(dir string, names ...string) string {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// FlagValue returns the value of a command-line flag given as "--name=value"
+// or "--name value", checking each of names. The last occurrence wins.
+func FlagValue(args []string, names ...string) (string, bool) {
+	var value string
+	found := false
+	for i, arg := range args {
+		for _, name := range names {
+			if v, ok := strings.CutPrefix(arg, name+"="); ok {
+				value, found = v, true
+			} else if arg == name && i+1 < len(args) {
+				value, found = args[i+1], true
+			}
+		}
+	}
+	return value, found
+}
@@ -0,0 +1,61 @@
+package jsconfig
+
+import "testing"
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		key      string
+		expected float64
+		found    bool
+	}{
+		{"js literal", "module.exports = { testTimeout: 10000 };", "testTimeout", 10000, true},
+		{"js product", "export default { testTimeout: 30 * 1000 }", "testTimeout", 30000, true},
+		{"numeric separator", "{ defaultCommandTimeout: 8_000 }", "defaultCommandTimeout", 8000, true},
+		{"json", `{"jest": {"testTimeout": 15000}}`, "testTimeout", 15000, true},
+		{"expression not understood", "{ testTimeout: process.env.CI ? 1 : 2 }", "testTimeout", 0, false},
+		{"missing", "{ verbose: true }", "testTimeout", 0, false},
+		{"key prefix does not match", "{ testTimeoutMs: 5 }", "testTimeout", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := Number(tt.content, tt.key)
+			if found != tt.found || got != tt.expected {
+				t.Errorf("Number() = %v, %v, want %v, %v", got, found, tt.expected, tt.found)
+			}
+		})
+	}
+}
+
+func TestCall(t *testing.T) {
+	got, found := Call("beforeAll(() => {});\njest.setTimeout(20 * 1000);", "jest.setTimeout")
+	if !found || got != 20000 {
+		t.Errorf("Call() = %v, %v, want 20000, true", got, found)
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+		found    bool
+	}{
+		{"equals form", []string{"npx", "jest", "--testTimeout=9000"}, "9000", true},
+		{"separate value", []string{"npx", "jest", "--testTimeout", "9000"}, "9000", true},
+		{"alias", []string{"cypress", "run", "-c", "defaultCommandTimeout=1"}, "defaultCommandTimeout=1", true},
+		{"last wins", []string{"--testTimeout=1", "--testTimeout=2"}, "2", true},
+		{"missing value", []string{"npx", "jest", "--testTimeout"}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := FlagValue(tt.args, "--testTimeout", "--config", "-c")
+			if found != tt.found || got != tt.expected {
+				t.Errorf("FlagValue() = %q, %v, want %q, %v", got, found, tt.expected, tt.found)
+			}
+		})
+	}
+}
@@ -2,7 +2,6 @@
 package classify
 
 import (
-	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -13,73 +12,6 @@ import (
 // maxExcerptLen is the maximum length for failure excerpts.
 const maxExcerptLen = 200
 
-// signaturePatterns maps failure signatures to their detection patterns.
-// Patterns are checked in order; first match wins.
-var signaturePatterns = []struct {
-	signature model.FailureSignature
-	patterns  []*regexp.Regexp
-}{
-	{
-		signature: model.SignatureTimeout,
-		patterns: []*regexp.Regexp{
-			regexp.MustCompile(`(?i)timeout`),
-			regexp.MustCompile(`(?i)timed?\s*out`),
-			regexp.MustCompile(`(?i)exceeded\s*time`),
-		},
-	},
-	{
-		signature: model.SignatureSelector,
-		patterns: []*regexp.Regexp{
-			regexp.MustCompile(`(?i)selector`),
-			regexp.MustCompile(`(?i)element\s*not\s*found`),
-			regexp.MustCompile(`(?i)cy\.get`),
-		},
-	},
-	{
-		signature: model.SignatureNetwork,
-		patterns: []*regexp.Regexp{
-			regexp.MustCompile(`(?i)network`),
-			regexp.MustCompile(`(?i)ECONNREFUSED`),
-			regexp.MustCompile(`(?i)fetch\s*failed`),
-		},
-	},
-	{
-		signature: model.SignatureDOMDetach,
-		patterns: []*regexp.Regexp{
-			regexp.MustCompile(`(?i)detached`),
-			regexp.MustCompile(`(?i)stale\s*element`),
-		},
-	},
-	{
-		signature: model.SignatureAssertion,
-		patterns: []*regexp.Regexp{
-			regexp.MustCompile(`(?i)\bexpect\b`),
-			regexp.MustCompile(`(?i)\bassert`),
-			regexp.MustCompile(`(?i)\btoBe\b`),
-			regexp.MustCompile(`(?i)\btoEqual\b`),
-		},
-	},
-}
-
-// DetectSignature analyzes a failure message and returns the appropriate signature.
-// Patterns are checked in a deterministic order; first match wins.
-// Returns SignatureUnknown if no patterns match.
-func DetectSignature(failureMessage string) model.FailureSignature {
-	if failureMessage == "" {
-		return model.SignatureUnknown
-	}
-
-	for _, sp := range signaturePatterns {
-		for _, pattern := range sp.patterns {
-			if pattern.MatchString(failureMessage) {
-				return sp.signature
-			}
-		}
-	}
-
-	return model.SignatureUnknown
-}
-
 // truncateExcerpt truncates a string to maxExcerptLen, adding ellipsis if truncated.
 func truncateExcerpt(s string) string {
 	// Normalize whitespace
@@ -91,6 +23,22 @@ func truncateExcerpt(s string) string {
 	return s[:maxExcerptLen-3] + "..."
 }
 
+// Options configures classification.
+type Options struct {
+	// Rules detect failure signatures. Nil uses the built-in rules.
+	Rules *RuleSet
+	// Tool scopes tool-specific rules.
+	Tool model.Tool
+	// ProjectRoot is the absolute project directory. When set, stack frames
+	// outside it are not used as failure locations, and locations are
+	// reported relative to it.
+	ProjectRoot string
+	// TestTimeout is the per-test timeout configured in the test tool, used
+	// to flag tests at risk of timing out. Timeouts reported on individual
+	// test results take precedence. Zero disables the check.
+	TestTimeout time.Duration
+}
+
 // testAggregator accumulates results for a single test across runs.
 type testAggregator struct {
 	testID          string
@@ -100,59 +48,84 @@ type testAggregator struct {
 	totalDuration   time.Duration
 	durationCount   int // count of runs with valid duration (excludes skips)
 	failureEvidence []model.FailureEvidence
+	clusters        map[string]*clusterAggregator // keyed by normalized message
+	runs            []model.RunSample
+	timeout         time.Duration // the test's own timeout, if reported
+	suite           bool
+	attempts        int // attempts in non-skipped runs, including retries
+	retriedRuns     int // runs with more than one attempt
+	flakyInRunCount int // runs whose attempts disagreed
+	retryPassCount  int // runs that passed after a failed attempt
 }
 
-// Aggregate merges per-test outcomes across runs and returns classified, ranked results.
-// The returned slice is sorted by wastedTime descending, with tie-breakers applied.
-func Aggregate(runs []model.RunResult) []model.AggregatedTest {
-	if len(runs) == 0 {
-		return []model.AggregatedTest{}
-	}
+// Aggregator incrementally accumulates run results and classifies tests.
+// It is not safe for concurrent use.
+type Aggregator struct {
+	opts  Options
+	tests map[string]*testAggregator
+	runs  int
+}
 
-	// Map testID -> aggregator
-	aggregators := make(map[string]*testAggregator)
+// NewAggregator creates an empty Aggregator.
+func NewAggregator(opts Options) *Aggregator {
+	return &Aggregator{
+		opts:  opts,
+		tests: make(map[string]*testAggregator),
+	}
+}
 
-	// Process each run
-	for _, run := range runs {
-		for _, test := range run.Tests {
-			agg, exists := aggregators[test.TestID]
-			if !exists {
-				agg = &testAggregator{
-					testID:          test.TestID,
-					failureEvidence: []model.FailureEvidence{},
-				}
-				aggregators[test.TestID] = agg
+// Add merges the outcomes of a single run and returns the tests that became
+// flaky as a result, sorted by TestID.
+func (a *Aggregator) Add(run model.RunResult) []model.AggregatedTest {
+	a.runs++
+
+	touched := make(map[string]bool)
+	wasFlaky := make(map[string]bool)
+
+	for _, test := range run.Tests {
+		agg, exists := a.tests[test.TestID]
+		if !exists {
+			agg = &testAggregator{
+				testID:          test.TestID,
+				suite:           test.Suite,
+				failureEvidence: []model.FailureEvidence{},
+				clusters:        make(map[string]*clusterAggregator),
 			}
+			a.tests[test.TestID] = agg
+		}
+		if !touched[test.TestID] {
+			touched[test.TestID] = true
+			wasFlaky[test.TestID] = agg.isFlaky()
+		}
 
-			switch test.Outcome {
-			case model.OutcomePass:
-				agg.passCount++
-				agg.totalDuration += test.Duration
-				agg.durationCount++
-			case model.OutcomeFail:
-				agg.failCount++
-				agg.totalDuration += test.Duration
-				agg.durationCount++
-
-				// Collect failure evidence
-				evidence := model.FailureEvidence{
-					RunIndex:  run.RunIndex,
-					Excerpt:   truncateExcerpt(test.FailureMessage),
-					Signature: DetectSignature(test.FailureMessage),
-				}
-				agg.failureEvidence = append(agg.failureEvidence, evidence)
-			case model.OutcomeSkip:
-				agg.skipCount++
-				// Skips do not count toward duration average
-			}
+		agg.add(run.RunIndex, test, a.opts)
+	}
+
+	var becameFlaky []model.AggregatedTest
+	for id := range touched {
+		agg := a.tests[id]
+		if !wasFlaky[id] && agg.isFlaky() {
+			becameFlaky = append(becameFlaky, buildAggregatedTest(agg, a.runs, a.opts))
 		}
 	}
+	sort.Slice(becameFlaky, func(i, j int) bool {
+		return becameFlaky[i].TestID < becameFlaky[j].TestID
+	})
+
+	return becameFlaky
+}
 
-	// Convert aggregators to AggregatedTest slice
-	results := make([]model.AggregatedTest, 0, len(aggregators))
-	for _, agg := range aggregators {
-		at := buildAggregatedTest(agg, len(runs))
-		results = append(results, at)
+// Runs returns the number of runs added so far.
+func (a *Aggregator) Runs() int {
+	return a.runs
+}
+
+// Snapshot returns the classified, ranked results for the runs added so far.
+// The returned slice is sorted by wastedTime descending, with tie-breakers applied.
+func (a *Aggregator) Snapshot() []model.AggregatedTest {
+	results := make([]model.AggregatedTest, 0, len(a.tests))
+	for _, agg := range a.tests {
+		results = append(results, buildAggregatedTest(agg, a.runs, a.opts))
 	}
 
 	// Sort results deterministically
@@ -161,8 +134,125 @@ func Aggregate(runs []model.RunResult) []model.AggregatedTest {
 	return results
 }
 
+// add records a single test outcome.
+func (agg *testAggregator) add(runIndex int, test model.TestResult, opts Options) {
+	sample := model.RunSample{
+		RunIndex:   runIndex,
+		Outcome:    test.Outcome,
+		Duration:   test.Duration,
+		FlakyInRun: test.FlakyInRun,
+	}
+	if len(test.Attempts) > 1 {
+		sample.Attempts = len(test.Attempts)
+	}
+	agg.runs = append(agg.runs, sample)
+	if test.Timeout > 0 {
+		agg.timeout = test.Timeout
+	}
+
+	if test.Outcome != model.OutcomeSkip {
+		agg.attempts += max(1, len(test.Attempts))
+		if len(test.Attempts) > 1 {
+			agg.retriedRuns++
+		}
+	}
+	if test.FlakyInRun {
+		agg.flakyInRunCount++
+	}
+
+	switch test.Outcome {
+	case model.OutcomePass:
+		agg.passCount++
+		agg.totalDuration += test.Duration
+		agg.durationCount++
+
+		// A failed attempt that passed on retry is still flake evidence
+		if failed, ok := lastFailedAttempt(test); ok {
+			agg.retryPassCount++
+			evidence := model.FailureEvidence{
+				RunIndex:    runIndex,
+				Excerpt:     truncateExcerpt(failed.FailureMessage),
+				Signature:   opts.detectSignature(failed.FailureMessage),
+				Location:    opts.locate(failed),
+				LogPath:     test.LogPath,
+				Retried:     true,
+				Screenshots: test.Screenshots,
+				Video:       test.Video,
+			}
+			agg.failureEvidence = append(agg.failureEvidence, evidence)
+			agg.addFailure(failed.FailureMessage, evidence)
+		}
+	case model.OutcomeFail:
+		agg.failCount++
+		agg.totalDuration += test.Duration
+		agg.durationCount++
+
+		// Collect failure evidence
+		evidence := model.FailureEvidence{
+			RunIndex:    runIndex,
+			Excerpt:     truncateExcerpt(test.FailureMessage),
+			Signature:   opts.detectSignature(test.FailureMessage),
+			Location:    opts.locate(test),
+			LogPath:     test.LogPath,
+			Screenshots: test.Screenshots,
+			Video:       test.Video,
+		}
+		agg.failureEvidence = append(agg.failureEvidence, evidence)
+		agg.addFailure(test.FailureMessage, evidence)
+	case model.OutcomeSkip:
+		agg.skipCount++
+		// Skips do not count toward duration average
+	}
+}
+
+// lastFailedAttempt returns the test as it was in its last failed attempt,
+// if the test was retried and its attempts disagreed.
+func lastFailedAttempt(test model.TestResult) (model.TestResult, bool) {
+	if !test.FlakyInRun {
+		return model.TestResult{}, false
+	}
+	for i := len(test.Attempts) - 1; i >= 0; i-- {
+		if attempt := test.Attempts[i]; attempt.Outcome == model.OutcomeFail {
+			test.FailureMessage = attempt.FailureMessage
+			test.StackTrace = attempt.StackTrace
+			return test, true
+		}
+	}
+	return model.TestResult{}, false
+}
+
+// classification classifies the accumulated outcomes. Attempts that
+// disagree within a run make a test flaky regardless of its final outcomes.
+func (agg *testAggregator) classification() model.Classification {
+	totalRuns := agg.passCount + agg.failCount
+	if agg.flakyInRunCount > 0 && totalRuns > 0 {
+		return model.ClassificationFlaky
+	}
+	return classify(agg.passCount, agg.failCount, totalRuns)
+}
+
+// isFlaky reports whether the accumulated outcomes classify as flaky.
+func (agg *testAggregator) isFlaky() bool {
+	return agg.classification() == model.ClassificationFlaky
+}
+
+// Aggregate merges per-test outcomes across runs and returns classified, ranked results.
+// The returned slice is sorted by wastedTime descending, with tie-breakers applied.
+func Aggregate(runs []model.RunResult) []model.AggregatedTest {
+	if len(runs) == 0 {
+		return []model.AggregatedTest{}
+	}
+
+	agg := NewAggregator(Options{})
+	for _, run := range runs {
+		agg.Add(run)
+	}
+
+	return agg.Snapshot()
+}
+
 // buildAggregatedTest constructs an AggregatedTest from an aggregator.
-func buildAggregatedTest(agg *testAggregator, numRuns int) model.AggregatedTest {
+func buildAggregatedTest(agg *testAggregator, numRuns int, opts Options) model.AggregatedTest {
 	// TotalRuns excludes skips for flake rate calculation
 	totalRuns := agg.passCount + agg.failCount
 
@@ -173,12 +263,13 @@ func buildAggregatedTest(agg *testAggregator, numRuns int) model.AggregatedTest
 	}
 
 	// Determine classification
-	classification := classify(agg.passCount, agg.failCount, totalRuns)
+	classification := agg.classification()
 
-	// Calculate flake rate
+	// Calculate flake rate: the share of runs with a failed attempt,
+	// including runs that passed on retry
 	var flakeRate float64
 	if totalRuns > 0 {
-		flakeRate = float64(agg.failCount) / float64(totalRuns)
+		flakeRate = float64(agg.failCount+agg.retryPassCount) / float64(totalRuns)
 	}
 
 	// Calculate wasted time: flakeRate * avgDuration * runs
@@ -195,8 +286,30 @@ func buildAggregatedTest(agg *testAggregator, numRuns int) model.AggregatedTest
 		return sortedEvidence[i].RunIndex < sortedEvidence[j].RunIndex
 	})
 
+	// Sort run samples by run index for deterministic output
+	runs := make([]model.RunSample, len(agg.runs))
+	copy(runs, agg.runs)
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].RunIndex < runs[j].RunIndex
+	})
+	// Test timeouts do not apply to suite setup, which spans the whole file
+	timeout := opts.TestTimeout
+	if agg.timeout > 0 {
+		timeout = agg.timeout
+	}
+	if agg.suite {
+		timeout = 0
+	}
+	durationStats := buildDurationStats(runs, timeout)
+
+	var temporal *model.TemporalAnalysis
+	if classification == model.ClassificationFlaky {
+		temporal = analyzeTemporal(runs)
+	}
+
 	return model.AggregatedTest{
 		TestID:          agg.testID,
+		Suite:           agg.suite,
 		PassCount:       agg.passCount,
 		FailCount:       agg.failCount,
 		SkipCount:       agg.skipCount,
@@ -206,6 +319,15 @@ func buildAggregatedTest(agg *testAggregator, numRuns int) model.AggregatedTest
 		FlakeRate:       flakeRate,
 		WastedTime:      wastedTime,
 		FailureEvidence: sortedEvidence,
+		FailureClusters: buildClusters(agg),
+		Runs:            runs,
+		DurationStats:   durationStats,
+		SlowUnstable:    !agg.suite && isSlowUnstable(classification, durationStats),
+		TimeoutRisk:     isTimeoutRisk(durationStats),
+		Temporal:        temporal,
+		Attempts:        agg.attempts,
+		RetriedRuns:     agg.retriedRuns,
+		FlakyInRunCount: agg.flakyInRunCount,
 	}
 }
 
@@ -276,9 +398,34 @@ func SignatureSummary(tests []model.AggregatedTest) map[string]int {
 	return summary
 }
 
+// FilterTests returns the individual tests, leaving out suite setup results.
+// The returned slice maintains the original sort order.
+func FilterTests(tests []model.AggregatedTest) []model.AggregatedTest {
+	result := make([]model.AggregatedTest, 0)
+	for _, t := range tests {
+		if !t.Suite {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// FilterSuites returns the suite setup results.
+// The returned slice maintains the original sort order.
+func FilterSuites(tests []model.AggregatedTest) []model.AggregatedTest {
+	result := make([]model.AggregatedTest, 0)
+	for _, t := range tests {
+		if t.Suite {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
 // TopFlakes returns up to n flaky tests, sorted by wasted time.
+// Suite setup results are not included.
 func TopFlakes(tests []model.AggregatedTest, n int) []model.AggregatedTest {
-	flaky := FilterByClassification(tests, model.ClassificationFlaky)
+	flaky := FilterByClassification(FilterTests(tests), model.ClassificationFlaky)
 	if len(flaky) <= n {
 		return flaky
 	}
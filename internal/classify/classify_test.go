@@ -561,6 +561,44 @@ func TestTopFlakes(t *testing.T) {
 	if len(allFlakes) != 3 {
 		t.Errorf("expected 3 flakes (all available), got %d", len(allFlakes))
 	}
+
+	tests = append(tests, model.AggregatedTest{TestID: "a.test.js::<suite setup>", Suite: true, Classification: model.ClassificationFlaky, WastedTime: time.Second})
+	if got := TopFlakes(tests, 10); len(got) != 3 || got[0].Suite {
+		t.Errorf("expected suite setup to be excluded from top flakes, got %v", got)
+	}
+}
+
+func TestSuiteSetupAggregation(t *testing.T) {
+	setup := func(outcome model.Outcome) model.TestResult {
+		return model.TestResult{
+			TestID:         "a.test.js::" + model.SuiteSetupName,
+			Outcome:        outcome,
+			Duration:       4 * time.Second,
+			FailureMessage: map[model.Outcome]string{model.OutcomeFail: "beforeAll failed"}[outcome],
+			Suite:          true,
+		}
+	}
+	agg := NewAggregator(Options{TestTimeout: 5 * time.Second})
+	for i, outcome := range []model.Outcome{model.OutcomePass, model.OutcomeFail, model.OutcomePass} {
+		agg.Add(model.RunResult{RunIndex: i + 1, Tests: []model.TestResult{setup(outcome)}})
+	}
+
+	results := agg.Snapshot()
+	if len(results) != 1 || !results[0].Suite {
+		t.Fatalf("expected one suite setup result, got %+v", results)
+	}
+	if results[0].Classification != model.ClassificationFlaky {
+		t.Errorf("Classification = %s, want flaky", results[0].Classification)
+	}
+	if results[0].TimeoutRisk || results[0].DurationStats.Timeout != 0 {
+		t.Error("test timeouts should not apply to suite setup")
+	}
+	if got := FilterSuites(results); len(got) != 1 {
+		t.Errorf("FilterSuites() returned %d results, want 1", len(got))
+	}
+	if got := FilterTests(results); len(got) != 0 {
+		t.Errorf("FilterTests() returned %d results, want 0", len(got))
+	}
 }
 
 func TestFailureEvidenceSortedByRunIndex(t *testing.T) {
@@ -585,3 +623,121 @@ func TestFailureEvidenceSortedByRunIndex(t *testing.T) {
 		t.Errorf("second evidence RunIndex = %d, want 3", test.FailureEvidence[1].RunIndex)
 	}
 }
+
+func TestAggregatorMatchesAggregate(t *testing.T) {
+	runs := []model.RunResult{
+		{RunIndex: 1, Tests: []model.TestResult{
+			{TestID: "a.js::test", Outcome: model.OutcomePass, Duration: 100 * time.Millisecond},
+			{TestID: "b.js::test", Outcome: model.OutcomeFail, Duration: 200 * time.Millisecond, FailureMessage: "timeout"},
+		}},
+		{RunIndex: 2, Tests: []model.TestResult{
+			{TestID: "a.js::test", Outcome: model.OutcomePass, Duration: 100 * time.Millisecond},
+			{TestID: "b.js::test", Outcome: model.OutcomePass, Duration: 200 * time.Millisecond},
+		}},
+	}
+
+	agg := NewAggregator(Options{})
+	for _, run := range runs {
+		agg.Add(run)
+	}
+
+	if agg.Runs() != 2 {
+		t.Errorf("Runs() = %d, want 2", agg.Runs())
+	}
+
+	got := agg.Snapshot()
+	want := Aggregate(runs)
+	if len(got) != len(want) {
+		t.Fatalf("Snapshot() returned %d tests, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].TestID != want[i].TestID || got[i].Classification != want[i].Classification || got[i].WastedTime != want[i].WastedTime {
+			t.Errorf("Snapshot()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAggregatorReportsTestsBecomingFlaky(t *testing.T) {
+	agg := NewAggregator(Options{})
+
+	became := agg.Add(model.RunResult{RunIndex: 1, Tests: []model.TestResult{
+		{TestID: "a.js::test", Outcome: model.OutcomePass},
+		{TestID: "b.js::test", Outcome: model.OutcomePass},
+	}})
+	if len(became) != 0 {
+		t.Fatalf("first run: expected no flaky tests, got %d", len(became))
+	}
+
+	became = agg.Add(model.RunResult{RunIndex: 2, Tests: []model.TestResult{
+		{TestID: "a.js::test", Outcome: model.OutcomeFail, FailureMessage: "timeout"},
+		{TestID: "b.js::test", Outcome: model.OutcomePass},
+	}})
+	if len(became) != 1 || became[0].TestID != "a.js::test" {
+		t.Fatalf("second run: expected a.js::test to become flaky, got %+v", became)
+	}
+	if became[0].Classification != model.ClassificationFlaky {
+		t.Errorf("Classification = %q, want flaky", became[0].Classification)
+	}
+
+	// Already flaky tests are not reported again
+	became = agg.Add(model.RunResult{RunIndex: 3, Tests: []model.TestResult{
+		{TestID: "a.js::test", Outcome: model.OutcomeFail, FailureMessage: "timeout"},
+	}})
+	if len(became) != 0 {
+		t.Errorf("third run: expected no newly flaky tests, got %+v", became)
+	}
+}
+
+func TestRetriedFailuresAreFlakeEvidence(t *testing.T) {
+	retried := model.TestResult{
+		TestID:     "login.cy.js::logs in",
+		Outcome:    model.OutcomePass,
+		Duration:   2 * time.Second,
+		FlakyInRun: true,
+		Attempts: []model.Attempt{
+			{Outcome: model.OutcomeFail, Duration: 4 * time.Second, FailureMessage: "Timed out retrying after 4000ms"},
+			{Outcome: model.OutcomePass, Duration: 2 * time.Second},
+		},
+	}
+	pass := model.TestResult{TestID: "login.cy.js::logs in", Outcome: model.OutcomePass, Duration: 2 * time.Second}
+
+	agg := NewAggregator(Options{})
+	agg.Add(model.RunResult{RunIndex: 1, Tests: []model.TestResult{pass}})
+	became := agg.Add(model.RunResult{RunIndex: 2, Tests: []model.TestResult{retried}})
+	if len(became) != 1 {
+		t.Fatalf("expected the retried test to become flaky, got %+v", became)
+	}
+	agg.Add(model.RunResult{RunIndex: 3, Tests: []model.TestResult{pass}})
+	agg.Add(model.RunResult{RunIndex: 4, Tests: []model.TestResult{pass}})
+
+	test := agg.Snapshot()[0]
+	if test.Classification != model.ClassificationFlaky {
+		t.Errorf("Classification = %s, want flaky", test.Classification)
+	}
+	if test.PassCount != 4 || test.FailCount != 0 {
+		t.Errorf("Pass/Fail = %d/%d, want 4/0", test.PassCount, test.FailCount)
+	}
+	if test.FlakeRate != 0.25 {
+		t.Errorf("FlakeRate = %v, want 0.25", test.FlakeRate)
+	}
+	if test.Attempts != 5 || test.RetriedRuns != 1 || test.FlakyInRunCount != 1 {
+		t.Errorf("Attempts/RetriedRuns/FlakyInRunCount = %d/%d/%d, want 5/1/1", test.Attempts, test.RetriedRuns, test.FlakyInRunCount)
+	}
+	if test.WastedTime <= 0 {
+		t.Error("expected wasted time for a test flaky only on retry")
+	}
+
+	if len(test.FailureEvidence) != 1 {
+		t.Fatalf("expected 1 failure evidence, got %d", len(test.FailureEvidence))
+	}
+	ev := test.FailureEvidence[0]
+	if !ev.Retried || ev.RunIndex != 2 || ev.Signature != model.SignatureTimeout {
+		t.Errorf("evidence = %+v, want retried TIMEOUT in run 2", ev)
+	}
+	if len(test.FailureClusters) != 1 || test.FailureClusters[0].Count != 1 {
+		t.Errorf("expected 1 failure mode, got %+v", test.FailureClusters)
+	}
+	if !test.Runs[1].Failed() || test.Runs[1].Attempts != 2 {
+		t.Errorf("run sample = %+v, want failed with 2 attempts", test.Runs[1])
+	}
+}
@@ -0,0 +1,139 @@
+package classify
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// ansiPattern matches ANSI escape sequences, such as terminal colors.
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// volatilePatterns replace details that vary between runs of the same failure.
+// They are applied in order, so more specific patterns come first.
+var volatilePatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`), "<ts>"},
+	{regexp.MustCompile(`\b\d{1,2}:\d{2}:\d{2}(\.\d+)?\b`), "<ts>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<id>"},
+	{regexp.MustCompile(`(?i)\b0x[0-9a-f]+\b`), "<hex>"},
+	{regexp.MustCompile(`\b[0-9a-f]{8,}\b`), "<hex>"}, // hashes and object ids; all-digit matches are left as numbers
+	{regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://\S+`), "<url>"},
+	{regexp.MustCompile(`(?:[A-Za-z]:)?(?:[\w.@-]*[/\\])+[\w.@-]+`), "<path>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<n>"},
+}
+
+// NormalizeMessage reduces a failure message to a form that is identical across
+// repeated occurrences of the same failure mode. ANSI codes are removed;
+// timestamps, UUIDs, hex ids, URLs, paths and numbers are replaced with
+// placeholders; and whitespace is collapsed.
+func NormalizeMessage(msg string) string {
+	msg = ansiPattern.ReplaceAllString(msg, "")
+	for _, v := range volatilePatterns {
+		msg = v.pattern.ReplaceAllStringFunc(msg, func(m string) string {
+			if v.replacement == "<hex>" && strings.Trim(m, "0123456789") == "" {
+				return m
+			}
+			return v.replacement
+		})
+	}
+	return strings.Join(strings.Fields(msg), " ")
+}
+
+// clusterAggregator accumulates the failures of one failure mode.
+type clusterAggregator struct {
+	fingerprint string
+	signature   model.FailureSignature
+	message     string
+	location    *model.SourceLocation
+	logPath     string
+	screenshot  string
+	video       string
+	mediaRun    int // run of the screenshot and video
+	firstRun    int
+	runIndices  []int
+}
+
+// addFailure records a failure in the cluster matching its location and
+// normalized message.
+func (agg *testAggregator) addFailure(message string, evidence model.FailureEvidence) {
+	normalized := NormalizeMessage(message)
+	key := normalized
+	if evidence.Location != nil {
+		key = evidence.Location.String() + "\n" + normalized
+	}
+
+	cluster, exists := agg.clusters[key]
+	if !exists {
+		cluster = &clusterAggregator{
+			fingerprint: truncateExcerpt(normalized),
+			firstRun:    evidence.RunIndex,
+		}
+		agg.clusters[key] = cluster
+	}
+	if !exists || evidence.RunIndex < cluster.firstRun {
+		// Keep the earliest occurrence as the representative
+		cluster.firstRun = evidence.RunIndex
+		cluster.signature = evidence.Signature
+		cluster.location = evidence.Location
+		cluster.logPath = evidence.LogPath
+		cluster.message = strings.TrimSpace(ansiPattern.ReplaceAllString(message, ""))
+	}
+	if len(evidence.Screenshots) > 0 || evidence.Video != "" {
+		// Keep the media of the earliest occurrence that has any
+		if cluster.mediaRun == 0 || evidence.RunIndex < cluster.mediaRun {
+			cluster.mediaRun = evidence.RunIndex
+			cluster.screenshot = ""
+			if len(evidence.Screenshots) > 0 {
+				cluster.screenshot = evidence.Screenshots[0]
+			}
+			cluster.video = evidence.Video
+		}
+	}
+	cluster.runIndices = append(cluster.runIndices, evidence.RunIndex)
+}
+
+// buildClusters returns the failure clusters of a test, sorted by count
+// descending, then by first run.
+func buildClusters(agg *testAggregator) []model.FailureCluster {
+	if len(agg.clusters) == 0 {
+		return nil
+	}
+
+	clusters := make([]model.FailureCluster, 0, len(agg.clusters))
+	for _, c := range agg.clusters {
+		runIndices := make([]int, len(c.runIndices))
+		copy(runIndices, c.runIndices)
+		sort.Ints(runIndices)
+
+		clusters = append(clusters, model.FailureCluster{
+			Fingerprint: c.fingerprint,
+			Signature:   c.signature,
+			Count:       len(runIndices),
+			FirstRun:    runIndices[0],
+			LastRun:     runIndices[len(runIndices)-1],
+			RunIndices:  runIndices,
+			Message:     c.message,
+			Location:    c.location,
+			LogPath:     c.logPath,
+			Screenshot:  c.screenshot,
+			Video:       c.video,
+		})
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		if clusters[i].FirstRun != clusters[j].FirstRun {
+			return clusters[i].FirstRun < clusters[j].FirstRun
+		}
+		return clusters[i].Fingerprint < clusters[j].Fingerprint
+	})
+
+	return clusters
+}
@@ -0,0 +1,131 @@
+package classify
+
+import (
+	"testing"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "numbers",
+			input:    "Expected 3 items but received 5 after 1234.5ms",
+			expected: "Expected <n> items but received <n> after <n>ms",
+		},
+		{
+			name:     "ansi codes",
+			input:    "\x1b[31mexpect(received).toBe(expected)\x1b[39m",
+			expected: "expect(received).toBe(expected)",
+		},
+		{
+			name:     "timestamps",
+			input:    "Request at 2024-05-01T10:22:33.123Z failed (10:22:34)",
+			expected: "Request at <ts> failed (<ts>)",
+		},
+		{
+			name:     "uuid and hex ids",
+			input:    "User 3f2b8c1e-9a4d-4e5f-8b6a-1c2d3e4f5a6b not found in 0x7ffe3a 507f1f77bcf86cd799439011",
+			expected: "User <id> not found in <hex> <hex>",
+		},
+		{
+			name:     "paths and urls",
+			input:    "at Object.<anonymous> (/home/ci/app/src/Button.test.tsx:42:13) GET http://localhost:3000/api/users?id=7",
+			expected: "at Object.<anonymous> (<path>:<n>:<n>) GET <url>",
+		},
+		{
+			name:     "whitespace",
+			input:    "  Timeout \n\n  exceeded  ",
+			expected: "Timeout exceeded",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := NormalizeMessage(tc.input)
+			if got != tc.expected {
+				t.Errorf("NormalizeMessage(%q) = %q, want %q", tc.input, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAggregateClustersFailures(t *testing.T) {
+	fail := func(msg string) model.TestResult {
+		return model.TestResult{TestID: "a.test.js::flaky", Outcome: model.OutcomeFail, FailureMessage: msg}
+	}
+	pass := model.TestResult{TestID: "a.test.js::flaky", Outcome: model.OutcomePass}
+
+	runs := []model.RunResult{
+		{RunIndex: 1, Tests: []model.TestResult{fail("Timeout of 5000ms exceeded at 2024-05-01T10:00:00Z")}},
+		{RunIndex: 2, Tests: []model.TestResult{pass}},
+		{RunIndex: 3, Tests: []model.TestResult{fail("expect(received).toBe(expected)\nExpected: 3\nReceived: 4")}},
+		{RunIndex: 4, Tests: []model.TestResult{fail("Timeout of 5000ms exceeded at 2024-05-01T10:07:12Z")}},
+		{RunIndex: 5, Tests: []model.TestResult{fail("Timeout of 5000ms exceeded at 2024-05-01T10:09:45Z")}},
+	}
+
+	results := Aggregate(runs)
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+
+	clusters := results[0].FailureClusters
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d: %+v", len(clusters), clusters)
+	}
+
+	timeout := clusters[0]
+	if timeout.Signature != model.SignatureTimeout {
+		t.Errorf("clusters[0].Signature = %s, want TIMEOUT", timeout.Signature)
+	}
+	if timeout.Count != 3 || timeout.FirstRun != 1 || timeout.LastRun != 5 {
+		t.Errorf("clusters[0] = count %d, runs %d-%d, want count 3, runs 1-5", timeout.Count, timeout.FirstRun, timeout.LastRun)
+	}
+	if timeout.Message != "Timeout of 5000ms exceeded at 2024-05-01T10:00:00Z" {
+		t.Errorf("clusters[0].Message = %q, want first occurrence", timeout.Message)
+	}
+
+	assertion := clusters[1]
+	if assertion.Count != 1 || assertion.FirstRun != 3 {
+		t.Errorf("clusters[1] = count %d, first run %d, want count 1, first run 3", assertion.Count, assertion.FirstRun)
+	}
+	if assertion.Message != "expect(received).toBe(expected)\nExpected: 3\nReceived: 4" {
+		t.Errorf("clusters[1].Message = %q, want full multi-line message", assertion.Message)
+	}
+}
+
+func TestClusterMedia(t *testing.T) {
+	failure := func(screenshots []string, video string) model.TestResult {
+		return model.TestResult{
+			TestID:         "login.cy.js::logs in",
+			Outcome:        model.OutcomeFail,
+			FailureMessage: "Timed out retrying after 4000ms",
+			Screenshots:    screenshots,
+			Video:          video,
+		}
+	}
+	runs := []model.RunResult{
+		{RunIndex: 1, Tests: []model.TestResult{failure(nil, "")}},
+		{RunIndex: 2, Tests: []model.TestResult{failure([]string{"runs/002/screenshots/a.png", "runs/002/screenshots/b.png"}, "runs/002/videos/login.cy.js.mp4")}},
+		{RunIndex: 3, Tests: []model.TestResult{failure([]string{"runs/003/screenshots/a.png"}, "")}},
+	}
+
+	results := Aggregate(runs)
+	clusters := results[0].FailureClusters
+	if len(clusters) != 1 {
+		t.Fatalf("expected 1 cluster, got %d", len(clusters))
+	}
+	if clusters[0].Screenshot != "runs/002/screenshots/a.png" {
+		t.Errorf("Screenshot = %q, want first screenshot of run 2", clusters[0].Screenshot)
+	}
+	if clusters[0].Video != "runs/002/videos/login.cy.js.mp4" {
+		t.Errorf("Video = %q, want video of run 2", clusters[0].Video)
+	}
+	if got := results[0].FailureEvidence[2].Screenshots; len(got) != 1 {
+		t.Errorf("evidence screenshots = %v, want run 3 screenshot", got)
+	}
+}
@@ -0,0 +1,209 @@
+package classify
+
+import (
+	"math"
+	"sort"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// Thresholds for linking two tests whose failures co-occur.
+const (
+	// minCoFailures is the number of runs in which both tests must fail.
+	minCoFailures = 2
+	// minCoFailureJaccard is the minimum overlap of the tests' failed runs.
+	minCoFailureJaccard = 0.5
+	// maxCoFailurePValue is the significance level of the overlap under the
+	// hypothesis that the tests fail independently.
+	maxCoFailurePValue = 0.01
+)
+
+// coFailureLink is a pair of tests whose failures co-occur.
+type coFailureLink struct {
+	a, b    int
+	jaccard float64
+}
+
+// CoFailureGroups clusters flaky tests whose failures co-occur in the same
+// runs far more often than if they failed independently. Pairs are linked
+// when their overlap is large and unlikely by chance (one-sided
+// hypergeometric test), and linked tests are grouped transitively. Groups are
+// sorted by size, then by the number of shared failed runs, descending.
+func CoFailureGroups(tests []model.AggregatedTest) []model.CoFailureGroup {
+	// Outcomes of each flaky test by run index, excluding skips
+	var flaky []model.AggregatedTest
+	var outcomes []map[int]bool // run index -> failed
+	for _, t := range tests {
+		if t.Classification != model.ClassificationFlaky {
+			continue
+		}
+		runs := make(map[int]bool, len(t.Runs))
+		for _, run := range t.Runs {
+			if run.Outcome != model.OutcomeSkip {
+				runs[run.RunIndex] = run.Failed()
+			}
+		}
+		flaky = append(flaky, t)
+		outcomes = append(outcomes, runs)
+	}
+
+	var links []coFailureLink
+	for i := range flaky {
+		for j := i + 1; j < len(flaky); j++ {
+			if jaccard, ok := coFails(outcomes[i], outcomes[j]); ok {
+				links = append(links, coFailureLink{a: i, b: j, jaccard: jaccard})
+			}
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	// Group linked tests transitively
+	parent := make([]int, len(flaky))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, l := range links {
+		parent[find(l.a)] = find(l.b)
+	}
+
+	members := make(map[int][]int)
+	jaccardSum := make(map[int]float64)
+	linkCount := make(map[int]int)
+	for _, l := range links {
+		root := find(l.a)
+		jaccardSum[root] += l.jaccard
+		linkCount[root]++
+	}
+	for i := range flaky {
+		if linkCount[find(i)] > 0 {
+			members[find(i)] = append(members[find(i)], i)
+		}
+	}
+
+	groups := make([]model.CoFailureGroup, 0, len(members))
+	for root, idx := range members {
+		groups = append(groups, buildCoFailureGroup(flaky, outcomes, idx, jaccardSum[root]/float64(linkCount[root])))
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].TestIDs) != len(groups[j].TestIDs) {
+			return len(groups[i].TestIDs) > len(groups[j].TestIDs)
+		}
+		if len(groups[i].RunIndices) != len(groups[j].RunIndices) {
+			return len(groups[i].RunIndices) > len(groups[j].RunIndices)
+		}
+		return groups[i].TestIDs[0] < groups[j].TestIDs[0]
+	})
+	return groups
+}
+
+// coFails reports whether two tests' failures co-occur more than chance,
+// and returns the Jaccard similarity of their failed runs. Only runs in
+// which both tests executed are compared.
+func coFails(a, b map[int]bool) (float64, bool) {
+	n, failsA, failsB, both := 0, 0, 0, 0
+	for run, failedA := range a {
+		failedB, ok := b[run]
+		if !ok {
+			continue
+		}
+		n++
+		if failedA {
+			failsA++
+		}
+		if failedB {
+			failsB++
+		}
+		if failedA && failedB {
+			both++
+		}
+	}
+	if both < minCoFailures {
+		return 0, false
+	}
+
+	jaccard := float64(both) / float64(failsA+failsB-both)
+	if jaccard < minCoFailureJaccard {
+		return 0, false
+	}
+	return jaccard, hypergeometricTail(n, failsA, failsB, both) <= maxCoFailurePValue
+}
+
+// hypergeometricTail returns the probability that two independent tests
+// failing in successes and draws of n runs fail together in at least k runs.
+func hypergeometricTail(n, successes, draws, k int) float64 {
+	logChoose := func(n, k int) float64 {
+		a, _ := math.Lgamma(float64(n + 1))
+		b, _ := math.Lgamma(float64(k + 1))
+		c, _ := math.Lgamma(float64(n - k + 1))
+		return a - b - c
+	}
+
+	total := logChoose(n, draws)
+	var p float64
+	for i := k; i <= min(successes, draws); i++ {
+		if draws-i > n-successes {
+			continue
+		}
+		p += math.Exp(logChoose(successes, i) + logChoose(n-successes, draws-i) - total)
+	}
+	return p
+}
+
+// buildCoFailureGroup summarizes the tests at idx as a co-failure group.
+func buildCoFailureGroup(flaky []model.AggregatedTest, outcomes []map[int]bool, idx []int, jaccard float64) model.CoFailureGroup {
+	// Runs in which at least two of the tests failed
+	failures := make(map[int]int)
+	for _, i := range idx {
+		for run, failed := range outcomes[i] {
+			if failed {
+				failures[run]++
+			}
+		}
+	}
+	shared := make(map[int]bool)
+	runIndices := make([]int, 0)
+	for run, count := range failures {
+		if count >= 2 {
+			shared[run] = true
+			runIndices = append(runIndices, run)
+		}
+	}
+	sort.Ints(runIndices)
+
+	// Most common signature of the tests' failures in the shared runs
+	signatures := make(map[model.FailureSignature]int)
+	testIDs := make([]string, 0, len(idx))
+	for _, i := range idx {
+		testIDs = append(testIDs, flaky[i].TestID)
+		for _, ev := range flaky[i].FailureEvidence {
+			if shared[ev.RunIndex] {
+				signatures[ev.Signature]++
+			}
+		}
+	}
+	sort.Strings(testIDs)
+
+	signature := model.SignatureUnknown
+	best := 0
+	for sig, count := range signatures {
+		if count > best || (count == best && sig < signature) {
+			signature, best = sig, count
+		}
+	}
+
+	return model.CoFailureGroup{
+		TestIDs:    testIDs,
+		RunIndices: runIndices,
+		Signature:  signature,
+		Jaccard:    jaccard,
+	}
+}
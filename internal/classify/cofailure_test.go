@@ -0,0 +1,127 @@
+package classify
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// matrixRuns builds runs from per-test pass/fail sequences such as "PFPPF".
+// All sequences must have the same length.
+func matrixRuns(sequences map[string]string, message string) []model.RunResult {
+	var n int
+	for _, seq := range sequences {
+		n = len(seq)
+	}
+
+	runs := make([]model.RunResult, n)
+	for i := range runs {
+		runs[i].RunIndex = i + 1
+		for id, seq := range sequences {
+			test := model.TestResult{TestID: id, Outcome: model.OutcomePass}
+			switch seq[i] {
+			case 'F':
+				test.Outcome = model.OutcomeFail
+				test.FailureMessage = message
+			case 'S':
+				test.Outcome = model.OutcomeSkip
+			}
+			runs[i].Tests = append(runs[i].Tests, test)
+		}
+	}
+	return runs
+}
+
+func TestCoFailureGroups(t *testing.T) {
+	runs := matrixRuns(map[string]string{
+		"db.test.js::reads":   "PFPPFPPFPPPFPPPFPPPP",
+		"db.test.js::writes":  "PFPPFPPFPPPFPPPFPPPP",
+		"api.test.js::list":   "PFPPFPPPPPPFPPPFPPPP",
+		"ui.test.js::renders": "FPPPPPFPPFPPPPPPPPFP",
+		"ui.test.js::stable":  "PPPPPPPPPPPPPPPPPPPP",
+	}, "Error: connect ECONNREFUSED 127.0.0.1:5432")
+
+	groups := CoFailureGroups(Aggregate(runs))
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d: %+v", len(groups), groups)
+	}
+
+	group := groups[0]
+	wantIDs := []string{"api.test.js::list", "db.test.js::reads", "db.test.js::writes"}
+	if !slices.Equal(group.TestIDs, wantIDs) {
+		t.Errorf("TestIDs = %v, want %v", group.TestIDs, wantIDs)
+	}
+	if want := []int{2, 5, 8, 12, 16}; !slices.Equal(group.RunIndices, want) {
+		t.Errorf("RunIndices = %v, want %v", group.RunIndices, want)
+	}
+	if group.Signature != model.SignatureNetwork {
+		t.Errorf("Signature = %s, want NETWORK", group.Signature)
+	}
+	if group.Jaccard <= minCoFailureJaccard || group.Jaccard > 1 {
+		t.Errorf("Jaccard = %.2f, want in (%.1f, 1]", group.Jaccard, minCoFailureJaccard)
+	}
+}
+
+func TestCoFailureGroupsIndependent(t *testing.T) {
+	tests := []struct {
+		name      string
+		sequences map[string]string
+	}{
+		{
+			name: "disjoint failures",
+			sequences: map[string]string{
+				"a.test.js::x": "FPPPPFPPPP",
+				"b.test.js::y": "PPFPPPPFPP",
+			},
+		},
+		{
+			name: "single shared failure",
+			sequences: map[string]string{
+				"a.test.js::x": "FPPPPPPPPP",
+				"b.test.js::y": "FPPPPPPPPP",
+			},
+		},
+		{
+			name: "frequent failures overlap by chance",
+			sequences: map[string]string{
+				"a.test.js::x": "FFPFFPFFPF",
+				"b.test.js::y": "FPFFPFFFFP",
+			},
+		},
+		{
+			name: "shared runs skipped",
+			sequences: map[string]string{
+				"a.test.js::x": "PFPPFPPFPP",
+				"b.test.js::y": "PSPPSPPFPP",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if groups := CoFailureGroups(Aggregate(matrixRuns(tt.sequences, "Error"))); len(groups) != 0 {
+				t.Errorf("expected no groups, got %+v", groups)
+			}
+		})
+	}
+}
+
+func TestHypergeometricTail(t *testing.T) {
+	tests := []struct {
+		n, successes, draws, k int
+		want                   float64
+	}{
+		{n: 10, successes: 3, draws: 3, k: 3, want: 1.0 / 120},
+		{n: 10, successes: 3, draws: 3, k: 0, want: 1},
+		{n: 4, successes: 2, draws: 2, k: 1, want: 5.0 / 6},
+	}
+
+	for _, tt := range tests {
+		got := hypergeometricTail(tt.n, tt.successes, tt.draws, tt.k)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("hypergeometricTail(%d, %d, %d, %d) = %v, want %v", tt.n, tt.successes, tt.draws, tt.k, got, tt.want)
+		}
+	}
+}
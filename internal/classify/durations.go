@@ -0,0 +1,143 @@
+package classify
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// Thresholds for duration-based flags.
+const (
+	// minVarianceSamples is the number of timed runs needed to judge variance.
+	minVarianceSamples = 5
+	// slowUnstableCV is the minimum coefficient of variation of a slow-unstable test.
+	slowUnstableCV = 0.5
+	// slowUnstableRatio is the minimum max/median ratio of a slow-unstable test.
+	slowUnstableRatio = 3.0
+	// slowUnstableMinMax ignores variance in tests that are always fast.
+	slowUnstableMinMax = time.Second
+	// timeoutRiskShare is the share of the timeout above which p95 is a risk.
+	timeoutRiskShare = 0.8
+)
+
+// buildDurationStats summarizes the durations of the timed (non-skipped) runs.
+// It returns nil if there are none.
+func buildDurationStats(runs []model.RunSample, timeout time.Duration) *model.DurationStats {
+	var durations []time.Duration
+	for _, run := range runs {
+		if run.Outcome != model.OutcomeSkip {
+			durations = append(durations, run.Duration)
+		}
+	}
+	if len(durations) == 0 {
+		return nil
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+
+	var sum float64
+	for _, d := range durations {
+		sum += float64(d)
+	}
+	mean := sum / float64(len(durations))
+
+	var sqDiff float64
+	for _, d := range durations {
+		sqDiff += (float64(d) - mean) * (float64(d) - mean)
+	}
+	stdDev := math.Sqrt(sqDiff / float64(len(durations)))
+
+	stats := &model.DurationStats{
+		Samples: len(durations),
+		Min:     durations[0],
+		Median:  percentile(durations, 0.5),
+		P95:     percentile(durations, 0.95),
+		Max:     durations[len(durations)-1],
+		Timeout: timeout,
+	}
+	if mean > 0 {
+		stats.CV = stdDev / mean
+	}
+	if stats.Median > 0 {
+		stats.MaxMedianRatio = float64(stats.Max) / float64(stats.Median)
+	}
+	if timeout > 0 {
+		stats.TimeoutShare = float64(stats.P95) / float64(timeout)
+	}
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p (0-1] of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	rank = max(0, min(rank, len(sorted)-1))
+	return sorted[rank]
+}
+
+// isSlowUnstable reports whether a consistently passing test has extreme
+// duration variance.
+func isSlowUnstable(classification model.Classification, stats *model.DurationStats) bool {
+	if classification != model.ClassificationStable || stats == nil || stats.Samples < minVarianceSamples {
+		return false
+	}
+	return stats.Max >= slowUnstableMinMax &&
+		stats.CV >= slowUnstableCV &&
+		stats.MaxMedianRatio >= slowUnstableRatio
+}
+
+// isTimeoutRisk reports whether a test's p95 duration is close to its timeout.
+func isTimeoutRisk(stats *model.DurationStats) bool {
+	if stats == nil || stats.Timeout <= 0 {
+		return false
+	}
+	return stats.TimeoutShare >= timeoutRiskShare
+}
+
+// FilterSlowUnstable returns the tests flagged as slow-unstable, sorted by
+// max/median ratio descending.
+func FilterSlowUnstable(tests []model.AggregatedTest) []model.AggregatedTest {
+	result := make([]model.AggregatedTest, 0)
+	for _, t := range tests {
+		if t.SlowUnstable {
+			result = append(result, t)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DurationStats.MaxMedianRatio > result[j].DurationStats.MaxMedianRatio
+	})
+	return result
+}
+
+// FilterTimeoutRisk returns the tests flagged as timeout risks, sorted by
+// p95 duration as a share of the timeout, descending.
+func FilterTimeoutRisk(tests []model.AggregatedTest) []model.AggregatedTest {
+	result := make([]model.AggregatedTest, 0)
+	for _, t := range RankByTimeoutHeadroom(tests) {
+		if t.TimeoutRisk {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// RankByTimeoutHeadroom returns the tests with a known timeout, sorted by
+// p95 duration as a share of the timeout, descending, so that the tests
+// running closest to their limit come first.
+func RankByTimeoutHeadroom(tests []model.AggregatedTest) []model.AggregatedTest {
+	result := make([]model.AggregatedTest, 0)
+	for _, t := range tests {
+		if t.DurationStats != nil && t.DurationStats.Timeout > 0 {
+			result = append(result, t)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].DurationStats.TimeoutShare != result[j].DurationStats.TimeoutShare {
+			return result[i].DurationStats.TimeoutShare > result[j].DurationStats.TimeoutShare
+		}
+		return result[i].TestID < result[j].TestID
+	})
+	return result
+}
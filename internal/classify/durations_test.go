@@ -0,0 +1,121 @@
+package classify
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// timedRuns builds passing runs of a single test with the given durations.
+func timedRuns(testID string, durations ...time.Duration) []model.RunResult {
+	runs := make([]model.RunResult, len(durations))
+	for i, d := range durations {
+		runs[i] = model.RunResult{
+			RunIndex: i + 1,
+			Tests:    []model.TestResult{{TestID: testID, Outcome: model.OutcomePass, Duration: d}},
+		}
+	}
+	return runs
+}
+
+func TestDurationStats(t *testing.T) {
+	ms := time.Millisecond
+	results := Aggregate(timedRuns("a.test.js::slow", 200*ms, 250*ms, 180*ms, 9000*ms, 220*ms, 210*ms))
+
+	stats := results[0].DurationStats
+	if stats == nil {
+		t.Fatal("expected duration stats")
+	}
+	if stats.Samples != 6 {
+		t.Errorf("Samples = %d, want 6", stats.Samples)
+	}
+	if stats.Min != 180*ms || stats.Max != 9000*ms {
+		t.Errorf("Min/Max = %v/%v, want 180ms/9s", stats.Min, stats.Max)
+	}
+	if stats.Median != 210*ms {
+		t.Errorf("Median = %v, want 210ms", stats.Median)
+	}
+	if stats.P95 != 9000*ms {
+		t.Errorf("P95 = %v, want 9s", stats.P95)
+	}
+	if math.Abs(stats.MaxMedianRatio-9000.0/210.0) > 0.001 {
+		t.Errorf("MaxMedianRatio = %f, want %f", stats.MaxMedianRatio, 9000.0/210.0)
+	}
+	if stats.CV < 1 {
+		t.Errorf("CV = %f, want > 1", stats.CV)
+	}
+	if len(results[0].Runs) != 6 || results[0].Runs[3].Duration != 9000*ms {
+		t.Errorf("Runs = %+v, want 6 samples in run order", results[0].Runs)
+	}
+}
+
+func TestSlowUnstable(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		expected  bool
+	}{
+		{
+			name:      "extreme variance",
+			durations: []time.Duration{200 * ms, 250 * ms, 180 * ms, 9000 * ms, 220 * ms, 210 * ms},
+			expected:  true,
+		},
+		{
+			name:      "consistent durations",
+			durations: []time.Duration{200 * ms, 250 * ms, 180 * ms, 300 * ms, 220 * ms, 210 * ms},
+			expected:  false,
+		},
+		{
+			name:      "always fast",
+			durations: []time.Duration{2 * ms, 3 * ms, 2 * ms, 90 * ms, 2 * ms, 2 * ms},
+			expected:  false,
+		},
+		{
+			name:      "too few runs",
+			durations: []time.Duration{200 * ms, 9000 * ms, 210 * ms},
+			expected:  false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := Aggregate(timedRuns("a.test.js::x", tc.durations...))
+			if results[0].SlowUnstable != tc.expected {
+				t.Errorf("SlowUnstable = %v, want %v (stats %+v)", results[0].SlowUnstable, tc.expected, results[0].DurationStats)
+			}
+		})
+	}
+}
+
+func TestTimeoutRisk(t *testing.T) {
+	ms := time.Millisecond
+	runs := append(
+		timedRuns("a.test.js::near", 4000*ms, 4200*ms, 4500*ms),
+		timedRuns("a.test.js::far", 1000*ms, 1100*ms, 1200*ms)...,
+	)
+	// Give the second test its own run indices
+	for i := 3; i < 6; i++ {
+		runs[i].RunIndex = i + 1
+	}
+
+	agg := NewAggregator(Options{TestTimeout: 5 * time.Second})
+	for _, run := range runs {
+		agg.Add(run)
+	}
+
+	risky := FilterTimeoutRisk(agg.Snapshot())
+	if len(risky) != 1 || risky[0].TestID != "a.test.js::near" {
+		t.Fatalf("FilterTimeoutRisk() = %v, want only a.test.js::near", risky)
+	}
+	if risky[0].DurationStats.Timeout != 5*time.Second {
+		t.Errorf("Timeout = %v, want 5s", risky[0].DurationStats.Timeout)
+	}
+
+	// Without a timeout nothing is flagged
+	if got := FilterTimeoutRisk(Aggregate(runs)); len(got) != 0 {
+		t.Errorf("expected no timeout risks without a timeout, got %d", len(got))
+	}
+}
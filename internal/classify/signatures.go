@@ -0,0 +1,246 @@
+package classify
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// DefaultCustomPriority is the priority of user-defined rules that do not set
+// one. It is above every built-in rule, so custom signatures win by default.
+const DefaultCustomPriority = 100
+
+// Rule maps failure messages to a signature.
+// A rule matches if any of its patterns or substrings matches.
+type Rule struct {
+	Signature model.FailureSignature
+	Patterns  []*regexp.Regexp
+	Contains  []string     // case-insensitive substrings
+	Priority  int          // higher priorities are checked first
+	Tools     []model.Tool // tools the rule applies to; empty means all
+	Builtin   bool
+}
+
+// builtinRules are the default signature rules, from highest to lowest priority.
+var builtinRules = []Rule{
+	{
+		Signature: model.SignatureTimeout,
+		Priority:  50,
+		Builtin:   true,
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)timeout`),
+			regexp.MustCompile(`(?i)timed?\s*out`),
+			regexp.MustCompile(`(?i)exceeded\s*time`),
+		},
+	},
+	{
+		Signature: model.SignatureSelector,
+		Priority:  40,
+		Builtin:   true,
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)selector`),
+			regexp.MustCompile(`(?i)element\s*not\s*found`),
+			regexp.MustCompile(`(?i)cy\.get`),
+		},
+	},
+	{
+		Signature: model.SignatureNetwork,
+		Priority:  30,
+		Builtin:   true,
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)network`),
+			regexp.MustCompile(`(?i)ECONNREFUSED`),
+			regexp.MustCompile(`(?i)fetch\s*failed`),
+		},
+	},
+	{
+		Signature: model.SignatureDOMDetach,
+		Priority:  20,
+		Builtin:   true,
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)detached`),
+			regexp.MustCompile(`(?i)stale\s*element`),
+		},
+	},
+	{
+		Signature: model.SignatureAssertion,
+		Priority:  10,
+		Builtin:   true,
+		Patterns: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)\bexpect\b`),
+			regexp.MustCompile(`(?i)\bassert`),
+			regexp.MustCompile(`(?i)\btoBe\b`),
+			regexp.MustCompile(`(?i)\btoEqual\b`),
+		},
+	},
+}
+
+// defaultRules contains only the built-in rules.
+var defaultRules = NewRuleSet(nil)
+
+// NewRule compiles a user-defined rule. A nil priority selects DefaultCustomPriority.
+func NewRule(name string, patterns, contains []string, priority *int, tools []string) (Rule, error) {
+	if name == "" {
+		return Rule{}, fmt.Errorf("signature name is required")
+	}
+	if len(patterns) == 0 && len(contains) == 0 {
+		return Rule{}, fmt.Errorf("signature %s must define at least one pattern or substring", name)
+	}
+
+	rule := Rule{
+		Signature: model.FailureSignature(name),
+		Priority:  DefaultCustomPriority,
+	}
+	if priority != nil {
+		rule.Priority = *priority
+	}
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return Rule{}, fmt.Errorf("invalid pattern %q for signature %s: %w", p, name, err)
+		}
+		rule.Patterns = append(rule.Patterns, re)
+	}
+
+	for _, c := range contains {
+		if c == "" {
+			return Rule{}, fmt.Errorf("empty substring for signature %s", name)
+		}
+		rule.Contains = append(rule.Contains, c)
+	}
+
+	for _, t := range tools {
+		switch model.Tool(t) {
+		case model.ToolJest, model.ToolCypress:
+			rule.Tools = append(rule.Tools, model.Tool(t))
+		default:
+			return Rule{}, fmt.Errorf("unknown tool %q for signature %s. Supported tools: jest, cypress", t, name)
+		}
+	}
+
+	return rule, nil
+}
+
+// RuleSet is an ordered collection of signature rules.
+type RuleSet struct {
+	rules []Rule
+}
+
+// NewRuleSet merges custom rules with the built-in rules.
+// Rules are ordered by priority (descending); on equal priority custom rules
+// come before built-in rules, and otherwise definition order is kept.
+func NewRuleSet(custom []Rule) *RuleSet {
+	rules := make([]Rule, 0, len(custom)+len(builtinRules))
+	rules = append(rules, custom...)
+	rules = append(rules, builtinRules...)
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority > rules[j].Priority
+		}
+		return !rules[i].Builtin && rules[j].Builtin
+	})
+
+	return &RuleSet{rules: rules}
+}
+
+// Rules returns the rules in the order they are checked.
+func (rs *RuleSet) Rules() []Rule {
+	return rs.rules
+}
+
+// Match describes a rule that matched a failure message.
+type Match struct {
+	Rule    Rule
+	Matcher string // the pattern or substring that matched
+}
+
+// Detect returns the signature of the highest-precedence rule matching the
+// message for the given tool, or SignatureUnknown. An empty tool matches
+// rules scoped to any tool.
+func (rs *RuleSet) Detect(failureMessage string, tool model.Tool) model.FailureSignature {
+	matches := rs.match(failureMessage, tool, true)
+	if len(matches) == 0 {
+		return model.SignatureUnknown
+	}
+	return matches[0].Rule.Signature
+}
+
+// Matches returns every rule matching the message for the given tool, in
+// precedence order. The first match determines the signature.
+func (rs *RuleSet) Matches(failureMessage string, tool model.Tool) []Match {
+	return rs.match(failureMessage, tool, false)
+}
+
+func (rs *RuleSet) match(failureMessage string, tool model.Tool, firstOnly bool) []Match {
+	if failureMessage == "" {
+		return nil
+	}
+
+	lower := strings.ToLower(failureMessage)
+	var matches []Match
+	for _, rule := range rs.rules {
+		if !rule.AppliesTo(tool) {
+			continue
+		}
+		matcher, ok := rule.match(failureMessage, lower)
+		if !ok {
+			continue
+		}
+		matches = append(matches, Match{Rule: rule, Matcher: matcher})
+		if firstOnly {
+			break
+		}
+	}
+	return matches
+}
+
+// AppliesTo reports whether the rule is scoped to the tool.
+// Every rule applies to an empty tool.
+func (r Rule) AppliesTo(tool model.Tool) bool {
+	if len(r.Tools) == 0 || tool == "" {
+		return true
+	}
+	for _, t := range r.Tools {
+		if t == tool {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns the first pattern or substring matching the message.
+// lower is the lowercased message, used for substring matching.
+func (r Rule) match(message, lower string) (string, bool) {
+	for _, pattern := range r.Patterns {
+		if pattern.MatchString(message) {
+			return pattern.String(), true
+		}
+	}
+	for _, c := range r.Contains {
+		if strings.Contains(lower, strings.ToLower(c)) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+// detectSignature detects the signature of a failure message using the configured rules.
+func (o Options) detectSignature(failureMessage string) model.FailureSignature {
+	rules := o.Rules
+	if rules == nil {
+		rules = defaultRules
+	}
+	return rules.Detect(failureMessage, o.Tool)
+}
+
+// DetectSignature analyzes a failure message and returns the appropriate signature
+// using the built-in rules. Rules are checked in a deterministic order; first match wins.
+// Returns SignatureUnknown if no rules match.
+func DetectSignature(failureMessage string) model.FailureSignature {
+	return defaultRules.Detect(failureMessage, "")
+}
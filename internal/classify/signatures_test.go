@@ -0,0 +1,180 @@
+package classify
+
+import (
+	"testing"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// mustRule compiles a custom rule or fails the test.
+func mustRule(t *testing.T, name string, patterns, contains []string, priority *int, tools []string) Rule {
+	t.Helper()
+	rule, err := NewRule(name, patterns, contains, priority, tools)
+	if err != nil {
+		t.Fatalf("NewRule(%s) failed: %v", name, err)
+	}
+	return rule
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestRuleSetDetect(t *testing.T) {
+	rules := NewRuleSet([]Rule{
+		mustRule(t, "PRISMA", []string{`PrismaClient\w+Error`}, nil, nil, nil),
+		mustRule(t, "REDIS", nil, []string{"redis connection lost"}, nil, nil),
+		mustRule(t, "FLAKY_FIXTURE", nil, []string{"fixture"}, intPtr(5), nil),
+		mustRule(t, "CY_INTERCEPT", nil, []string{"cy.wait() timed out"}, nil, []string{"cypress"}),
+	})
+
+	tests := []struct {
+		name     string
+		message  string
+		tool     model.Tool
+		expected model.FailureSignature
+	}{
+		{
+			name:     "custom regex beats built-in assertion",
+			message:  "PrismaClientKnownRequestError: expect unique constraint",
+			expected: "PRISMA",
+		},
+		{
+			name:     "substring match is case-insensitive",
+			message:  "Error: Redis Connection Lost while reading",
+			expected: "REDIS",
+		},
+		{
+			name:     "low priority custom rule loses to built-in",
+			message:  "fixture setup timed out",
+			expected: model.SignatureTimeout,
+		},
+		{
+			name:     "low priority custom rule matches when built-ins do not",
+			message:  "fixture users.json missing",
+			expected: "FLAKY_FIXTURE",
+		},
+		{
+			name:     "tool-scoped rule applies to its tool",
+			message:  "cy.wait() timed out waiting for route",
+			tool:     model.ToolCypress,
+			expected: "CY_INTERCEPT",
+		},
+		{
+			name:     "tool-scoped rule skipped for other tools",
+			message:  "cy.wait() timed out waiting for route",
+			tool:     model.ToolJest,
+			expected: model.SignatureTimeout,
+		},
+		{
+			name:     "no match",
+			message:  "Something went wrong",
+			expected: model.SignatureUnknown,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := rules.Detect(tc.message, tc.tool)
+			if got != tc.expected {
+				t.Errorf("Detect(%q, %q) = %q, want %q", tc.message, tc.tool, got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestRuleSetOrder(t *testing.T) {
+	rules := NewRuleSet([]Rule{
+		mustRule(t, "LOW", nil, []string{"x"}, intPtr(10), nil),
+		mustRule(t, "HIGH", nil, []string{"x"}, intPtr(200), nil),
+	})
+
+	got := rules.Rules()
+	want := []model.FailureSignature{
+		"HIGH",
+		model.SignatureTimeout,
+		model.SignatureSelector,
+		model.SignatureNetwork,
+		model.SignatureDOMDetach,
+		"LOW", // custom rules come before built-ins of equal priority
+		model.SignatureAssertion,
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d rules, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].Signature != want[i] {
+			t.Errorf("rule %d = %s, want %s", i, got[i].Signature, want[i])
+		}
+	}
+}
+
+func TestRuleSetMatches(t *testing.T) {
+	rules := NewRuleSet([]Rule{
+		mustRule(t, "PRISMA", []string{`Prisma`}, nil, nil, nil),
+	})
+
+	matches := rules.Matches("Prisma request timed out: expect retry", "")
+	if len(matches) != 3 {
+		t.Fatalf("expected 3 matches, got %d", len(matches))
+	}
+
+	want := []model.FailureSignature{"PRISMA", model.SignatureTimeout, model.SignatureAssertion}
+	for i, m := range matches {
+		if m.Rule.Signature != want[i] {
+			t.Errorf("match %d = %s, want %s", i, m.Rule.Signature, want[i])
+		}
+	}
+	if matches[0].Matcher != "Prisma" {
+		t.Errorf("Matcher = %q, want Prisma", matches[0].Matcher)
+	}
+}
+
+func TestNewRuleErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		sigName  string
+		patterns []string
+		contains []string
+		tools    []string
+	}{
+		{name: "missing name", patterns: []string{"x"}},
+		{name: "no matchers", sigName: "EMPTY"},
+		{name: "invalid regex", sigName: "BROKEN", patterns: []string{"(unclosed"}},
+		{name: "empty substring", sigName: "BLANK", contains: []string{""}},
+		{name: "unknown tool", sigName: "MOCHA", contains: []string{"x"}, tools: []string{"mocha"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewRule(tc.sigName, tc.patterns, tc.contains, nil, tc.tools); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestAggregateWithCustomRules(t *testing.T) {
+	rules := NewRuleSet([]Rule{
+		mustRule(t, "PRISMA", []string{`PrismaClient\w+Error`}, nil, nil, nil),
+	})
+
+	agg := NewAggregator(Options{Rules: rules, Tool: model.ToolJest})
+	agg.Add(model.RunResult{RunIndex: 1, Tests: []model.TestResult{
+		{TestID: "db.js::query", Outcome: model.OutcomeFail, FailureMessage: "PrismaClientKnownRequestError: expect unique constraint"},
+		{TestID: "ui.js::click", Outcome: model.OutcomeFail, FailureMessage: "Timeout waiting for element"},
+	}})
+
+	signatures := make(map[string]model.FailureSignature)
+	for _, test := range agg.Snapshot() {
+		signatures[test.TestID] = test.FailureEvidence[0].Signature
+	}
+
+	if signatures["db.js::query"] != "PRISMA" {
+		t.Errorf("db.js::query signature = %q, want PRISMA", signatures["db.js::query"])
+	}
+	if signatures["ui.js::click"] != model.SignatureTimeout {
+		t.Errorf("ui.js::click signature = %q, want TIMEOUT", signatures["ui.js::click"])
+	}
+}
@@ -0,0 +1,142 @@
+package classify
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// StackFrame is a single frame of a JavaScript stack trace.
+type StackFrame struct {
+	Function string
+	model.SourceLocation
+}
+
+var (
+	// v8FramePattern matches V8 frames: "at fn (file:1:2)" or "at file:1:2".
+	v8FramePattern = regexp.MustCompile(`^\s*at\s+(?:(.*?)\s+\()?(.+?):(\d+):(\d+)\)?\s*$`)
+	// geckoFramePattern matches Firefox and WebKit frames: "fn@file:1:2".
+	geckoFramePattern = regexp.MustCompile(`^\s*(.*?)@(.+?):(\d+):(\d+)\s*$`)
+	// cypressSpecPattern extracts the spec path from Cypress bundle URLs.
+	cypressSpecPattern = regexp.MustCompile(`/__cypress/tests\?p=([^&]+)`)
+	// urlPrefixPattern matches the scheme and host of a URL.
+	urlPrefixPattern = regexp.MustCompile(`^[a-z]+://[^/]*`)
+)
+
+// internalPathMarkers identify frames from dependencies, Node.js internals
+// and test framework runtimes.
+var internalPathMarkers = []string{
+	"node_modules/",
+	"node:",
+	"__cypress/",
+	"cypress_runner",
+	"<anonymous>",
+}
+
+// ParseStack extracts the stack frames found in text, in order.
+// Lines that are not stack frames are ignored.
+func ParseStack(text string) []StackFrame {
+	var frames []StackFrame
+	for _, line := range strings.Split(text, "\n") {
+		m := v8FramePattern.FindStringSubmatch(line)
+		if m == nil {
+			m = geckoFramePattern.FindStringSubmatch(line)
+		}
+		if m == nil {
+			continue
+		}
+
+		lineNum, err := strconv.Atoi(m[3])
+		if err != nil {
+			continue
+		}
+		column, err := strconv.Atoi(m[4])
+		if err != nil {
+			continue
+		}
+
+		frames = append(frames, StackFrame{
+			Function: strings.TrimPrefix(m[1], "async "),
+			SourceLocation: model.SourceLocation{
+				File:   cleanFramePath(m[2]),
+				Line:   lineNum,
+				Column: column,
+			},
+		})
+	}
+	return frames
+}
+
+// cleanFramePath strips URL schemes and bundler prefixes from a frame path.
+func cleanFramePath(path string) string {
+	if m := cypressSpecPattern.FindStringSubmatch(path); m != nil {
+		return m[1]
+	}
+
+	path = strings.TrimPrefix(path, "file://")
+	if strings.HasPrefix(path, "webpack://") {
+		// webpack:///./src/a.js or webpack://app/./src/a.js
+		path = strings.TrimPrefix(path, "webpack://")
+		if i := strings.Index(path, "/"); i >= 0 {
+			path = path[i+1:]
+		}
+	} else {
+		path = urlPrefixPattern.ReplaceAllString(path, "")
+	}
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	return strings.TrimPrefix(path, "./")
+}
+
+// UserFrame returns the first frame inside the project, skipping dependencies
+// and framework internals. If projectRoot is set, absolute paths outside it
+// are skipped and paths inside it are made relative to it.
+func UserFrame(frames []StackFrame, projectRoot string) (StackFrame, bool) {
+	for _, frame := range frames {
+		if isInternalPath(frame.File) {
+			continue
+		}
+
+		if projectRoot != "" && filepath.IsAbs(frame.File) {
+			rel, err := filepath.Rel(projectRoot, frame.File)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			frame.File = filepath.ToSlash(rel)
+		}
+
+		return frame, true
+	}
+	return StackFrame{}, false
+}
+
+// isInternalPath reports whether a frame path belongs to a dependency or runtime.
+func isInternalPath(path string) bool {
+	if path == "" || path == "native" || strings.HasPrefix(path, "internal/") {
+		return true
+	}
+	for _, marker := range internalPathMarkers {
+		if strings.Contains(path, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// locate returns the source location of a failure from its message and stack trace.
+func (o Options) locate(test model.TestResult) *model.SourceLocation {
+	frames := ParseStack(test.FailureMessage)
+	if test.StackTrace != "" {
+		frames = append(frames, ParseStack(test.StackTrace)...)
+	}
+
+	frame, ok := UserFrame(frames, o.ProjectRoot)
+	if !ok {
+		return nil
+	}
+	return &frame.SourceLocation
+}
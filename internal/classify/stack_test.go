@@ -0,0 +1,116 @@
+package classify
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+func TestParseStack(t *testing.T) {
+	text := `Error: expect(received).toBe(expected)
+
+Expected: 3
+Received: 4
+    at Object.<anonymous> (/home/ci/app/src/cart.test.js:42:13)
+    at async Promise.all (index 0)
+    at processTicksAndRejections (node:internal/process/task_queues:95:5)
+    at /home/ci/app/src/helpers.js:7:3
+Context.eval@webpack:///./cypress/e2e/checkout.cy.js:15:10
+    at Context.eval (http://localhost:3000/__cypress/tests?p=cypress/e2e/login.cy.js:101:8)`
+
+	want := []StackFrame{
+		{Function: "Object.<anonymous>", SourceLocation: model.SourceLocation{File: "/home/ci/app/src/cart.test.js", Line: 42, Column: 13}},
+		{Function: "processTicksAndRejections", SourceLocation: model.SourceLocation{File: "node:internal/process/task_queues", Line: 95, Column: 5}},
+		{SourceLocation: model.SourceLocation{File: "/home/ci/app/src/helpers.js", Line: 7, Column: 3}},
+		{Function: "Context.eval", SourceLocation: model.SourceLocation{File: "cypress/e2e/checkout.cy.js", Line: 15, Column: 10}},
+		{Function: "Context.eval", SourceLocation: model.SourceLocation{File: "cypress/e2e/login.cy.js", Line: 101, Column: 8}},
+	}
+
+	got := ParseStack(text)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d frames, got %d: %+v", len(want), len(got), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("frame %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUserFrame(t *testing.T) {
+	tests := []struct {
+		name        string
+		stack       string
+		projectRoot string
+		expected    string
+	}{
+		{
+			name: "skips node_modules and node internals",
+			stack: `    at Object.toBe (/app/node_modules/expect/build/index.js:10:5)
+    at processTicksAndRejections (node:internal/process/task_queues:95:5)
+    at Object.<anonymous> (/app/src/cart.test.js:42:13)`,
+			projectRoot: "/app",
+			expected:    "src/cart.test.js:42:13",
+		},
+		{
+			name: "skips frames outside the project",
+			stack: `    at helper (/opt/shared/lib.js:1:1)
+    at Object.<anonymous> (/app/src/cart.test.js:42:13)`,
+			projectRoot: "/app",
+			expected:    "src/cart.test.js:42:13",
+		},
+		{
+			name: "skips cypress runner frames",
+			stack: `    at $Cy.retry (http://localhost:3000/__cypress/runner/cypress_runner.js:1:2)
+    at Context.eval (webpack:///./cypress/e2e/checkout.cy.js:15:10)`,
+			expected: "cypress/e2e/checkout.cy.js:15:10",
+		},
+		{
+			name:     "no project frame",
+			stack:    `    at Object.toBe (/app/node_modules/expect/build/index.js:10:5)`,
+			expected: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			frame, ok := UserFrame(ParseStack(tc.stack), tc.projectRoot)
+			got := ""
+			if ok {
+				got = frame.SourceLocation.String()
+			}
+			if got != tc.expected {
+				t.Errorf("UserFrame() = %q, want %q", got, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAggregateGroupsByLocation(t *testing.T) {
+	fail := func(runIndex, line int) model.RunResult {
+		return model.RunResult{RunIndex: runIndex, Tests: []model.TestResult{{
+			TestID:         "cart.test.js::adds item",
+			Outcome:        model.OutcomeFail,
+			FailureMessage: "expect(received).toBe(expected)",
+			StackTrace:     fmt.Sprintf("    at Object.<anonymous> (/app/src/cart.test.js:%d:5)", line),
+		}}}
+	}
+
+	agg := NewAggregator(Options{ProjectRoot: "/app"})
+	agg.Add(fail(1, 4))
+	agg.Add(fail(2, 7))
+	agg.Add(fail(3, 4))
+
+	results := agg.Snapshot()
+	clusters := results[0].FailureClusters
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters, got %d", len(clusters))
+	}
+	if clusters[0].Location == nil || clusters[0].Location.String() != "src/cart.test.js:4:5" || clusters[0].Count != 2 {
+		t.Errorf("clusters[0] = %+v at %v, want 2 failures at src/cart.test.js:4:5", clusters[0], clusters[0].Location)
+	}
+	if loc := results[0].FailureEvidence[1].Location; loc == nil || loc.String() != "src/cart.test.js:7:5" {
+		t.Errorf("evidence[1].Location = %v, want src/cart.test.js:7:5", loc)
+	}
+}
@@ -0,0 +1,153 @@
+package classify
+
+import (
+	"math"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// Thresholds for run-order analysis.
+const (
+	// minTemporalRuns is the number of timed runs needed to judge run order.
+	minTemporalRuns = 5
+	// minRunsTestRuns is the number of timed runs needed for the runs test,
+	// whose normal approximation is poor for short sequences.
+	minRunsTestRuns = 10
+	// minSettledRuns is the number of passes that must follow cold-start
+	// failures, or precede degrading ones.
+	minSettledRuns = 2
+	// zCritical is the two-sided 5% significance level of a z-score.
+	zCritical = 1.96
+)
+
+// analyzeTemporal looks for patterns in the order of a flaky test's passes
+// and failures. Runs that passed after a failed attempt count as failures.
+// It returns nil if there are too few runs or the test did not both pass
+// and fail.
+func analyzeTemporal(runs []model.RunSample) *model.TemporalAnalysis {
+	var failed []bool
+	for _, run := range runs {
+		if run.Outcome != model.OutcomeSkip {
+			failed = append(failed, run.Failed())
+		}
+	}
+
+	n := len(failed)
+	fails := 0
+	for _, f := range failed {
+		if f {
+			fails++
+		}
+	}
+	passes := n - fails
+	if n < minTemporalRuns || fails == 0 || passes == 0 {
+		return nil
+	}
+
+	analysis := &model.TemporalAnalysis{
+		TrendZ:            trendZ(failed, fails, passes),
+		RunsZ:             runsZ(failed, fails, passes),
+		LongestFailStreak: longestStreak(failed),
+	}
+
+	// A contiguous block of failures at either end of the session is the
+	// clearest sign of warm-up or accumulated state, even in short sessions.
+	switch {
+	case isPrefix(failed, fails) && passes >= minSettledRuns && fails <= passes:
+		analysis.Patterns = append(analysis.Patterns, model.PatternColdStart)
+	case isPrefix(reversed(failed), fails) && passes >= minSettledRuns && fails >= 2,
+		analysis.TrendZ >= zCritical:
+		analysis.Patterns = append(analysis.Patterns, model.PatternDegrading)
+	case n >= minRunsTestRuns && analysis.RunsZ <= -zCritical && analysis.LongestFailStreak >= 2:
+		analysis.Patterns = append(analysis.Patterns, model.PatternStreaky)
+	case n >= minRunsTestRuns && analysis.RunsZ >= zCritical:
+		analysis.Patterns = append(analysis.Patterns, model.PatternAlternating)
+	}
+
+	return analysis
+}
+
+// trendZ returns the Mann-Whitney z-score comparing the positions of failures
+// to those of passes. It is positive when failures tend to come later.
+func trendZ(failed []bool, fails, passes int) float64 {
+	// u counts (failure, pass) pairs in which the failure comes later
+	u := 0
+	passesSeen := 0
+	for _, f := range failed {
+		if f {
+			u += passesSeen
+		} else {
+			passesSeen++
+		}
+	}
+
+	n1, n2 := float64(fails), float64(passes)
+	mean := n1 * n2 / 2
+	stdDev := math.Sqrt(n1 * n2 * (n1 + n2 + 1) / 12)
+	return (float64(u) - mean) / stdDev
+}
+
+// runsZ returns the Wald-Wolfowitz runs test z-score of the sequence. It is
+// negative when there are fewer runs of equal outcomes than chance predicts.
+func runsZ(failed []bool, fails, passes int) float64 {
+	runs := 1
+	for i := 1; i < len(failed); i++ {
+		if failed[i] != failed[i-1] {
+			runs++
+		}
+	}
+
+	n1, n2 := float64(fails), float64(passes)
+	n := n1 + n2
+	mean := 2*n1*n2/n + 1
+	variance := 2 * n1 * n2 * (2*n1*n2 - n) / (n * n * (n - 1))
+	if variance <= 0 {
+		return 0
+	}
+	return (float64(runs) - mean) / math.Sqrt(variance)
+}
+
+// longestStreak returns the length of the longest run of consecutive failures.
+func longestStreak(failed []bool) int {
+	longest, current := 0, 0
+	for _, f := range failed {
+		if f {
+			current++
+			longest = max(longest, current)
+		} else {
+			current = 0
+		}
+	}
+	return longest
+}
+
+// isPrefix reports whether all failures come before all passes.
+func isPrefix(failed []bool, fails int) bool {
+	for i := range fails {
+		if !failed[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// reversed returns a reversed copy of the sequence.
+func reversed(failed []bool) []bool {
+	result := make([]bool, len(failed))
+	for i, f := range failed {
+		result[len(failed)-1-i] = f
+	}
+	return result
+}
+
+// FilterTemporal returns the tests with a detected run-order pattern,
+// preserving their order.
+func FilterTemporal(tests []model.AggregatedTest) []model.AggregatedTest {
+	result := make([]model.AggregatedTest, 0)
+	for _, t := range tests {
+		if t.Temporal != nil && len(t.Temporal.Patterns) > 0 {
+			result = append(result, t)
+		}
+	}
+	return result
+}
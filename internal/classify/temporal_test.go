@@ -0,0 +1,102 @@
+package classify
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// sequenceRuns builds runs of a single test from a pass/fail sequence such
+// as "FPPPP", where F fails, P passes and S is skipped.
+func sequenceRuns(testID, sequence string) []model.RunResult {
+	outcomes := map[rune]model.Outcome{'F': model.OutcomeFail, 'P': model.OutcomePass, 'S': model.OutcomeSkip}
+	runs := make([]model.RunResult, 0, len(sequence))
+	for i, c := range sequence {
+		test := model.TestResult{TestID: testID, Outcome: outcomes[c]}
+		if test.Outcome == model.OutcomeFail {
+			test.FailureMessage = "Error: failed"
+		}
+		runs = append(runs, model.RunResult{RunIndex: i + 1, Tests: []model.TestResult{test}})
+	}
+	return runs
+}
+
+func TestAnalyzeTemporal(t *testing.T) {
+	tests := []struct {
+		name       string
+		sequence   string
+		want       []model.TemporalPattern
+		wantStreak int
+	}{
+		{name: "first run only", sequence: "FPPPPP", want: []model.TemporalPattern{model.PatternColdStart}, wantStreak: 1},
+		{name: "warm-up failures", sequence: "FFPPPPPP", want: []model.TemporalPattern{model.PatternColdStart}, wantStreak: 2},
+		{name: "skips ignored", sequence: "SFPSPPP", want: []model.TemporalPattern{model.PatternColdStart}, wantStreak: 1},
+		{name: "fails after run N", sequence: "PPPPFFF", want: []model.TemporalPattern{model.PatternDegrading}, wantStreak: 3},
+		{name: "increasing failures", sequence: "PPPPPFPPFPFFPFFF", want: []model.TemporalPattern{model.PatternDegrading}, wantStreak: 3},
+		{name: "streaks", sequence: "PPPPFFFFPPPPPFFFFPPP", want: []model.TemporalPattern{model.PatternStreaky}, wantStreak: 4},
+		{name: "alternating", sequence: "PFPFPFPFPFPF", want: []model.TemporalPattern{model.PatternAlternating}, wantStreak: 1},
+		{name: "no pattern", sequence: "PFPPFPPPFPFP", want: nil, wantStreak: 1},
+		{name: "single final failure", sequence: "PPPPPF", want: nil, wantStreak: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Aggregate(sequenceRuns("a.test.js::t", tt.sequence))
+			temporal := results[0].Temporal
+			if temporal == nil {
+				t.Fatal("expected temporal analysis")
+			}
+			if !slices.Equal(temporal.Patterns, tt.want) {
+				t.Errorf("Patterns = %v, want %v (trendZ %.2f, runsZ %.2f)", temporal.Patterns, tt.want, temporal.TrendZ, temporal.RunsZ)
+			}
+			if temporal.LongestFailStreak != tt.wantStreak {
+				t.Errorf("LongestFailStreak = %d, want %d", temporal.LongestFailStreak, tt.wantStreak)
+			}
+		})
+	}
+}
+
+func TestAnalyzeTemporalSkipped(t *testing.T) {
+	tests := []struct {
+		name     string
+		sequence string
+	}{
+		{name: "too few runs", sequence: "FPPP"},
+		{name: "stable", sequence: "PPPPPP"},
+		{name: "always failing", sequence: "FFFFFF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Aggregate(sequenceRuns("a.test.js::t", tt.sequence))
+			if results[0].Temporal != nil {
+				t.Errorf("expected no temporal analysis, got %+v", results[0].Temporal)
+			}
+		})
+	}
+}
+
+func TestTemporalZScores(t *testing.T) {
+	results := Aggregate(sequenceRuns("a.test.js::t", "PPPPPPFFFFFF"))
+	temporal := results[0].Temporal
+	if temporal.TrendZ <= zCritical {
+		t.Errorf("TrendZ = %.2f, want > %.2f", temporal.TrendZ, zCritical)
+	}
+	if temporal.RunsZ >= -zCritical {
+		t.Errorf("RunsZ = %.2f, want < %.2f", temporal.RunsZ, -zCritical)
+	}
+}
+
+func TestFilterTemporal(t *testing.T) {
+	tests := []model.AggregatedTest{
+		{TestID: "a", Temporal: &model.TemporalAnalysis{Patterns: []model.TemporalPattern{model.PatternColdStart}}},
+		{TestID: "b", Temporal: &model.TemporalAnalysis{}},
+		{TestID: "c"},
+	}
+
+	got := FilterTemporal(tests)
+	if len(got) != 1 || got[0].TestID != "a" {
+		t.Errorf("FilterTemporal() = %v, want only a", got)
+	}
+}
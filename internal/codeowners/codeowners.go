@@ -0,0 +1,265 @@
+// Package codeowners attributes tests to owners with the repository's
+// CODEOWNERS file.
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// Locations are the paths, relative to the repository root, where GitHub
+// looks for the CODEOWNERS file, in order.
+var Locations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	rules []rule
+
+	// Warnings describe the lines that were skipped because their patterns
+	// are not supported. GitHub skips such lines too.
+	Warnings []string
+}
+
+// rule is a line of a CODEOWNERS file.
+type rule struct {
+	pattern *regexp.Regexp
+	owners  []string
+}
+
+// Find searches dir and its parents up to the repository root for a
+// CODEOWNERS file. It returns the path of the file and the repository root
+// its patterns are relative to, or empty paths if there is none.
+func Find(dir string) (path, root string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	for {
+		for _, location := range Locations {
+			candidate := filepath.Join(dir, filepath.FromSlash(location))
+			if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+				return candidate, dir, nil
+			}
+		}
+		// Files above the repository root belong to other repositories
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", "", nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads the CODEOWNERS file at path.
+func Load(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	file, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CODEOWNERS file %s: %w", path, err)
+	}
+	for i, warning := range file.Warnings {
+		file.Warnings[i] = fmt.Sprintf("%s: %s", path, warning)
+	}
+	return file, nil
+}
+
+// Parse reads a CODEOWNERS file. Lines without owners are kept, as they
+// clear the owners of the paths they match. Lines with unsupported patterns
+// are skipped and recorded in the file's warnings.
+func Parse(r io.Reader) (*File, error) {
+	file := &File{}
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		pattern, err := compilePattern(fields[0])
+		if err != nil {
+			file.Warnings = append(file.Warnings, fmt.Sprintf("line %d skipped: %v", lineNum, err))
+			continue
+		}
+		var owners []string
+		for _, field := range fields[1:] {
+			if strings.HasPrefix(field, "#") {
+				break
+			}
+			owners = append(owners, field)
+		}
+		file.rules = append(file.rules, rule{pattern: pattern, owners: owners})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read CODEOWNERS: %w", err)
+	}
+	return file, nil
+}
+
+// Owners returns the owners of path, relative to the repository root with
+// forward slashes. As on GitHub, the last matching rule wins.
+func (f *File) Owners(path string) []string {
+	for i := len(f.rules) - 1; i >= 0; i-- {
+		if f.rules[i].pattern.MatchString(path) {
+			return f.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// compilePattern converts a CODEOWNERS pattern to a regular expression with
+// GitHub's semantics, which follow gitignore except that a trailing /*
+// matches only the files directly in a directory.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(pattern, "!") || strings.ContainsAny(pattern, "[]") {
+		return nil, fmt.Errorf("pattern %q uses negation or character ranges, which CODEOWNERS does not support", pattern)
+	}
+
+	var re strings.Builder
+	trimmed := strings.TrimSuffix(pattern, "/")
+	// Patterns with a slash before the end are relative to the root; others
+	// match at any depth
+	if strings.Contains(trimmed, "/") {
+		re.WriteString(`\A`)
+	} else {
+		re.WriteString(`(?:\A|/)`)
+	}
+	body := strings.TrimPrefix(pattern, "/")
+
+	for i := 0; i < len(body); i++ {
+		switch {
+		case strings.HasPrefix(body[i:], "**/"):
+			re.WriteString(`(?:.*/)?`)
+			i += 2
+		case strings.HasPrefix(body[i:], "**"):
+			re.WriteString(`.*`)
+			i++
+		case body[i] == '*':
+			re.WriteString(`[^/]*`)
+		case body[i] == '?':
+			re.WriteString(`[^/]`)
+		default:
+			re.WriteString(regexp.QuoteMeta(body[i : i+1]))
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(pattern, "/"):
+		// A directory matches everything beneath it
+		re.WriteString(`.*\z`)
+	case strings.HasSuffix(pattern, "/*"):
+		re.WriteString(`\z`)
+	default:
+		// A file, or a directory and everything beneath it
+		re.WriteString(`(?:/.*)?\z`)
+	}
+
+	return regexp.Compile(re.String())
+}
+
+// Attach sets the owners of each test from the CODEOWNERS file of the
+// repository containing projectRoot, and returns the warnings of the file.
+// Relative test files are resolved against projectRoot. It does nothing if
+// there is no CODEOWNERS file.
+func Attach(tests []model.AggregatedTest, projectRoot string) ([]string, error) {
+	path, root, err := Find(projectRoot)
+	if err != nil || path == "" {
+		return nil, err
+	}
+	file, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range tests {
+		testPath, _, _ := strings.Cut(tests[i].TestID, "::")
+		if !filepath.IsAbs(testPath) {
+			testPath = filepath.Join(projectRoot, filepath.FromSlash(testPath))
+		}
+		rel, err := filepath.Rel(root, testPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		tests[i].Owners = file.Owners(filepath.ToSlash(rel))
+	}
+	return file.Warnings, nil
+}
+
+// Summarize returns the flaky and failing tests of each owner with the time
+// they wasted, most wasted time first. Tests without owners are grouped
+// under an empty owner. It returns nil if no test has owners.
+func Summarize(tests []model.AggregatedTest) []model.OwnerSummary {
+	owned := false
+	for _, test := range tests {
+		if len(test.Owners) > 0 {
+			owned = true
+			break
+		}
+	}
+	if !owned {
+		return nil
+	}
+
+	// Worst tests first within each owner
+	sorted := append([]model.AggregatedTest(nil), tests...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].WastedTime > sorted[j].WastedTime })
+
+	byOwner := make(map[string]*model.OwnerSummary)
+	var summaries []*model.OwnerSummary
+	for _, test := range sorted {
+		if test.Suite {
+			continue
+		}
+		owners := test.Owners
+		if len(owners) == 0 {
+			owners = []string{""}
+		}
+		for _, owner := range owners {
+			summary, ok := byOwner[owner]
+			if !ok {
+				summary = &model.OwnerSummary{Owner: owner}
+				byOwner[owner] = summary
+				summaries = append(summaries, summary)
+			}
+			summary.WastedTime += test.WastedTime
+			switch test.Classification {
+			case model.ClassificationFlaky:
+				summary.FlakyCount++
+			case model.ClassificationDeterministicFail:
+				summary.FailingCount++
+			default:
+				continue
+			}
+			summary.TestIDs = append(summary.TestIDs, test.TestID)
+		}
+	}
+
+	result := make([]model.OwnerSummary, 0, len(summaries))
+	for _, summary := range summaries {
+		if summary.FlakyCount+summary.FailingCount > 0 {
+			result = append(result, *summary)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].WastedTime != result[j].WastedTime {
+			return result[i].WastedTime > result[j].WastedTime
+		}
+		return result[i].Owner < result[j].Owner
+	})
+	return result
+}
@@ -0,0 +1,169 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+const sampleCodeOwners = `# Default owners
+*       @acme/maintainers
+
+*.js    @acme/js-owner # inline comment
+/build/logs/ @acme/infra
+docs/*  docs@example.com
+apps/   @acme/apps
+/scripts/ @acme/scripts
+**/fixtures @acme/qa
+/src/**/e2e/ @acme/e2e
+/src/generated/
+`
+
+func TestOwners(t *testing.T) {
+	file, err := Parse(strings.NewReader(sampleCodeOwners))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"README.md", []string{"@acme/maintainers"}},
+		{"src/index.js", []string{"@acme/js-owner"}},
+		{"build/logs/run.txt", []string{"@acme/infra"}},
+		{"build/logs/nested/run.txt", []string{"@acme/infra"}},
+		{"src/build/logs/run.txt", []string{"@acme/maintainers"}},
+		// A trailing /* only matches the files directly in the directory
+		{"docs/getting-started.md", []string{"docs@example.com"}},
+		{"docs/build-app/troubleshooting.md", []string{"@acme/maintainers"}},
+		// A slash before the end anchors the pattern to the root
+		{"packages/docs/guide.md", []string{"@acme/maintainers"}},
+		// Directories without a leading slash match at any depth
+		{"apps/web/page.ts", []string{"@acme/apps"}},
+		{"services/apps/page.ts", []string{"@acme/apps"}},
+		{"scripts/deploy.sh", []string{"@acme/scripts"}},
+		{"tools/scripts/deploy.sh", []string{"@acme/maintainers"}},
+		{"test/fixtures/user.json", []string{"@acme/qa"}},
+		{"fixtures/user.json", []string{"@acme/qa"}},
+		{"src/e2e/login.cy.js", []string{"@acme/e2e"}},
+		{"src/app/checkout/e2e/pay.cy.js", []string{"@acme/e2e"}},
+		// A rule without owners clears them
+		{"src/generated/api.js", nil},
+	}
+	for _, tc := range tests {
+		if got := file.Owners(tc.path); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Owners(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestParseSkipsUnsupportedPatterns(t *testing.T) {
+	content := "* @acme/all\n!docs/ @acme/docs\n*.[ch] @acme/c\n/src/ @acme/src\n"
+	file, err := Parse(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(file.Warnings) != 2 || !strings.HasPrefix(file.Warnings[0], "line 2 ") || !strings.HasPrefix(file.Warnings[1], "line 3 ") {
+		t.Errorf("Warnings = %q, want lines 2 and 3", file.Warnings)
+	}
+	// The valid rules around the skipped lines still apply
+	for path, want := range map[string][]string{"docs/a.md": {"@acme/all"}, "main.c": {"@acme/all"}, "src/a.js": {"@acme/src"}} {
+		if got := file.Owners(path); !reflect.DeepEqual(got, want) {
+			t.Errorf("Owners(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+// writeFile writes content to path, creating its directory.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dirs: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestAttach(t *testing.T) {
+	repo := t.TempDir()
+	project := filepath.Join(repo, "packages", "web")
+	writeFile(t, filepath.Join(repo, ".github", "CODEOWNERS"), "/packages/web/ @acme/web\n/packages/web/cypress/ @acme/qa\n")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0755); err != nil {
+		t.Fatalf("failed to create .git: %v", err)
+	}
+
+	tests := []model.AggregatedTest{
+		{TestID: filepath.Join(project, "src", "a.test.js") + "::A works"},
+		{TestID: "cypress/e2e/login.cy.js::logs in"},
+		{TestID: "/elsewhere/b.test.js::B"},
+	}
+	if warnings, err := Attach(tests, project); err != nil || len(warnings) != 0 {
+		t.Fatalf("Attach() = %v, %v, want no warnings", warnings, err)
+	}
+	for i, want := range [][]string{{"@acme/web"}, {"@acme/qa"}, nil} {
+		if !reflect.DeepEqual(tests[i].Owners, want) {
+			t.Errorf("tests[%d].Owners = %v, want %v", i, tests[i].Owners, want)
+		}
+	}
+
+	t.Run("unsupported pattern", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "CODEOWNERS")
+		writeFile(t, path, "!*.md @acme/docs\n* @acme/all\n")
+		tests := []model.AggregatedTest{{TestID: "a.test.js::A"}}
+		warnings, err := Attach(tests, dir)
+		if err != nil {
+			t.Fatalf("Attach failed: %v", err)
+		}
+		if len(warnings) != 1 || !strings.HasPrefix(warnings[0], path+": line 1 skipped") {
+			t.Errorf("warnings = %q, want line 1 of %s skipped", warnings, path)
+		}
+		if !reflect.DeepEqual(tests[0].Owners, []string{"@acme/all"}) {
+			t.Errorf("Owners = %v, want [@acme/all]", tests[0].Owners)
+		}
+	})
+
+	t.Run("no CODEOWNERS", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, ".git"), 0755); err != nil {
+			t.Fatalf("failed to create .git: %v", err)
+		}
+		tests := []model.AggregatedTest{{TestID: "a.test.js::A"}}
+		if _, err := Attach(tests, dir); err != nil {
+			t.Fatalf("Attach failed: %v", err)
+		}
+		if tests[0].Owners != nil {
+			t.Errorf("Owners = %v, want none", tests[0].Owners)
+		}
+	})
+}
+
+func TestSummarize(t *testing.T) {
+	if got := Summarize([]model.AggregatedTest{{TestID: "a", Classification: model.ClassificationFlaky}}); got != nil {
+		t.Errorf("Summarize() without owners = %v, want nil", got)
+	}
+
+	tests := []model.AggregatedTest{
+		{TestID: "a", Owners: []string{"@web"}, Classification: model.ClassificationFlaky, WastedTime: time.Second},
+		{TestID: "b", Owners: []string{"@web", "@api"}, Classification: model.ClassificationFlaky, WastedTime: 3 * time.Second},
+		{TestID: "c", Owners: []string{"@api"}, Classification: model.ClassificationStable},
+		{TestID: "d", Classification: model.ClassificationDeterministicFail},
+		{TestID: "e", Owners: []string{"@docs"}, Classification: model.ClassificationStable},
+		{TestID: "f", Owners: []string{"@web"}, Classification: model.ClassificationFlaky, Suite: true, WastedTime: time.Minute},
+	}
+	want := []model.OwnerSummary{
+		{Owner: "@web", FlakyCount: 2, WastedTime: 4 * time.Second, TestIDs: []string{"b", "a"}},
+		{Owner: "@api", FlakyCount: 1, WastedTime: 3 * time.Second, TestIDs: []string{"b"}},
+		{Owner: "", FailingCount: 1, TestIDs: []string{"d"}},
+	}
+	if got := Summarize(tests); !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,427 @@
+// Package config loads flakehunt project configuration files.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the configuration file names searched for, in order of preference.
+var FileNames = []string{".flakehunt.yaml", ".flakehunt.yml"}
+
+// File is the content of a configuration file.
+// Top-level settings apply to every session; a selected profile overrides them.
+type File struct {
+	Settings `yaml:",inline"`
+	Profiles map[string]Settings `yaml:"profiles,omitempty"`
+
+	// Path is the file the configuration was loaded from.
+	Path string `yaml:"-"`
+}
+
+// Settings holds the options that can be set at the top level of a config
+// file or in a profile. Nil fields are unset and fall through to the next
+// source in precedence order.
+type Settings struct {
+	Runs        *int           `yaml:"runs,omitempty"`
+	Timeout     *time.Duration `yaml:"timeout,omitempty"`
+	TestTimeout *time.Duration `yaml:"test-timeout,omitempty"`
+	Out         *string        `yaml:"out,omitempty"`
+	KeepRuns    *int           `yaml:"keep-runs,omitempty"`
+	JSON        *bool          `yaml:"json,omitempty"`
+	FailOnFlake *bool          `yaml:"fail-on-flake,omitempty"`
+	Target      *string        `yaml:"target,omitempty"`
+	TUI         *bool          `yaml:"tui,omitempty"`
+
+	// Tool forces the adapter instead of detecting it from the command.
+	Tool *string `yaml:"tool,omitempty"`
+
+	Hooks      Hooks                      `yaml:"hooks,omitempty"`
+	Signatures []Signature                `yaml:"signatures,omitempty"`
+	Adapters   map[string]AdapterSettings `yaml:"adapters,omitempty"`
+	Report     ReportSettings             `yaml:"report,omitempty"`
+	Notify     NotifySettings             `yaml:"notify,omitempty"`
+	Webhooks   []Webhook                  `yaml:"webhooks,omitempty"`
+	Issues     IssueSettings              `yaml:"issues,omitempty"`
+	Quarantine QuarantineSettings         `yaml:"quarantine,omitempty"`
+}
+
+// Hooks holds lifecycle hook commands.
+type Hooks struct {
+	BeforeSession *string `yaml:"before-session,omitempty"`
+	BeforeRun     *string `yaml:"before-run,omitempty"`
+	AfterRun      *string `yaml:"after-run,omitempty"`
+	AfterSession  *string `yaml:"after-session,omitempty"`
+}
+
+// Signature is a user-defined failure signature rule.
+// It matches if any regular expression in Patterns or any case-insensitive
+// substring in Contains is found in the failure message.
+type Signature struct {
+	Name     string   `yaml:"name"`
+	Patterns []string `yaml:"patterns,omitempty"`
+	Contains []string `yaml:"contains,omitempty"`
+	// Priority orders rules; higher wins. Built-in rules use 10-50 and
+	// unset custom priorities default to 100.
+	Priority *int `yaml:"priority,omitempty"`
+	// Tools limits the rule to the listed tools; empty applies to all.
+	Tools []string `yaml:"tools,omitempty"`
+}
+
+// AdapterSettings holds per-tool adapter overrides.
+type AdapterSettings struct {
+	// Args are appended to the test command.
+	Args []string `yaml:"args,omitempty"`
+}
+
+// ReportSettings controls report output.
+type ReportSettings struct {
+	TopN    *int     `yaml:"top-n,omitempty"`
+	Formats []string `yaml:"formats,omitempty"`
+}
+
+// NotifySettings configures the pull request comment posted after a session.
+// Unset fields are taken from the CI environment where possible.
+type NotifySettings struct {
+	// Provider is github or gitlab; no comment is posted if unset.
+	Provider *string `yaml:"provider,omitempty"`
+	// URL is the base URL of the provider's REST API.
+	URL *string `yaml:"url,omitempty"`
+	// Repo is the GitHub owner/name or the GitLab project path or ID.
+	Repo *string `yaml:"repo,omitempty"`
+	// PR is the pull request or merge request number.
+	PR *int `yaml:"pr,omitempty"`
+	// TokenEnv names the environment variable holding the API token.
+	TokenEnv *string `yaml:"token-env,omitempty"`
+	// ArtifactsURL is linked from the comment.
+	ArtifactsURL *string `yaml:"artifacts-url,omitempty"`
+}
+
+// NotifyProviders are the supported values of notify.provider.
+var NotifyProviders = []string{"github", "gitlab"}
+
+// Webhook configures a chat webhook notified after a session.
+type Webhook struct {
+	// URL is the webhook URL; URLEnv names an environment variable holding
+	// it instead, keeping the secret out of the file. Exactly one is set.
+	URL    string `yaml:"url,omitempty"`
+	URLEnv string `yaml:"url-env,omitempty"`
+	// Format is the payload format; json if empty.
+	Format string `yaml:"format,omitempty"`
+	// When is the condition for sending; new-flakes if empty.
+	When string `yaml:"when,omitempty"`
+	// Template is a Go text/template for the message text.
+	Template string `yaml:"template,omitempty"`
+}
+
+// WebhookFormats are the supported values of webhooks[].format.
+var WebhookFormats = []string{"slack", "teams", "json"}
+
+// WebhookConditions are the supported values of webhooks[].when.
+var WebhookConditions = []string{"new-flakes", "flakes", "always"}
+
+// IssueSettings configures the tickets filed for new flaky tests.
+type IssueSettings struct {
+	// Provider is github or jira; no tickets are filed if unset.
+	Provider *string `yaml:"provider,omitempty"`
+	// URL is the GitHub REST API base URL or the Jira site URL.
+	URL *string `yaml:"url,omitempty"`
+	// Repo is the GitHub owner/name.
+	Repo *string `yaml:"repo,omitempty"`
+	// Project and IssueType select the Jira project and issue type.
+	Project   *string  `yaml:"project,omitempty"`
+	IssueType *string  `yaml:"issue-type,omitempty"`
+	Labels    []string `yaml:"labels,omitempty"`
+	// TokenEnv and UserEnv name the environment variables holding the API
+	// token and, for Jira basic auth, the account email.
+	TokenEnv *string `yaml:"token-env,omitempty"`
+	UserEnv  *string `yaml:"user-env,omitempty"`
+	// Limit caps the tickets filed per session.
+	Limit *int `yaml:"limit,omitempty"`
+	// DryRun writes the tickets to disk instead of filing them.
+	DryRun *bool `yaml:"dry-run,omitempty"`
+}
+
+// IssueProviders are the supported values of issues.provider.
+var IssueProviders = []string{"github", "jira"}
+
+// QuarantineSettings configures the quarantine file maintained by
+// `flakehunt quarantine`.
+type QuarantineSettings struct {
+	// File is the quarantine file, relative to the config file.
+	File *string `yaml:"file,omitempty"`
+	// Days is how long a test stays quarantined before it must be
+	// re-evaluated.
+	Days *int `yaml:"days,omitempty"`
+	// Owner is recorded on entries added without one.
+	Owner *string `yaml:"owner,omitempty"`
+}
+
+// Find searches dir and its parents for a configuration file.
+// It returns an empty path if none is found.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	for {
+		for _, name := range FileNames {
+			path := filepath.Join(dir, name)
+			info, err := os.Stat(path)
+			if err == nil && !info.IsDir() {
+				return path, nil
+			}
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return "", fmt.Errorf("failed to check %s: %w", path, err)
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads and parses the configuration file at path.
+// Unknown keys are rejected so that typos do not go unnoticed.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	file := &File{Path: path}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if err := file.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return file, nil
+}
+
+// Resolve returns the top-level settings overridden by the named profile.
+// An empty profile name returns the top-level settings unchanged.
+func (f *File) Resolve(profile string) (Settings, error) {
+	if profile == "" {
+		return f.Settings, nil
+	}
+
+	p, ok := f.Profiles[profile]
+	if !ok {
+		return Settings{}, fmt.Errorf("profile %q not found in %s (available: %s)", profile, f.Path, f.profileNames())
+	}
+
+	return f.Settings.Merge(p), nil
+}
+
+// Merge returns s overridden by the set fields of over.
+// Signatures are combined; all other lists replace the lower-precedence value.
+func (s Settings) Merge(over Settings) Settings {
+	merged := s
+
+	mergePtr(&merged.Runs, over.Runs)
+	mergePtr(&merged.Timeout, over.Timeout)
+	mergePtr(&merged.TestTimeout, over.TestTimeout)
+	mergePtr(&merged.Out, over.Out)
+	mergePtr(&merged.KeepRuns, over.KeepRuns)
+	mergePtr(&merged.JSON, over.JSON)
+	mergePtr(&merged.FailOnFlake, over.FailOnFlake)
+	mergePtr(&merged.Target, over.Target)
+	mergePtr(&merged.TUI, over.TUI)
+	mergePtr(&merged.Tool, over.Tool)
+
+	mergePtr(&merged.Hooks.BeforeSession, over.Hooks.BeforeSession)
+	mergePtr(&merged.Hooks.BeforeRun, over.Hooks.BeforeRun)
+	mergePtr(&merged.Hooks.AfterRun, over.Hooks.AfterRun)
+	mergePtr(&merged.Hooks.AfterSession, over.Hooks.AfterSession)
+
+	if len(over.Signatures) > 0 {
+		merged.Signatures = append(append([]Signature{}, s.Signatures...), over.Signatures...)
+	}
+
+	if len(over.Adapters) > 0 {
+		merged.Adapters = make(map[string]AdapterSettings, len(s.Adapters)+len(over.Adapters))
+		for tool, a := range s.Adapters {
+			merged.Adapters[tool] = a
+		}
+		for tool, a := range over.Adapters {
+			merged.Adapters[tool] = a
+		}
+	}
+
+	mergePtr(&merged.Report.TopN, over.Report.TopN)
+	if len(over.Report.Formats) > 0 {
+		merged.Report.Formats = over.Report.Formats
+	}
+
+	mergePtr(&merged.Notify.Provider, over.Notify.Provider)
+	mergePtr(&merged.Notify.URL, over.Notify.URL)
+	mergePtr(&merged.Notify.Repo, over.Notify.Repo)
+	mergePtr(&merged.Notify.PR, over.Notify.PR)
+	mergePtr(&merged.Notify.TokenEnv, over.Notify.TokenEnv)
+	mergePtr(&merged.Notify.ArtifactsURL, over.Notify.ArtifactsURL)
+
+	if len(over.Webhooks) > 0 {
+		merged.Webhooks = over.Webhooks
+	}
+
+	mergePtr(&merged.Issues.Provider, over.Issues.Provider)
+	mergePtr(&merged.Issues.URL, over.Issues.URL)
+	mergePtr(&merged.Issues.Repo, over.Issues.Repo)
+	mergePtr(&merged.Issues.Project, over.Issues.Project)
+	mergePtr(&merged.Issues.IssueType, over.Issues.IssueType)
+	if len(over.Issues.Labels) > 0 {
+		merged.Issues.Labels = over.Issues.Labels
+	}
+	mergePtr(&merged.Issues.TokenEnv, over.Issues.TokenEnv)
+	mergePtr(&merged.Issues.UserEnv, over.Issues.UserEnv)
+	mergePtr(&merged.Issues.Limit, over.Issues.Limit)
+	mergePtr(&merged.Issues.DryRun, over.Issues.DryRun)
+
+	mergePtr(&merged.Quarantine.File, over.Quarantine.File)
+	mergePtr(&merged.Quarantine.Days, over.Quarantine.Days)
+	mergePtr(&merged.Quarantine.Owner, over.Quarantine.Owner)
+
+	return merged
+}
+
+// Marshal returns v encoded as YAML with two-space indentation.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalAnnotated returns s encoded as YAML, with each key annotated by its
+// entry in sources. Nested keys are looked up by their dotted path, such as
+// "hooks.before-run".
+func MarshalAnnotated(s Settings, sources map[string]string) ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(s); err != nil {
+		return nil, err
+	}
+	annotate(&doc, "", sources)
+	return Marshal(&doc)
+}
+
+// annotate sets the line comment of each mapping key found in sources.
+func annotate(node *yaml.Node, prefix string, sources map[string]string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		path := prefix + key.Value
+		if source, ok := sources[path]; ok {
+			key.LineComment = source
+		}
+		annotate(value, path+".", sources)
+	}
+}
+
+// validate checks values that YAML decoding cannot.
+func (f *File) validate() error {
+	check := func(where string, s Settings) error {
+		if s.Runs != nil && *s.Runs <= 0 {
+			return fmt.Errorf("%sruns must be a positive integer, got %d", where, *s.Runs)
+		}
+		if s.KeepRuns != nil && *s.KeepRuns < 0 {
+			return fmt.Errorf("%skeep-runs must not be negative, got %d", where, *s.KeepRuns)
+		}
+		if p := s.Notify.Provider; p != nil && !slices.Contains(NotifyProviders, *p) {
+			return fmt.Errorf("%snotify.provider must be one of %s, got %q", where, strings.Join(NotifyProviders, ", "), *p)
+		}
+		if s.Notify.PR != nil && *s.Notify.PR <= 0 {
+			return fmt.Errorf("%snotify.pr must be a positive integer, got %d", where, *s.Notify.PR)
+		}
+		if p := s.Issues.Provider; p != nil && !slices.Contains(IssueProviders, *p) {
+			return fmt.Errorf("%sissues.provider must be one of %s, got %q", where, strings.Join(IssueProviders, ", "), *p)
+		}
+		if s.Issues.Limit != nil && *s.Issues.Limit < 0 {
+			return fmt.Errorf("%sissues.limit must not be negative, got %d", where, *s.Issues.Limit)
+		}
+		if s.Quarantine.Days != nil && *s.Quarantine.Days <= 0 {
+			return fmt.Errorf("%squarantine.days must be a positive integer, got %d", where, *s.Quarantine.Days)
+		}
+		for i, hook := range s.Webhooks {
+			if (hook.URL == "") == (hook.URLEnv == "") {
+				return fmt.Errorf("%swebhooks[%d] must set exactly one of url and url-env", where, i)
+			}
+			if hook.Format != "" && !slices.Contains(WebhookFormats, hook.Format) {
+				return fmt.Errorf("%swebhooks[%d].format must be one of %s, got %q", where, i, strings.Join(WebhookFormats, ", "), hook.Format)
+			}
+			if hook.When != "" && !slices.Contains(WebhookConditions, hook.When) {
+				return fmt.Errorf("%swebhooks[%d].when must be one of %s, got %q", where, i, strings.Join(WebhookConditions, ", "), hook.When)
+			}
+		}
+		for i, sig := range s.Signatures {
+			if sig.Name == "" {
+				return fmt.Errorf("%ssignatures[%d].name is required", where, i)
+			}
+			if len(sig.Patterns) == 0 && len(sig.Contains) == 0 {
+				return fmt.Errorf("%ssignatures[%d] (%s) must define at least one pattern or contains entry", where, i, sig.Name)
+			}
+		}
+		return nil
+	}
+
+	if err := check("", f.Settings); err != nil {
+		return err
+	}
+	for _, name := range f.sortedProfileNames() {
+		if err := check(fmt.Sprintf("profiles.%s.", name), f.Profiles[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// profileNames returns the defined profile names for error messages.
+func (f *File) profileNames() string {
+	names := f.sortedProfileNames()
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+func (f *File) sortedProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mergePtr replaces *dst with src when src is set.
+func mergePtr[T any](dst **T, src *T) {
+	if src != nil {
+		*dst = src
+	}
+}
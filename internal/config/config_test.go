@@ -0,0 +1,294 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleConfig = `
+runs: 20
+timeout: 5m
+hooks:
+  before-run: npm run db:reset
+signatures:
+  - name: PRISMA
+    patterns: ["PrismaClient\\w+Error"]
+adapters:
+  jest:
+    args: ["--ci"]
+report:
+  top-n: 10
+profiles:
+  quick:
+    runs: 5
+    signatures:
+      - name: REDIS
+        contains: ["Redis connection lost"]
+        priority: 60
+        tools: [jest]
+  ci-like:
+    runs: 50
+    fail-on-flake: false
+    adapters:
+      jest:
+        args: ["--maxWorkers=2"]
+`
+
+// writeConfig writes content to a config file in dir and returns its path.
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, FileNames[0])
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "packages", "web")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("failed to create dirs: %v", err)
+	}
+
+	t.Run("no config file", func(t *testing.T) {
+		path, err := Find(nested)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		// A config file above the temp dir would be found; only assert it is not inside root
+		if strings.HasPrefix(path, root) {
+			t.Errorf("Find() = %q, want no config inside %s", path, root)
+		}
+	})
+
+	t.Run("found in ancestor", func(t *testing.T) {
+		want := writeConfig(t, root, "runs: 3\n")
+		got, err := Find(nested)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("Find() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("nearest wins", func(t *testing.T) {
+		want := writeConfig(t, nested, "runs: 4\n")
+		got, err := Find(nested)
+		if err != nil {
+			t.Fatalf("Find failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("Find() = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), sampleConfig)
+
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if file.Runs == nil || *file.Runs != 20 {
+		t.Errorf("Runs = %v, want 20", file.Runs)
+	}
+	if file.Timeout == nil || *file.Timeout != 5*time.Minute {
+		t.Errorf("Timeout = %v, want 5m", file.Timeout)
+	}
+	if file.Hooks.BeforeRun == nil || *file.Hooks.BeforeRun != "npm run db:reset" {
+		t.Errorf("Hooks.BeforeRun = %v, want npm run db:reset", file.Hooks.BeforeRun)
+	}
+	if len(file.Profiles) != 2 {
+		t.Errorf("expected 2 profiles, got %d", len(file.Profiles))
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unknown key",
+			content: "runz: 3\n",
+			wantErr: "field runz not found",
+		},
+		{
+			name:    "invalid duration",
+			content: "timeout: soon\n",
+			wantErr: "invalid config file",
+		},
+		{
+			name:    "non-positive runs in profile",
+			content: "profiles:\n  broken:\n    runs: 0\n",
+			wantErr: "profiles.broken.runs must be a positive integer",
+		},
+		{
+			name:    "signature without patterns",
+			content: "signatures:\n  - name: EMPTY\n",
+			wantErr: "must define at least one pattern or contains entry",
+		},
+		{
+			name:    "unknown notify provider",
+			content: "notify:\n  provider: bitbucket\n",
+			wantErr: "notify.provider must be one of github, gitlab",
+		},
+		{
+			name:    "non-positive pull request",
+			content: "notify:\n  pr: 0\n",
+			wantErr: "notify.pr must be a positive integer",
+		},
+		{
+			name:    "webhook without url",
+			content: "webhooks:\n  - format: slack\n",
+			wantErr: "webhooks[0] must set exactly one of url and url-env",
+		},
+		{
+			name:    "unknown webhook format",
+			content: "profiles:\n  nightly:\n    webhooks:\n      - url-env: HOOK_URL\n        format: discord\n",
+			wantErr: "profiles.nightly.webhooks[0].format must be one of slack, teams, json",
+		},
+		{
+			name:    "unknown webhook condition",
+			content: "webhooks:\n  - url: https://example.com/hook\n    when: sometimes\n",
+			wantErr: "webhooks[0].when must be one of new-flakes, flakes, always",
+		},
+		{
+			name:    "unknown issues provider",
+			content: "issues:\n  provider: linear\n",
+			wantErr: "issues.provider must be one of github, jira",
+		},
+		{
+			name:    "negative issues limit",
+			content: "issues:\n  limit: -1\n",
+			wantErr: "issues.limit must not be negative",
+		},
+		{
+			name:    "non-positive quarantine days",
+			content: "profiles:\n  ci:\n    quarantine:\n      days: 0\n",
+			wantErr: "profiles.ci.quarantine.days must be a positive integer",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), tc.content)
+			_, err := Load(path)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error %q does not contain %q", err.Error(), tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadEmptyFile(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "")
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if file.Runs != nil {
+		t.Errorf("Runs = %v, want unset", *file.Runs)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), sampleConfig)
+	file, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	t.Run("no profile", func(t *testing.T) {
+		s, err := file.Resolve("")
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if *s.Runs != 20 {
+			t.Errorf("Runs = %d, want 20", *s.Runs)
+		}
+	})
+
+	t.Run("profile overrides and inherits", func(t *testing.T) {
+		s, err := file.Resolve("ci-like")
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if *s.Runs != 50 {
+			t.Errorf("Runs = %d, want 50", *s.Runs)
+		}
+		if s.FailOnFlake == nil || *s.FailOnFlake {
+			t.Errorf("FailOnFlake = %v, want false", s.FailOnFlake)
+		}
+		if *s.Timeout != 5*time.Minute {
+			t.Errorf("Timeout = %v, want inherited 5m", *s.Timeout)
+		}
+		if got := s.Adapters["jest"].Args; len(got) != 1 || got[0] != "--maxWorkers=2" {
+			t.Errorf("jest args = %v, want [--maxWorkers=2]", got)
+		}
+		if *s.Report.TopN != 10 {
+			t.Errorf("Report.TopN = %d, want inherited 10", *s.Report.TopN)
+		}
+	})
+
+	t.Run("profile signatures are added", func(t *testing.T) {
+		s, err := file.Resolve("quick")
+		if err != nil {
+			t.Fatalf("Resolve failed: %v", err)
+		}
+		if len(s.Signatures) != 2 {
+			t.Fatalf("expected 2 signatures, got %d", len(s.Signatures))
+		}
+		if s.Signatures[0].Name != "PRISMA" || s.Signatures[1].Name != "REDIS" {
+			t.Errorf("signatures = %v, want PRISMA then REDIS", s.Signatures)
+		}
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		_, err := file.Resolve("nightly")
+		if err == nil {
+			t.Fatal("expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), "available: ci-like, quick") {
+			t.Errorf("error should list available profiles: %v", err)
+		}
+	})
+}
+
+func TestMarshalAnnotated(t *testing.T) {
+	runs := 5
+	hook := "npm run db:reset"
+	s := Settings{
+		Runs:  &runs,
+		Hooks: Hooks{BeforeRun: &hook},
+	}
+
+	data, err := MarshalAnnotated(s, map[string]string{
+		"runs":             "profile quick",
+		"hooks.before-run": "config file",
+	})
+	if err != nil {
+		t.Fatalf("MarshalAnnotated failed: %v", err)
+	}
+
+	got := string(data)
+	for _, want := range []string{
+		"runs: 5 # profile quick",
+		"  before-run: npm run db:reset # config file",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,470 @@
+// Package dashboard implements the live terminal progress view for flakehunt.
+package dashboard
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/boyarskiy/flakehunt/internal/classify"
+	"github.com/boyarskiy/flakehunt/internal/model"
+	"github.com/boyarskiy/flakehunt/internal/runner"
+)
+
+const (
+	// refreshInterval is how often the elapsed time and ETA are redrawn
+	// while a run is in progress.
+	refreshInterval = time.Second
+
+	// maxSparkline is the number of most recent runs shown in the sparkline.
+	maxSparkline = 60
+
+	// maxFlakyRows is the number of flaky tests listed in the dashboard.
+	maxFlakyRows = 8
+
+	// maxTestIDWidth is the width at which test IDs are truncated.
+	maxTestIDWidth = 90
+)
+
+// ANSI escape sequences used for rendering.
+const (
+	ansiReset = "\033[0m"
+	ansiBold  = "\033[1m"
+	ansiDim   = "\033[2m"
+	ansiRed   = "\033[31m"
+	ansiGreen = "\033[32m"
+	ansiYel   = "\033[33m"
+	ansiClear = "\033[J"
+)
+
+// sparkLevels are the bar heights used for the per-run sparkline.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// Dashboard renders a live, redrawn view of session progress.
+// It implements runner.Observer and is safe for concurrent use.
+type Dashboard struct {
+	mu sync.Mutex
+
+	w     io.Writer
+	tool  model.Tool
+	total int
+
+	start      time.Time
+	runStart   time.Time
+	current    int
+	runs       []model.RunResult
+	tests      []model.AggregatedTest
+	signatures map[string]int
+	flakySince map[string]int // run index at which each test became flaky
+
+	stopped bool
+	lines   int // lines drawn by the previous frame
+
+	// width returns the terminal width in columns, or 0 if unknown.
+	width func() int
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a dashboard for a session of total runs with the given tool.
+func New(w io.Writer, tool model.Tool, total int) *Dashboard {
+	return &Dashboard{
+		w:          w,
+		tool:       tool,
+		total:      total,
+		signatures: map[string]int{},
+		flakySince: map[string]int{},
+		done:       make(chan struct{}),
+		width:      func() int { return writerWidth(w) },
+	}
+}
+
+// writerWidth returns the width of the terminal w writes to, falling back
+// to $COLUMNS, or 0 if unknown.
+func writerWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		if width := terminalWidth(f); width > 0 {
+			return width
+		}
+	}
+	width, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || width < 0 {
+		return 0
+	}
+	return width
+}
+
+// IsTerminal reports whether f is connected to a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Start draws the initial frame and begins periodic redraws.
+func (d *Dashboard) Start() {
+	d.mu.Lock()
+	d.start = time.Now()
+	d.redraw()
+	d.mu.Unlock()
+
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.done:
+				return
+			case <-ticker.C:
+				d.mu.Lock()
+				d.redraw()
+				d.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stop halts periodic redraws and draws the final frame.
+func (d *Dashboard) Stop() {
+	close(d.done)
+	d.wg.Wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.current = 0
+	d.stopped = true
+	d.redraw()
+}
+
+// RunStarted records that a run has begun.
+func (d *Dashboard) RunStarted(runIndex, totalRuns int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.current = runIndex
+	d.total = totalRuns
+	d.runStart = time.Now()
+	d.redraw()
+}
+
+// RunFinished records a parsed run and the updated flake statistics.
+func (d *Dashboard) RunFinished(result *model.RunResult, tests []model.AggregatedTest) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if result != nil {
+		d.runs = append(d.runs, *result)
+	}
+	d.tests = tests
+	d.signatures = classify.SignatureSummary(tests)
+	d.redraw()
+}
+
+// TestBecameFlaky records the run at which a test was first seen to be flaky.
+func (d *Dashboard) TestBecameFlaky(test model.AggregatedTest) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.flakySince[test.TestID] = len(d.runs)
+}
+
+// SessionEnded is a no-op; the final frame is drawn by Stop.
+func (d *Dashboard) SessionEnded(result *runner.Result) {}
+
+// redraw replaces the previous frame with the current state.
+// Callers must hold d.mu.
+func (d *Dashboard) redraw() {
+	// Lines that wrap would take more rows than the cursor moves back up
+	// on the next redraw, so they are cut to the terminal width
+	frame := fitWidth(d.render(time.Now()), d.width())
+
+	var sb strings.Builder
+	if d.lines > 0 {
+		// Move to the start of the previous frame and clear it
+		sb.WriteString(fmt.Sprintf("\033[%dF", d.lines))
+	}
+	sb.WriteString(ansiClear)
+	sb.WriteString(frame)
+
+	d.lines = strings.Count(frame, "\n")
+	fmt.Fprint(d.w, sb.String())
+}
+
+// render builds a single frame.
+func (d *Dashboard) render(now time.Time) string {
+	var sb strings.Builder
+	completed := len(d.runs)
+
+	// Progress line
+	status := "starting"
+	switch {
+	case d.stopped:
+		status = "done"
+	case d.current > 0:
+		status = fmt.Sprintf("run %d/%d", d.current, d.total)
+	}
+	elapsed := now.Sub(d.start)
+	sb.WriteString(fmt.Sprintf("%sflakehunt%s  %s  %s  %s  elapsed %s",
+		ansiBold, ansiReset, d.tool, status, progressBar(completed, d.total, 20), formatElapsed(elapsed)))
+	if eta, ok := d.eta(now); ok {
+		sb.WriteString(fmt.Sprintf("  ETA %s", formatElapsed(eta)))
+	}
+	sb.WriteString("\n")
+
+	// Sparkline and run totals
+	clean, failing, errored := 0, 0, 0
+	for _, r := range d.runs {
+		switch {
+		case r.Error != "":
+			errored++
+		case runFailCount(r) > 0:
+			failing++
+		default:
+			clean++
+		}
+	}
+	sb.WriteString(fmt.Sprintf("Runs   %s  %s%d passed%s  %s%d failed%s",
+		sparkline(d.runs), ansiGreen, clean, ansiReset, ansiRed, failing, ansiReset))
+	if errored > 0 {
+		sb.WriteString(fmt.Sprintf("  %s%d errored%s", ansiYel, errored, ansiReset))
+	}
+	sb.WriteString("\n")
+
+	// Flaky tests
+	flaky := classify.TopFlakes(d.tests, maxFlakyRows)
+	flakyTotal := len(classify.FilterByClassification(classify.FilterTests(d.tests), model.ClassificationFlaky))
+	sb.WriteString(fmt.Sprintf("Flaky  %d\n", flakyTotal))
+	for _, t := range flaky {
+		since := ""
+		if run, ok := d.flakySince[t.TestID]; ok {
+			since = fmt.Sprintf("  %s(since run %d)%s", ansiDim, run, ansiReset)
+		}
+		sb.WriteString(fmt.Sprintf("  %s%5.1f%%%s  %3d/%-3d  %s%s\n",
+			ansiRed, t.FlakeRate*100, ansiReset, t.FailCount, t.TotalRuns, truncate(t.TestID, maxTestIDWidth), since))
+	}
+	if flakyTotal > len(flaky) {
+		sb.WriteString(fmt.Sprintf("  %s... and %d more%s\n", ansiDim, flakyTotal-len(flaky), ansiReset))
+	}
+
+	// Signatures
+	if len(d.signatures) > 0 {
+		sb.WriteString("Signatures ")
+		for _, sig := range sortedSignatures(d.signatures) {
+			sb.WriteString(fmt.Sprintf(" %s %d", sig.name, sig.count))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// eta estimates the remaining session time from the average completed run.
+func (d *Dashboard) eta(now time.Time) (time.Duration, bool) {
+	completed := len(d.runs)
+	if completed == 0 || completed >= d.total {
+		return 0, false
+	}
+
+	// Average duration of completed runs, measured up to the current run's start
+	measured := now.Sub(d.start)
+	if d.current > completed && !d.runStart.IsZero() {
+		measured = d.runStart.Sub(d.start)
+	}
+	avg := measured / time.Duration(completed)
+
+	remaining := avg * time.Duration(d.total-completed)
+	if d.current > completed {
+		// Credit time already spent on the in-progress run
+		inProgress := now.Sub(d.runStart)
+		if inProgress < avg {
+			remaining -= inProgress
+		} else {
+			remaining -= avg
+		}
+	}
+	return remaining, true
+}
+
+// runFailCount returns the number of failed tests in a run.
+func runFailCount(r model.RunResult) int {
+	count := 0
+	for _, t := range r.Tests {
+		if t.Outcome == model.OutcomeFail {
+			count++
+		}
+	}
+	return count
+}
+
+// sparkline renders one bar per run; bar height is the share of failed tests.
+func sparkline(runs []model.RunResult) string {
+	if len(runs) > maxSparkline {
+		runs = runs[len(runs)-maxSparkline:]
+	}
+
+	var sb strings.Builder
+	for _, r := range runs {
+		if r.Error != "" {
+			sb.WriteString(ansiYel + "!" + ansiReset)
+			continue
+		}
+
+		failed := runFailCount(r)
+		if failed == 0 {
+			sb.WriteString(ansiGreen + string(sparkLevels[0]) + ansiReset)
+			continue
+		}
+
+		executed := 0
+		for _, t := range r.Tests {
+			if t.Outcome != model.OutcomeSkip {
+				executed++
+			}
+		}
+		// Round up so that a single failure is always visible
+		level := (failed*(len(sparkLevels)-1) + executed - 1) / executed
+		sb.WriteString(ansiRed + string(sparkLevels[level]) + ansiReset)
+	}
+	return sb.String()
+}
+
+// progressBar renders a fixed-width completion bar.
+func progressBar(done, total, width int) string {
+	if total <= 0 {
+		return ""
+	}
+	filled := done * width / total
+	if filled > width {
+		filled = width
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
+// formatElapsed formats a duration with second precision.
+func formatElapsed(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d < 0 {
+		d = 0
+	}
+	h := d / time.Hour
+	m := (d % time.Hour) / time.Minute
+	s := (d % time.Minute) / time.Second
+	if h > 0 {
+		return fmt.Sprintf("%dh%02dm%02ds", h, m, s)
+	}
+	if m > 0 {
+		return fmt.Sprintf("%dm%02ds", m, s)
+	}
+	return fmt.Sprintf("%ds", s)
+}
+
+// truncate shortens s to maxLen characters.
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
+
+// fitWidth truncates each line of frame to fewer than width columns, not
+// counting ANSI escape sequences, so that no line wraps. A width of 0
+// leaves frame unchanged.
+func fitWidth(frame string, width int) string {
+	if width <= 0 {
+		return frame
+	}
+	// Writing the last column makes some terminals wrap, so one is left free
+	maxCols := max(width-1, len("..."))
+
+	lines := strings.SplitAfter(frame, "\n")
+	for i, line := range lines {
+		text, newline := strings.CutSuffix(line, "\n")
+		if visibleWidth(text) <= maxCols {
+			continue
+		}
+		cut := cutVisible(text, maxCols-len("..."))
+		lines[i] = cut + ansiReset + "..."
+		if newline {
+			lines[i] += "\n"
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// visibleWidth returns the number of characters of s outside ANSI escape
+// sequences.
+func visibleWidth(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if end, ok := escapeEnd(s, i); ok {
+			i = end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
+
+// cutVisible returns the prefix of s holding n visible characters, along
+// with the escape sequences among them.
+func cutVisible(s string, n int) string {
+	for i := 0; i < len(s); {
+		if end, ok := escapeEnd(s, i); ok {
+			i = end
+			continue
+		}
+		if n == 0 {
+			return s[:i]
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n--
+	}
+	return s
+}
+
+// escapeEnd returns the end of the ANSI CSI escape sequence starting at
+// s[i], if there is one.
+func escapeEnd(s string, i int) (int, bool) {
+	if !strings.HasPrefix(s[i:], "\033[") {
+		return 0, false
+	}
+	for j := i + 2; j < len(s); j++ {
+		// A final byte in 0x40-0x7E ends the sequence
+		if s[j] >= 0x40 && s[j] <= 0x7e {
+			return j + 1, true
+		}
+	}
+	return len(s), true
+}
+
+// signatureCount holds a signature name and its count.
+type signatureCount struct {
+	name  string
+	count int
+}
+
+// sortedSignatures returns signatures sorted by count (descending), then name (ascending).
+func sortedSignatures(summary map[string]int) []signatureCount {
+	result := make([]signatureCount, 0, len(summary))
+	for name, count := range summary {
+		result = append(result, signatureCount{name: name, count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].count != result[j].count {
+			return result[i].count > result[j].count
+		}
+		return result[i].name < result[j].name
+	})
+	return result
+}
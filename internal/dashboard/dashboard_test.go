@@ -0,0 +1,173 @@
+package dashboard
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/classify"
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+func TestSparkline(t *testing.T) {
+	runs := []model.RunResult{
+		{RunIndex: 1, Tests: []model.TestResult{{TestID: "a", Outcome: model.OutcomePass}, {TestID: "b", Outcome: model.OutcomePass}}},
+		{RunIndex: 2, Tests: []model.TestResult{{TestID: "a", Outcome: model.OutcomeFail}, {TestID: "b", Outcome: model.OutcomePass}}},
+		{RunIndex: 3, Tests: []model.TestResult{{TestID: "a", Outcome: model.OutcomeFail}, {TestID: "b", Outcome: model.OutcomeFail}}},
+		{RunIndex: 4, Error: "expected artifact not found"},
+	}
+
+	got := stripANSI(sparkline(runs))
+	want := "▁▅█!"
+	if got != want {
+		t.Errorf("sparkline() = %q, want %q", got, want)
+	}
+}
+
+func TestSparklineKeepsMostRecentRuns(t *testing.T) {
+	runs := make([]model.RunResult, maxSparkline+5)
+	for i := range runs {
+		runs[i] = model.RunResult{RunIndex: i + 1}
+	}
+
+	got := []rune(stripANSI(sparkline(runs)))
+	if len(got) != maxSparkline {
+		t.Errorf("sparkline width = %d, want %d", len(got), maxSparkline)
+	}
+}
+
+func TestRenderShowsRunningFlakeRates(t *testing.T) {
+	var buf bytes.Buffer
+	d := New(&buf, model.ToolJest, 10)
+	d.start = time.Now()
+
+	agg := classify.NewAggregator(classify.Options{})
+	runs := []model.RunResult{
+		{RunIndex: 1, Tests: []model.TestResult{
+			{TestID: "a.test.js::flaky", Outcome: model.OutcomePass, Duration: 100 * time.Millisecond},
+		}},
+		{RunIndex: 2, Tests: []model.TestResult{
+			{TestID: "a.test.js::flaky", Outcome: model.OutcomeFail, Duration: 100 * time.Millisecond, FailureMessage: "Timeout exceeded"},
+		}},
+	}
+	for _, run := range runs {
+		d.RunStarted(run.RunIndex, 10)
+		becameFlaky := agg.Add(run)
+		d.RunFinished(&run, agg.Snapshot())
+		for _, test := range becameFlaky {
+			d.TestBecameFlaky(test)
+		}
+	}
+
+	frame := stripANSI(d.render(time.Now()))
+
+	for _, want := range []string{
+		"run 2/10",
+		"ETA",
+		"Flaky  1",
+		"50.0%",
+		"a.test.js::flaky",
+		"TIMEOUT 1",
+		"since run 2",
+	} {
+		if !strings.Contains(frame, want) {
+			t.Errorf("frame missing %q:\n%s", want, frame)
+		}
+	}
+}
+
+func TestRedrawClearsPreviousFrame(t *testing.T) {
+	var buf bytes.Buffer
+	d := New(&buf, model.ToolJest, 3)
+	d.start = time.Now()
+
+	d.redraw()
+	lines := d.lines
+	buf.Reset()
+	d.redraw()
+
+	want := "\033[" + string(rune('0'+lines)) + "F"
+	if !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("redraw should start by moving up %d lines, got %q", lines, buf.String())
+	}
+}
+
+func TestFitWidth(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+		width int
+		want  string
+	}{
+		{"unknown width", "a long line\n", 0, "a long line\n"},
+		{"line fits", "short\n", 20, "short\n"},
+		{"line is cut", "0123456789\n", 8, "0123" + ansiReset + "...\n"},
+		{"escape sequences take no columns", ansiRed + "50.0%" + ansiReset + " a.test.js\n", 16,
+			ansiRed + "50.0%" + ansiReset + " a.test.js\n"},
+		{"escape sequences are kept", ansiRed + "50.0%" + ansiReset + " a.test.js::works\n", 12,
+			ansiRed + "50.0%" + ansiReset + " a." + ansiReset + "...\n"},
+		{"multibyte characters", "Runs   ▁▁█▁▁▁▁▁\n", 12, "Runs   ▁" + ansiReset + "...\n"},
+		{"last line without newline", "0123456789", 8, "0123" + ansiReset + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitWidth(tt.frame, tt.width); got != tt.want {
+				t.Errorf("fitWidth() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedrawFitsNarrowTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	d := New(&buf, model.ToolJest, 3)
+	d.width = func() int { return 30 }
+	d.start = time.Now()
+	id := "src/components/checkout/" + strings.Repeat("nested/", 8) + "cart.test.tsx::cart adds an item"
+	d.tests = []model.AggregatedTest{{TestID: id, Classification: model.ClassificationFlaky, PassCount: 1, FailCount: 1, TotalRuns: 2, FlakeRate: 0.5}}
+
+	d.redraw()
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if width := visibleWidth(line); width >= 30 {
+			t.Errorf("line %q is %d columns wide, want less than 30", stripANSI(line), width)
+		}
+	}
+
+	// Each line takes one row, so the next redraw moves up over the whole frame
+	lines := d.lines
+	buf.Reset()
+	d.redraw()
+	if want := fmt.Sprintf("\033[%dF", lines); !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("redraw should start by moving up %d lines, got %q", lines, buf.String())
+	}
+}
+
+func TestFormatElapsed(t *testing.T) {
+	tests := []struct {
+		input    time.Duration
+		expected string
+	}{
+		{0, "0s"},
+		{1400 * time.Millisecond, "1s"},
+		{75 * time.Second, "1m15s"},
+		{time.Hour + 2*time.Minute + 3*time.Second, "1h02m03s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			if got := formatElapsed(tt.input); got != tt.expected {
+				t.Errorf("formatElapsed(%v) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// stripANSI removes the color escape sequences used by the dashboard.
+func stripANSI(s string) string {
+	for _, seq := range []string{ansiReset, ansiBold, ansiDim, ansiRed, ansiGreen, ansiYel} {
+		s = strings.ReplaceAll(s, seq, "")
+	}
+	return s
+}
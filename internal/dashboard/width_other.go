@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package dashboard
+
+import "os"
+
+// terminalWidth returns 0: the terminal size is not queried on this platform.
+func terminalWidth(f *os.File) int {
+	return 0
+}
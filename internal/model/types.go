@@ -1,7 +1,12 @@
 // Package model defines shared data types for flakehunt.
 package model
 
-import "time"
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
 
 // Outcome represents the result of a single test execution.
 type Outcome string
@@ -21,9 +26,11 @@ const (
 	ClassificationDeterministicFail Classification = "deterministic_fail"
 )
 
-// FailureSignature represents a categorized failure type.
+// FailureSignature names a category of failure. The built-in signatures are
+// listed below; user-defined signature rules may introduce any other name.
 type FailureSignature string
 
+// Built-in failure signatures.
 const (
 	SignatureTimeout   FailureSignature = "TIMEOUT"
 	SignatureSelector  FailureSignature = "SELECTOR"
@@ -39,13 +46,74 @@ type TestResult struct {
 	Outcome        Outcome       `json:"outcome"`
 	Duration       time.Duration `json:"duration"`
 	FailureMessage string        `json:"failureMessage,omitempty"`
+	// StackTrace holds the stack trace when the tool reports it separately
+	// from the failure message.
+	StackTrace string `json:"stackTrace,omitempty"`
+	// LogPath is the file holding the full failure text and related output,
+	// relative to the session output directory.
+	LogPath string `json:"logPath,omitempty"`
+	// Timeout is the test's own timeout, when the adapter can determine that
+	// it differs from the session-wide timeout.
+	Timeout time.Duration `json:"timeout,omitempty"`
+	// Suite marks a synthetic result for the file-level setup of a test
+	// suite, such as loading the file and running beforeAll and afterAll
+	// hooks, rather than an individual test.
+	Suite bool `json:"suite,omitempty"`
+	// Attempts lists every attempt of a test the tool retried within the
+	// run, in order; the last attempt determines Outcome. Nil if the test
+	// ran once.
+	Attempts []Attempt `json:"attempts,omitempty"`
+	// FlakyInRun marks a test whose attempts within the run disagreed,
+	// such as one that failed and then passed on retry.
+	FlakyInRun bool `json:"flakyInRun,omitempty"`
+	// Screenshots and Video are media captured when the test failed. Adapters
+	// set them relative to the run directory; the runner rewrites them
+	// relative to the session output directory.
+	Screenshots []string `json:"screenshots,omitempty"`
+	Video       string   `json:"video,omitempty"`
+}
+
+// Attempt is a single attempt of a test within a run.
+type Attempt struct {
+	Outcome        Outcome       `json:"outcome"`
+	Duration       time.Duration `json:"duration"`
+	FailureMessage string        `json:"failureMessage,omitempty"`
+	StackTrace     string        `json:"stackTrace,omitempty"`
 }
 
+// SuiteSetupName is the test name of synthetic suite setup results, whose
+// TestID is <file>::<suite setup>.
+const SuiteSetupName = "<suite setup>"
+
 // RunResult represents the parsed results of a single test run.
 type RunResult struct {
-	RunIndex int          `json:"runIndex"`
-	Tests    []TestResult `json:"tests"`
-	Error    string       `json:"error,omitempty"`
+	RunIndex    int          `json:"runIndex"`
+	Tests       []TestResult `json:"tests"`
+	Error       string       `json:"error,omitempty"`
+	InfraErrors []InfraError `json:"infraErrors,omitempty"`
+}
+
+// InfraError records a failure of the session infrastructure, such as a
+// lifecycle hook, as opposed to a test failure.
+type InfraError struct {
+	RunIndex int    `json:"runIndex,omitempty"` // 0 for session-level errors
+	Stage    string `json:"stage"`
+	Message  string `json:"message"`
+}
+
+// SourceLocation is a position in a source file.
+type SourceLocation struct {
+	File   string `json:"file"` // relative to the project root when inside it
+	Line   int    `json:"line"`
+	Column int    `json:"column,omitempty"`
+}
+
+// String formats the location as file:line:column.
+func (l SourceLocation) String() string {
+	if l.Column == 0 {
+		return fmt.Sprintf("%s:%d", l.File, l.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", l.File, l.Line, l.Column)
 }
 
 // FailureEvidence captures details of a specific failure occurrence.
@@ -53,11 +121,48 @@ type FailureEvidence struct {
 	RunIndex  int              `json:"runIndex"`
 	Excerpt   string           `json:"excerpt"`
 	Signature FailureSignature `json:"signature"`
+	// Location is the first stack frame inside the project, if any.
+	Location *SourceLocation `json:"location,omitempty"`
+	// LogPath is the full failure log, relative to the session output directory.
+	LogPath string `json:"logPath,omitempty"`
+	// Retried marks the failure of an attempt that later passed on retry.
+	Retried bool `json:"retried,omitempty"`
+	// Screenshots and Video are media captured at the failure, relative to
+	// the session output directory.
+	Screenshots []string `json:"screenshots,omitempty"`
+	Video       string   `json:"video,omitempty"`
+}
+
+// FailureCluster groups the failures of a test that occur at the same source
+// location and whose messages are identical once volatile details such as
+// numbers, ids, timestamps and paths are removed.
+type FailureCluster struct {
+	Fingerprint string           `json:"fingerprint"` // normalized message, truncated
+	Signature   FailureSignature `json:"signature"`
+	Count       int              `json:"count"`
+	FirstRun    int              `json:"firstRun"`
+	LastRun     int              `json:"lastRun"`
+	RunIndices  []int            `json:"runIndices"`
+	Message     string           `json:"message"` // full message of the first occurrence
+	Location    *SourceLocation  `json:"location,omitempty"`
+	Snippet     *CodeSnippet     `json:"snippet,omitempty"`
+	LogPath     string           `json:"logPath,omitempty"` // full log of the first occurrence
+	// Screenshot and Video are media of the earliest occurrence that has them.
+	Screenshot string `json:"screenshot,omitempty"`
+	Video      string `json:"video,omitempty"`
+}
+
+// CodeSnippet is an excerpt of a source file around a failure location.
+type CodeSnippet struct {
+	StartLine int      `json:"startLine"` // line number of Lines[0]
+	Lines     []string `json:"lines"`
+	Highlight int      `json:"highlight"` // line number of the failing line
 }
 
 // AggregatedTest represents the aggregated results of a test across all runs.
 type AggregatedTest struct {
 	TestID          string            `json:"testId"`
+	Suite           bool              `json:"suite,omitempty"` // suite setup rather than a test
 	PassCount       int               `json:"passCount"`
 	FailCount       int               `json:"failCount"`
 	SkipCount       int               `json:"skipCount"`
@@ -67,6 +172,90 @@ type AggregatedTest struct {
 	FlakeRate       float64           `json:"flakeRate"`
 	WastedTime      time.Duration     `json:"wastedTime"`
 	FailureEvidence []FailureEvidence `json:"failureEvidence,omitempty"`
+	FailureClusters []FailureCluster  `json:"failureClusters,omitempty"` // sorted by count, descending
+	Runs            []RunSample       `json:"runs,omitempty"`            // sorted by run index
+	DurationStats   *DurationStats    `json:"durationStats,omitempty"`
+	// SlowUnstable marks tests that pass consistently but whose durations
+	// vary widely between runs.
+	SlowUnstable bool `json:"slowUnstable,omitempty"`
+	// TimeoutRisk marks tests whose p95 duration is close to their timeout.
+	TimeoutRisk bool `json:"timeoutRisk,omitempty"`
+	// Attempts counts the attempts across non-skipped runs, including
+	// retries. FlakyInRunCount counts the runs in which attempts disagreed;
+	// they are flake evidence even when the final outcome passed.
+	Attempts        int `json:"attempts,omitempty"`
+	RetriedRuns     int `json:"retriedRuns,omitempty"`
+	FlakyInRunCount int `json:"flakyInRunCount,omitempty"`
+	// Temporal describes the run order of a flaky test's failures.
+	Temporal *TemporalAnalysis `json:"temporal,omitempty"`
+	// Owners are the owners of the test file in CODEOWNERS.
+	Owners []string `json:"owners,omitempty"`
+}
+
+// TemporalPattern is a pattern in the order in which a test fails across runs.
+type TemporalPattern string
+
+const (
+	PatternColdStart   TemporalPattern = "cold_start"  // fails only in the first runs
+	PatternDegrading   TemporalPattern = "degrading"   // fails increasingly in later runs
+	PatternStreaky     TemporalPattern = "streaky"     // failures cluster in consecutive runs
+	PatternAlternating TemporalPattern = "alternating" // passes and failures alternate
+)
+
+// Label returns a short description of the pattern for reports.
+func (p TemporalPattern) Label() string {
+	switch p {
+	case PatternColdStart:
+		return "fails only when cold"
+	case PatternDegrading:
+		return "degrades over time"
+	case PatternStreaky:
+		return "fails in streaks"
+	case PatternAlternating:
+		return "alternates between pass and fail"
+	default:
+		return string(p)
+	}
+}
+
+// TemporalAnalysis describes the order of a test's passes and failures
+// across runs, excluding skips.
+type TemporalAnalysis struct {
+	Patterns []TemporalPattern `json:"patterns,omitempty"`
+	// TrendZ is the rank-sum z-score of failure positions; positive when
+	// failures occur in later runs.
+	TrendZ float64 `json:"trendZ"`
+	// RunsZ is the Wald-Wolfowitz runs test z-score of the pass/fail
+	// sequence; negative when failures cluster, positive when they alternate.
+	RunsZ             float64 `json:"runsZ"`
+	LongestFailStreak int     `json:"longestFailStreak"`
+}
+
+// RunSample is the outcome of a test in a single run.
+type RunSample struct {
+	RunIndex   int           `json:"runIndex"`
+	Outcome    Outcome       `json:"outcome"`
+	Duration   time.Duration `json:"duration"`
+	Attempts   int           `json:"attempts,omitempty"` // set when the test was retried
+	FlakyInRun bool          `json:"flakyInRun,omitempty"`
+}
+
+// Failed reports whether any attempt of the test failed in the run.
+func (r RunSample) Failed() bool {
+	return r.Outcome == OutcomeFail || r.FlakyInRun
+}
+
+// DurationStats summarizes the durations of a test across runs, excluding skips.
+type DurationStats struct {
+	Samples        int           `json:"samples"`
+	Min            time.Duration `json:"min"`
+	Median         time.Duration `json:"median"`
+	P95            time.Duration `json:"p95"`
+	Max            time.Duration `json:"max"`
+	CV             float64       `json:"cv"`             // coefficient of variation: stddev / mean
+	MaxMedianRatio float64       `json:"maxMedianRatio"` // 0 if the median is 0
+	Timeout        time.Duration `json:"timeout,omitempty"`
+	TimeoutShare   float64       `json:"timeoutShare,omitempty"` // p95 / timeout
 }
 
 // Report is the top-level structure for the JSON report.
@@ -80,6 +269,46 @@ type Report struct {
 	Tests            []AggregatedTest `json:"tests"`
 	TopFlakes        []AggregatedTest `json:"topFlakes"`
 	SignatureSummary map[string]int   `json:"signatureSummary"`
+	InfraErrors      []InfraError     `json:"infraErrors,omitempty"`
+	// Suite setup results are counted separately from tests.
+	FlakySuiteCount   int `json:"flakySuiteCount,omitempty"`
+	DetFailSuiteCount int `json:"deterministicFailSuiteCount,omitempty"`
+	// TestTimeout is the session-wide per-test timeout and where it was found.
+	TestTimeout       time.Duration `json:"testTimeout,omitempty"`
+	TestTimeoutSource string        `json:"testTimeoutSource,omitempty"`
+	// CoFailureGroups lists flaky tests that fail together far more often
+	// than chance, largest group first.
+	CoFailureGroups []CoFailureGroup `json:"coFailureGroups,omitempty"`
+	// ProjectRoot is the absolute project directory, against which reports
+	// resolve the test files of absolute test IDs.
+	ProjectRoot string `json:"projectRoot,omitempty"`
+	// Command is the test command that was repeated.
+	Command []string `json:"command,omitempty"`
+	// Owners summarizes the flaky and failing tests of each CODEOWNERS
+	// owner, most wasted time first. It is empty if no test has owners.
+	Owners []OwnerSummary `json:"owners,omitempty"`
+}
+
+// OwnerSummary is the flaky and failing tests of an owner.
+type OwnerSummary struct {
+	Owner        string        `json:"owner"` // empty for tests without owners
+	FlakyCount   int           `json:"flakyCount"`
+	FailingCount int           `json:"failingCount"`
+	WastedTime   time.Duration `json:"wastedTime"`
+	// TestIDs are the flaky and failing tests, most wasted time first.
+	TestIDs []string `json:"testIds"`
+}
+
+// CoFailureGroup is a set of flaky tests whose failures co-occur in the same
+// runs, which usually points to a shared root cause.
+type CoFailureGroup struct {
+	TestIDs []string `json:"testIds"` // sorted
+	// RunIndices are the runs in which at least two of the tests failed.
+	RunIndices []int `json:"runIndices"`
+	// Signature is the most common signature of the tests' failures in those runs.
+	Signature FailureSignature `json:"signature"`
+	// Jaccard is the mean Jaccard similarity of the failed runs of linked pairs.
+	Jaccard float64 `json:"jaccard"`
 }
 
 // Tool represents a supported test tool.
@@ -102,3 +331,51 @@ type Adapter interface {
 	// ExpectedArtifact returns the path to the expected artifact for verification.
 	ExpectedArtifact(runDir string) string
 }
+
+// OutputSlicer is implemented by adapters that can find the part of a run's
+// captured output that belongs to a single test.
+type OutputSlicer interface {
+	// SliceOutput returns the output related to test, or an empty string
+	// if it cannot be identified.
+	SliceOutput(test TestResult, stdout, stderr string) string
+}
+
+// QuarantineRenderer is implemented by adapters that can make the test tool
+// skip quarantined tests in normal runs.
+type QuarantineRenderer interface {
+	// RenderQuarantine returns a file that skips the tests in expiries, which
+	// maps test IDs to the YYYY-MM-DD date from which they run again, unless
+	// the tool runs under flakehunt, and instructions for loading it from
+	// path. source is the quarantine file it is generated from.
+	RenderQuarantine(expiries map[string]string, source, path string) (content, usage string, err error)
+}
+
+// TimeoutDiscoverer is implemented by adapters that can determine the
+// per-test timeout configured in the test tool.
+type TimeoutDiscoverer interface {
+	// DiscoverTimeout returns the timeout that applies to tests run by
+	// userCmd from projectDir, and a description of where it was found.
+	// Tools without a per-test timeout return their closest limit and say
+	// so in the description.
+	DiscoverTimeout(projectDir string, userCmd []string) (time.Duration, string, error)
+}
+
+// RelativeTestID returns testID with its file made relative to projectRoot,
+// with forward slashes, if the file is an absolute path inside projectRoot.
+// Jest test IDs start with absolute paths; other test IDs, and IDs without a
+// test name, are handled alike.
+func RelativeTestID(testID, projectRoot string) string {
+	file, name, found := strings.Cut(testID, "::")
+	if projectRoot == "" || !filepath.IsAbs(file) {
+		return testID
+	}
+	rel, err := filepath.Rel(projectRoot, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return testID
+	}
+	rel = filepath.ToSlash(rel)
+	if !found {
+		return rel
+	}
+	return rel + "::" + name
+}
@@ -0,0 +1,21 @@
+package model
+
+import "testing"
+
+func TestRelativeTestID(t *testing.T) {
+	tests := []struct {
+		testID, root, want string
+	}{
+		{"/repo/src/a.test.js::A works", "/repo", "src/a.test.js::A works"},
+		{"/repo/src/a.test.js", "/repo", "src/a.test.js"},
+		{"/other/a.test.js::A", "/repo", "/other/a.test.js::A"},
+		{"/repository/a.test.js::A", "/repo", "/repository/a.test.js::A"},
+		{"src/a.test.js::A", "/repo", "src/a.test.js::A"},
+		{"/repo/src/a.test.js::A", "", "/repo/src/a.test.js::A"},
+	}
+	for _, tc := range tests {
+		if got := RelativeTestID(tc.testID, tc.root); got != tc.want {
+			t.Errorf("RelativeTestID(%q, %q) = %q, want %q", tc.testID, tc.root, got, tc.want)
+		}
+	}
+}
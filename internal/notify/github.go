@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// gitHub maintains a sticky comment on a GitHub pull request, using the
+// issue comments API.
+type gitHub struct {
+	cfg Config
+	api *apiClient
+}
+
+func newGitHub(cfg Config) Notifier {
+	return &gitHub{cfg: cfg, api: newGitHubAPI(cfg.Client, cfg.Token)}
+}
+
+// newGitHubAPI returns a client of the GitHub REST API.
+func newGitHubAPI(client *http.Client, token string) *apiClient {
+	return &apiClient{
+		client:  client,
+		service: "GitHub",
+		header: http.Header{
+			"Accept":               {"application/vnd.github+json"},
+			"Authorization":        {"Bearer " + token},
+			"X-Github-Api-Version": {"2022-11-28"},
+		},
+	}
+}
+
+// Notify creates the flakehunt comment on the pull request, or updates it if
+// it exists.
+func (g *gitHub) Notify(ctx context.Context, rpt *model.Report) error {
+	existing, err := findComment(ctx, g.listComments)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{"body": commentBody(g.cfg, rpt)}
+
+	if existing != nil {
+		url := fmt.Sprintf("%s/repos/%s/issues/comments/%d", g.cfg.BaseURL, g.cfg.Repo, existing.ID)
+		if err := g.api.do(ctx, http.MethodPatch, url, body, nil); err != nil {
+			return fmt.Errorf("failed to update pull request comment: %w", err)
+		}
+		return nil
+	}
+	if !hasFindings(rpt) {
+		return nil
+	}
+	url := fmt.Sprintf("%s/repos/%s/issues/%d/comments", g.cfg.BaseURL, g.cfg.Repo, g.cfg.Number)
+	if err := g.api.do(ctx, http.MethodPost, url, body, nil); err != nil {
+		return fmt.Errorf("failed to create pull request comment: %w", err)
+	}
+	return nil
+}
+
+// listComments returns a page of the pull request's comments.
+func (g *gitHub) listComments(ctx context.Context, page int) ([]comment, error) {
+	url := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=%d&page=%d", g.cfg.BaseURL, g.cfg.Repo, g.cfg.Number, commentsPerPage, page)
+	var comments []comment
+	if err := g.api.do(ctx, http.MethodGet, url, nil, &comments); err != nil {
+		return nil, fmt.Errorf("failed to list pull request comments: %w", err)
+	}
+	return comments, nil
+}
+
+// gitHubIssues files tickets as GitHub issues.
+type gitHubIssues struct {
+	cfg IssuesConfig
+	api *apiClient
+}
+
+func newGitHubIssues(cfg IssuesConfig) Tracker {
+	return &gitHubIssues{cfg: cfg, api: newGitHubAPI(cfg.Client, cfg.Token)}
+}
+
+// Create files ticket as an issue of the configured repository.
+func (g *gitHubIssues) Create(ctx context.Context, ticket Ticket) (TrackedIssue, error) {
+	body := map[string]any{"title": ticket.Title, "body": ticket.Body}
+	if len(g.cfg.Labels) > 0 {
+		body["labels"] = g.cfg.Labels
+	}
+
+	var created struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/issues", g.cfg.BaseURL, g.cfg.Repo)
+	if err := g.api.do(ctx, http.MethodPost, url, body, &created); err != nil {
+		return TrackedIssue{}, err
+	}
+	return TrackedIssue{Key: fmt.Sprintf("#%d", created.Number), URL: created.HTMLURL}, nil
+}
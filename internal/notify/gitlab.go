@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// gitLab maintains a sticky note on a GitLab merge request.
+type gitLab struct {
+	cfg Config
+	api *apiClient
+}
+
+func newGitLab(cfg Config) Notifier {
+	return &gitLab{
+		cfg: cfg,
+		api: &apiClient{
+			client:  cfg.Client,
+			service: "GitLab",
+			header:  http.Header{"Private-Token": {cfg.Token}},
+		},
+	}
+}
+
+// Notify creates the flakehunt note on the merge request, or updates it if
+// it exists.
+func (g *gitLab) Notify(ctx context.Context, rpt *model.Report) error {
+	existing, err := findComment(ctx, g.listNotes)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{"body": commentBody(g.cfg, rpt)}
+
+	if existing != nil {
+		if err := g.api.do(ctx, http.MethodPut, fmt.Sprintf("%s/%d", g.notesURL(), existing.ID), body, nil); err != nil {
+			return fmt.Errorf("failed to update merge request note: %w", err)
+		}
+		return nil
+	}
+	if !hasFindings(rpt) {
+		return nil
+	}
+	if err := g.api.do(ctx, http.MethodPost, g.notesURL(), body, nil); err != nil {
+		return fmt.Errorf("failed to create merge request note: %w", err)
+	}
+	return nil
+}
+
+// listNotes returns a page of the merge request's notes.
+func (g *gitLab) listNotes(ctx context.Context, page int) ([]comment, error) {
+	var notes []comment
+	listURL := fmt.Sprintf("%s?per_page=%d&page=%d", g.notesURL(), commentsPerPage, page)
+	if err := g.api.do(ctx, http.MethodGet, listURL, nil, &notes); err != nil {
+		return nil, fmt.Errorf("failed to list merge request notes: %w", err)
+	}
+	return notes, nil
+}
+
+// notesURL returns the URL of the merge request's notes. Project paths such
+// as group/project are escaped into a single path segment.
+func (g *gitLab) notesURL() string {
+	return fmt.Sprintf("%s/projects/%s/merge_requests/%d/notes", g.cfg.BaseURL, url.PathEscape(g.cfg.Repo), g.cfg.Number)
+}
@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+	"github.com/boyarskiy/flakehunt/internal/report"
+)
+
+// IssuesConfig selects the issue tracker that tickets are filed in.
+type IssuesConfig struct {
+	Provider  string // github or jira
+	BaseURL   string // REST API base URL; the site URL for Jira
+	Repo      string // GitHub owner/name
+	Project   string // Jira project key
+	IssueType string // Jira issue type; Bug if empty
+	Labels    []string
+	User      string // Jira account email for basic auth; bearer auth if empty
+	Token     string
+
+	// Client sends API requests; http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// Tracker files tickets in an issue tracker.
+type Tracker interface {
+	// Create files ticket and returns the created issue.
+	Create(ctx context.Context, ticket Ticket) (TrackedIssue, error)
+}
+
+// Ticket is a rendered ticket for a flaky test.
+type Ticket struct {
+	Fingerprint string
+	TestID      string
+	Title       string
+	Body        string // Markdown, or wiki markup for Jira
+}
+
+// trackers creates the tracker of each supported provider.
+var trackers = map[string]func(cfg IssuesConfig) Tracker{
+	"github": newGitHubIssues,
+	"jira":   newJira,
+}
+
+// DefaultIssuesTokenEnv is the environment variable holding each tracker's
+// API token, unless configured otherwise.
+var DefaultIssuesTokenEnv = map[string]string{
+	"github": "GITHUB_TOKEN",
+	"jira":   "JIRA_API_TOKEN",
+}
+
+// NewTracker returns the tracker for cfg.Provider.
+func NewTracker(cfg IssuesConfig) (Tracker, error) {
+	create, ok := trackers[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown issues provider %q. Supported providers: github, jira", cfg.Provider)
+	}
+	switch cfg.Provider {
+	case "github":
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = defaultBaseURLs["github"]
+		}
+		if cfg.Repo == "" {
+			return nil, fmt.Errorf("no repository to file issues in: set issues.repo")
+		}
+	case "jira":
+		if cfg.BaseURL == "" {
+			return nil, fmt.Errorf("no Jira site to file issues in: set issues.url")
+		}
+		if cfg.Project == "" {
+			return nil, fmt.Errorf("no Jira project to file issues in: set issues.project")
+		}
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("no %s API token", cfg.Provider)
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return create(cfg), nil
+}
+
+// IssuesFromEnv fills the unset fields of cfg from the GitHub Actions
+// environment.
+func IssuesFromEnv(cfg IssuesConfig, getenv func(string) string) IssuesConfig {
+	if cfg.Provider != "github" {
+		return cfg
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = getenv("GITHUB_API_URL")
+	}
+	if cfg.Repo == "" {
+		cfg.Repo = getenv("GITHUB_REPOSITORY")
+	}
+	return cfg
+}
+
+// Tickets renders a ticket for each flaky test of report without a tracked
+// issue in known, most wasted time first. At most limit tickets are
+// returned, unless limit is 0.
+func Tickets(rpt *model.Report, provider string, known *KnownFlakes, limit int) []Ticket {
+	var flakes []model.AggregatedTest
+	for _, test := range rpt.Tests {
+		if test.Classification != model.ClassificationFlaky {
+			continue
+		}
+		if _, ok := known.Issues[report.TestFingerprint(test.TestID, rpt.ProjectRoot)]; ok {
+			continue
+		}
+		flakes = append(flakes, test)
+	}
+	sort.SliceStable(flakes, func(i, j int) bool {
+		if flakes[i].WastedTime != flakes[j].WastedTime {
+			return flakes[i].WastedTime > flakes[j].WastedTime
+		}
+		return flakes[i].TestID < flakes[j].TestID
+	})
+	if limit > 0 && len(flakes) > limit {
+		flakes = flakes[:limit]
+	}
+
+	tickets := make([]Ticket, 0, len(flakes))
+	for _, test := range flakes {
+		body := report.RenderIssue(rpt, test)
+		if provider == "jira" {
+			body = report.RenderJiraIssue(rpt, test)
+		}
+		tickets = append(tickets, Ticket{
+			Fingerprint: report.TestFingerprint(test.TestID, rpt.ProjectRoot),
+			TestID:      model.RelativeTestID(test.TestID, rpt.ProjectRoot),
+			Title:       report.IssueTitle(rpt, test),
+			Body:        body,
+		})
+	}
+	return tickets
+}
+
+// FileIssues creates tickets with tracker and records the created issues in
+// known. It returns the created issues and the errors of the others.
+func FileIssues(ctx context.Context, tracker Tracker, tickets []Ticket, known *KnownFlakes, now time.Time) ([]TrackedIssue, error) {
+	var filed []TrackedIssue
+	var errs []error
+	for _, ticket := range tickets {
+		issue, err := tracker.Create(ctx, ticket)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to file issue for %s: %w", ticket.TestID, err))
+			continue
+		}
+		issue.TestID = ticket.TestID
+		issue.Created = now
+		known.Issues[ticket.Fingerprint] = issue
+		filed = append(filed, issue)
+	}
+	return filed, errors.Join(errs...)
+}
+
+// WriteTickets writes each ticket to dir as <fingerprint>.md, or
+// <fingerprint>.jira.txt for Jira, and returns the written paths.
+func WriteTickets(dir, provider string, tickets []Ticket) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	ext := ".md"
+	if provider == "jira" {
+		ext = ".jira.txt"
+	}
+	paths := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		path := filepath.Join(dir, ticket.Fingerprint+ext)
+		content := fmt.Sprintf("Title: %s\n\n%s", ticket.Title, ticket.Body)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return paths, fmt.Errorf("failed to write ticket to %s: %w", path, err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,203 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+	"github.com/boyarskiy/flakehunt/internal/report"
+)
+
+// issuesReport returns a report with two flaky tests and a failing one.
+func issuesReport() *model.Report {
+	rpt := flakyReport()
+	rpt.Tests[0].WastedTime = time.Second
+	rpt.Tests = append(rpt.Tests,
+		model.AggregatedTest{TestID: "src/b.test.js::B", Classification: model.ClassificationFlaky, WastedTime: 3 * time.Second},
+		model.AggregatedTest{TestID: "src/c.test.js::C", Classification: model.ClassificationDeterministicFail},
+	)
+	rpt.FlakyCount = 2
+	return rpt
+}
+
+func TestTickets(t *testing.T) {
+	known := knownFlakes()
+	tickets := Tickets(issuesReport(), "github", known, 0)
+	if len(tickets) != 2 || tickets[0].TestID != "src/b.test.js::B" || tickets[1].TestID != "src/a.test.js::A works" {
+		t.Fatalf("tickets = %+v, want B then A, most wasted time first", tickets)
+	}
+	if tickets[1].Title != "Flaky test: src/a.test.js::A works" || tickets[1].Fingerprint != report.TestFingerprint("src/a.test.js::A works", "") {
+		t.Errorf("ticket = %+v", tickets[1])
+	}
+	if !strings.Contains(tickets[1].Body, "## Failure Modes") {
+		t.Errorf("GitHub ticket body is not Markdown:\n%s", tickets[1].Body)
+	}
+
+	if got := Tickets(issuesReport(), "jira", known, 1); len(got) != 1 || !strings.Contains(got[0].Body, "h2. Reproduce") {
+		t.Errorf("limited Jira tickets = %+v, want one in wiki markup", got)
+	}
+
+	// Tests with a tracked issue are skipped
+	known.Issues[report.TestFingerprint("src/b.test.js::B", "")] = TrackedIssue{Key: "#1"}
+	if got := Tickets(issuesReport(), "github", known, 0); len(got) != 1 || got[0].TestID != "src/a.test.js::A works" {
+		t.Errorf("tickets = %+v, want only A", got)
+	}
+}
+
+func TestFileIssues(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      IssuesConfig
+		wantPath string
+		wantAuth string
+		wantKey  string
+		wantURL  string
+		check    func(t *testing.T, body map[string]any)
+	}{
+		{
+			name:     "github",
+			cfg:      IssuesConfig{Provider: "github", Repo: "acme/shop", Labels: []string{"flaky"}, Token: "secret"},
+			wantPath: "/repos/acme/shop/issues",
+			wantAuth: "Bearer secret",
+			wantKey:  "#12",
+			wantURL:  "https://github.com/acme/shop/issues/12",
+			check: func(t *testing.T, body map[string]any) {
+				if body["title"] != "Flaky test: src/a.test.js::A works" || body["labels"].([]any)[0] != "flaky" {
+					t.Errorf("issue = %v", body)
+				}
+			},
+		},
+		{
+			name:     "jira",
+			cfg:      IssuesConfig{Provider: "jira", Project: "SHOP", User: "ci@acme.com", Token: "secret"},
+			wantPath: "/rest/api/2/issue",
+			wantAuth: "Basic Y2lAYWNtZS5jb206c2VjcmV0",
+			wantKey:  "SHOP-7",
+			check: func(t *testing.T, body map[string]any) {
+				fields := body["fields"].(map[string]any)
+				if fields["project"].(map[string]any)["key"] != "SHOP" || fields["issuetype"].(map[string]any)["name"] != "Bug" {
+					t.Errorf("fields = %v", fields)
+				}
+				if !strings.Contains(fields["description"].(string), "h2. Failure Modes") {
+					t.Errorf("description is not wiki markup: %v", fields["description"])
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != tt.wantPath {
+					t.Errorf("request = %s %s, want POST %s", r.Method, r.URL.Path, tt.wantPath)
+				}
+				if got := r.Header.Get("Authorization"); got != tt.wantAuth {
+					t.Errorf("Authorization = %q, want %q", got, tt.wantAuth)
+				}
+				var body map[string]any
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				tt.check(t, body)
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"number": 12, "html_url": "https://github.com/acme/shop/issues/12", "key": "SHOP-7"}`))
+			}))
+			t.Cleanup(srv.Close)
+
+			cfg := tt.cfg
+			cfg.BaseURL = srv.URL
+			tracker, err := NewTracker(cfg)
+			if err != nil {
+				t.Fatalf("NewTracker failed: %v", err)
+			}
+
+			known := knownFlakes()
+			now := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+			filed, err := FileIssues(context.Background(), tracker, Tickets(flakyReport(), tt.cfg.Provider, known, 0), known, now)
+			if err != nil {
+				t.Fatalf("FileIssues failed: %v", err)
+			}
+
+			wantURL := tt.wantURL
+			if wantURL == "" {
+				wantURL = srv.URL + "/browse/SHOP-7"
+			}
+			want := TrackedIssue{TestID: "src/a.test.js::A works", Key: tt.wantKey, URL: wantURL, Created: now}
+			if len(filed) != 1 || filed[0] != want {
+				t.Errorf("filed = %+v, want [%+v]", filed, want)
+			}
+			if got := known.Issues[report.TestFingerprint("src/a.test.js::A works", "")]; got != want {
+				t.Errorf("tracked issue = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestFileIssuesError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Resource not accessible"}`, http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+
+	tracker, err := NewTracker(IssuesConfig{Provider: "github", BaseURL: srv.URL, Repo: "acme/shop", Token: "secret"})
+	if err != nil {
+		t.Fatalf("NewTracker failed: %v", err)
+	}
+	known := knownFlakes()
+	filed, err := FileIssues(context.Background(), tracker, Tickets(issuesReport(), "github", known, 0), known, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "failed to file issue for src/b.test.js::B") || !strings.Contains(err.Error(), "403 Forbidden") {
+		t.Errorf("FileIssues() error = %v", err)
+	}
+	if len(filed) != 0 || len(known.Issues) != 0 {
+		t.Errorf("filed = %v, tracked = %v, want none", filed, known.Issues)
+	}
+}
+
+func TestNewTrackerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     IssuesConfig
+		wantErr string
+	}{
+		{"unknown provider", IssuesConfig{Provider: "linear", Token: "secret"}, `unknown issues provider "linear"`},
+		{"github without repo", IssuesConfig{Provider: "github", Token: "secret"}, "set issues.repo"},
+		{"jira without site", IssuesConfig{Provider: "jira", Project: "SHOP", Token: "secret"}, "set issues.url"},
+		{"jira without project", IssuesConfig{Provider: "jira", BaseURL: "https://acme.atlassian.net", Token: "secret"}, "set issues.project"},
+		{"no token", IssuesConfig{Provider: "github", Repo: "acme/shop"}, "no github API token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTracker(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewTracker() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWriteTickets(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "issues")
+	tickets := Tickets(flakyReport(), "jira", knownFlakes(), 0)
+
+	paths, err := WriteTickets(dir, "jira", tickets)
+	if err != nil {
+		t.Fatalf("WriteTickets failed: %v", err)
+	}
+	want := filepath.Join(dir, tickets[0].Fingerprint+".jira.txt")
+	if len(paths) != 1 || paths[0] != want {
+		t.Fatalf("paths = %v, want [%s]", paths, want)
+	}
+	data, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatalf("failed to read ticket: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "Title: Flaky test: src/a.test.js::A works\n\n") {
+		t.Errorf("ticket file = %q", data)
+	}
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+)
+
+// jira files tickets with the Jira REST API v2, whose descriptions are
+// wiki markup.
+type jira struct {
+	cfg IssuesConfig
+	api *apiClient
+}
+
+func newJira(cfg IssuesConfig) Tracker {
+	auth := "Bearer " + cfg.Token
+	if cfg.User != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.User+":"+cfg.Token))
+	}
+	return &jira{
+		cfg: cfg,
+		api: &apiClient{
+			client:  cfg.Client,
+			service: "Jira",
+			header: http.Header{
+				"Accept":        {"application/json"},
+				"Authorization": {auth},
+			},
+		},
+	}
+}
+
+// Create files ticket as an issue of the configured project.
+func (j *jira) Create(ctx context.Context, ticket Ticket) (TrackedIssue, error) {
+	fields := map[string]any{
+		"project":     map[string]string{"key": j.cfg.Project},
+		"issuetype":   map[string]string{"name": cmp.Or(j.cfg.IssueType, "Bug")},
+		"summary":     ticket.Title,
+		"description": ticket.Body,
+	}
+	if len(j.cfg.Labels) > 0 {
+		fields["labels"] = j.cfg.Labels
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.api.do(ctx, http.MethodPost, j.cfg.BaseURL+"/rest/api/2/issue", map[string]any{"fields": fields}, &created); err != nil {
+		return TrackedIssue{}, err
+	}
+	return TrackedIssue{Key: created.Key, URL: fmt.Sprintf("%s/browse/%s", j.cfg.BaseURL, created.Key)}, nil
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// KnownFlakesFile is the name of the known flakes state file in the output
+// directory. It lives outside latest/ so that it survives across sessions.
+const KnownFlakesFile = "known-flakes.json"
+
+// KnownFlakes records the flaky tests found by earlier sessions, so that
+// notifications can tell new flakes from known ones, and the tickets filed
+// for them.
+type KnownFlakes struct {
+	Tests map[string]KnownFlake `json:"tests"`
+	// Issues are the filed tickets, by test fingerprint.
+	Issues map[string]TrackedIssue `json:"issues,omitempty"`
+}
+
+// TrackedIssue is a ticket filed for a flaky test.
+type TrackedIssue struct {
+	TestID  string    `json:"testId"`
+	Key     string    `json:"key"` // issue number or Jira key
+	URL     string    `json:"url,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// KnownFlake is a test found flaky by an earlier session.
+type KnownFlake struct {
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
+
+// NewKnownFlakes returns an empty state.
+func NewKnownFlakes() *KnownFlakes {
+	return &KnownFlakes{Tests: map[string]KnownFlake{}, Issues: map[string]TrackedIssue{}}
+}
+
+// LoadKnownFlakes reads the state file at path. A missing file yields an
+// empty state.
+func LoadKnownFlakes(path string) (*KnownFlakes, error) {
+	known := NewKnownFlakes()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return known, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, known); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if known.Tests == nil {
+		known.Tests = map[string]KnownFlake{}
+	}
+	if known.Issues == nil {
+		known.Issues = map[string]TrackedIssue{}
+	}
+	return known, nil
+}
+
+// Save writes the state file to path.
+func (k *KnownFlakes) Save(path string) error {
+	data, err := json.MarshalIndent(k, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode known flakes: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// IsKnown reports whether testID was found flaky by an earlier session.
+// Tests are keyed by their ID relative to projectRoot, so the state carries
+// over between checkouts in different directories.
+func (k *KnownFlakes) IsKnown(testID, projectRoot string) bool {
+	_, ok := k.Tests[model.RelativeTestID(testID, projectRoot)]
+	return ok
+}
+
+// Update records the flaky tests of report as seen at now.
+func (k *KnownFlakes) Update(report *model.Report, now time.Time) {
+	for _, test := range report.Tests {
+		if test.Classification != model.ClassificationFlaky {
+			continue
+		}
+		id := model.RelativeTestID(test.TestID, report.ProjectRoot)
+		flake, ok := k.Tests[id]
+		if !ok {
+			flake.FirstSeen = now
+		}
+		flake.LastSeen = now
+		k.Tests[id] = flake
+	}
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+func TestKnownFlakes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), KnownFlakesFile)
+
+	known, err := LoadKnownFlakes(path)
+	if err != nil {
+		t.Fatalf("LoadKnownFlakes of a missing file failed: %v", err)
+	}
+	if len(known.Tests) != 0 {
+		t.Fatalf("got %d known flakes, want none", len(known.Tests))
+	}
+
+	first := time.Date(2026, 10, 1, 2, 0, 0, 0, time.UTC)
+	known.Update(flakyReport(), first)
+	if err := known.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// A later session sees the flake again, and a stable test stays unknown
+	rpt := flakyReport()
+	rpt.Tests = append(rpt.Tests, model.AggregatedTest{TestID: "src/b.test.js::B", Classification: model.ClassificationStable})
+	known, err = LoadKnownFlakes(path)
+	if err != nil {
+		t.Fatalf("LoadKnownFlakes failed: %v", err)
+	}
+	second := first.Add(24 * time.Hour)
+	known.Update(rpt, second)
+
+	want := KnownFlake{FirstSeen: first, LastSeen: second}
+	if got := known.Tests["src/a.test.js::A works"]; !got.FirstSeen.Equal(want.FirstSeen) || !got.LastSeen.Equal(want.LastSeen) {
+		t.Errorf("known flake = %+v, want %+v", got, want)
+	}
+	if known.IsKnown("src/b.test.js::B", "") {
+		t.Error("stable test recorded as a known flake")
+	}
+}
+
+func TestKnownFlakesRelativeToProjectRoot(t *testing.T) {
+	known := NewKnownFlakes()
+	known.Update(&model.Report{
+		ProjectRoot: "/work/shop",
+		Tests: []model.AggregatedTest{
+			{TestID: "/work/shop/src/a.test.js::A works", Classification: model.ClassificationFlaky},
+		},
+	}, time.Now())
+
+	if _, ok := known.Tests["src/a.test.js::A works"]; !ok {
+		t.Errorf("known flakes = %v, want keys relative to the project root", known.Tests)
+	}
+	if !known.IsKnown("/ci/build/src/a.test.js::A works", "/ci/build") {
+		t.Error("flake is not known in another checkout")
+	}
+}
+
+func TestLoadKnownFlakesInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), KnownFlakesFile)
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKnownFlakes(path); err == nil {
+		t.Error("expected error for an invalid state file, got nil")
+	}
+}
@@ -0,0 +1,219 @@
+// Package notify posts flakehunt results to code review systems, chat
+// webhooks and issue trackers.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+	"github.com/boyarskiy/flakehunt/internal/report"
+)
+
+// Marker identifies the comment flakehunt maintains on a pull request. It is
+// an HTML comment, hidden when the Markdown is rendered.
+const Marker = "<!-- flakehunt:report -->"
+
+// Notifier posts the results of a session.
+type Notifier interface {
+	// Notify posts a summary of report.
+	Notify(ctx context.Context, report *model.Report) error
+}
+
+// Config selects where results are posted.
+type Config struct {
+	Provider     string // github or gitlab
+	BaseURL      string // REST API base URL
+	Repo         string // GitHub owner/name, or GitLab project path or ID
+	Number       int    // pull request or merge request number
+	Token        string
+	ArtifactsURL string // linked from the comment, if set
+
+	// Client sends API requests; http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// providers creates the notifier of each supported provider.
+var providers = map[string]func(cfg Config) Notifier{
+	"github": newGitHub,
+	"gitlab": newGitLab,
+}
+
+// defaultBaseURLs are the API base URLs of the public services.
+var defaultBaseURLs = map[string]string{
+	"github": "https://api.github.com",
+	"gitlab": "https://gitlab.com/api/v4",
+}
+
+// DefaultTokenEnv is the environment variable holding each provider's API
+// token, unless configured otherwise.
+var DefaultTokenEnv = map[string]string{
+	"github": "GITHUB_TOKEN",
+	"gitlab": "GITLAB_TOKEN",
+}
+
+// New returns the notifier for cfg.Provider. The base URL defaults to the
+// public service's.
+func New(cfg Config) (Notifier, error) {
+	create, ok := providers[cfg.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown notify provider %q. Supported providers: github, gitlab", cfg.Provider)
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = defaultBaseURLs[cfg.Provider]
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Repo == "" {
+		return nil, fmt.Errorf("no repository to comment on: set notify.repo")
+	}
+	if cfg.Number <= 0 {
+		return nil, fmt.Errorf("no pull request to comment on: set notify.pr")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("no %s API token", cfg.Provider)
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return create(cfg), nil
+}
+
+// pullRefPattern matches the ref of a GitHub pull request build.
+var pullRefPattern = regexp.MustCompile(`^refs/pull/(\d+)/`)
+
+// FromEnv fills the unset fields of cfg from the CI environment of its
+// provider: GitHub Actions or GitLab CI.
+func FromEnv(cfg Config, getenv func(string) string) Config {
+	switch cfg.Provider {
+	case "github":
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = getenv("GITHUB_API_URL")
+		}
+		if cfg.Repo == "" {
+			cfg.Repo = getenv("GITHUB_REPOSITORY")
+		}
+		if cfg.Number == 0 {
+			if m := pullRefPattern.FindStringSubmatch(getenv("GITHUB_REF")); m != nil {
+				cfg.Number, _ = strconv.Atoi(m[1])
+			}
+		}
+		if cfg.ArtifactsURL == "" && getenv("GITHUB_RUN_ID") != "" {
+			cfg.ArtifactsURL = fmt.Sprintf("%s/%s/actions/runs/%s",
+				getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY"), getenv("GITHUB_RUN_ID"))
+		}
+	case "gitlab":
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = getenv("CI_API_V4_URL")
+		}
+		if cfg.Repo == "" {
+			cfg.Repo = getenv("CI_PROJECT_ID")
+		}
+		if cfg.Number == 0 {
+			cfg.Number, _ = strconv.Atoi(getenv("CI_MERGE_REQUEST_IID"))
+		}
+		if cfg.ArtifactsURL == "" && getenv("CI_JOB_URL") != "" {
+			cfg.ArtifactsURL = getenv("CI_JOB_URL") + "/artifacts/browse"
+		}
+	}
+	return cfg
+}
+
+// commentBody returns the body of the sticky comment.
+func commentBody(cfg Config, rpt *model.Report) string {
+	return Marker + "\n" + report.RenderComment(rpt, cfg.ArtifactsURL)
+}
+
+// hasFindings reports whether a session found flaky or failing tests. A
+// comment is only created for sessions with findings, but an existing one is
+// always updated so that it does not go stale.
+func hasFindings(rpt *model.Report) bool {
+	return rpt.FlakyCount > 0 || rpt.DetFailCount > 0
+}
+
+// comment is a pull request comment, as GitHub issue comments and GitLab
+// notes share these fields.
+type comment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// maxCommentPages bounds the pages of comments searched for the marker.
+const maxCommentPages = 20
+
+// commentsPerPage is the page size of comment listings.
+const commentsPerPage = 100
+
+// findComment returns the first comment containing Marker on the pages
+// listed by list, or nil if there is none.
+func findComment(ctx context.Context, list func(ctx context.Context, page int) ([]comment, error)) (*comment, error) {
+	for page := 1; page <= maxCommentPages; page++ {
+		comments, err := list(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		for i := range comments {
+			if strings.Contains(comments[i].Body, Marker) {
+				return &comments[i], nil
+			}
+		}
+		if len(comments) < commentsPerPage {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// apiClient sends JSON requests to a REST API.
+type apiClient struct {
+	client  *http.Client
+	service string // service name for error messages
+	header  http.Header
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out, if not nil.
+func (c *apiClient) do(ctx context.Context, method, url string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s API: %w", c.service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s API %s %s returned %s: %s", c.service, method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode %s API response: %w", c.service, err)
+		}
+	}
+	return nil
+}
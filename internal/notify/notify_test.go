@@ -0,0 +1,308 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// fakeServer is a stand-in for the comment APIs of GitHub and GitLab,
+// holding the comments of a single pull request.
+type fakeServer struct {
+	t        *testing.T
+	provider string
+
+	mu       sync.Mutex
+	comments []comment
+	requests []string // method and escaped path of each request
+	fail     bool     // reject every request
+}
+
+func newFakeServer(t *testing.T, provider string, comments ...comment) (*fakeServer, *httptest.Server) {
+	f := &fakeServer{t: t, provider: provider, comments: comments}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.EscapedPath())
+
+	if f.fail {
+		http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+		return
+	}
+	switch f.provider {
+	case "github":
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			f.t.Errorf("Authorization = %q, want Bearer secret", r.Header.Get("Authorization"))
+		}
+	case "gitlab":
+		if r.Header.Get("Private-Token") != "secret" {
+			f.t.Errorf("Private-Token = %q, want secret", r.Header.Get("Private-Token"))
+		}
+	}
+
+	var body struct{ Body string }
+	if r.Body != nil && r.Method != http.MethodGet {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.t.Errorf("failed to decode request body: %v", err)
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		page := r.URL.Query().Get("page")
+		if page != "1" {
+			json.NewEncoder(w).Encode([]comment{})
+			return
+		}
+		json.NewEncoder(w).Encode(f.comments)
+	case http.MethodPost:
+		c := comment{ID: int64(100 + len(f.comments)), Body: body.Body}
+		f.comments = append(f.comments, c)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(c)
+	case http.MethodPatch, http.MethodPut:
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		for i := range f.comments {
+			if fmt.Sprint(f.comments[i].ID) == id {
+				f.comments[i].Body = body.Body
+				json.NewEncoder(w).Encode(f.comments[i])
+				return
+			}
+		}
+		http.NotFound(w, r)
+	default:
+		http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+	}
+}
+
+// flakyReport returns a report with one flaky test.
+func flakyReport() *model.Report {
+	flake := model.AggregatedTest{
+		TestID:         "src/a.test.js::A works",
+		Classification: model.ClassificationFlaky,
+		PassCount:      7,
+		FailCount:      3,
+		TotalRuns:      10,
+		FlakeRate:      0.3,
+		FailureClusters: []model.FailureCluster{
+			{Signature: model.SignatureTimeout, Count: 3, Message: "Exceeded timeout of 5000 ms"},
+		},
+	}
+	return &model.Report{
+		Tool:         "jest",
+		Target:       "npx jest",
+		RunsExecuted: 10,
+		FlakyCount:   1,
+		Tests:        []model.AggregatedTest{flake},
+		TopFlakes:    []model.AggregatedTest{flake},
+	}
+}
+
+func newTestNotifier(t *testing.T, provider, baseURL string) Notifier {
+	t.Helper()
+	n, err := New(Config{
+		Provider:     provider,
+		BaseURL:      baseURL,
+		Repo:         "acme/shop",
+		Number:       42,
+		Token:        "secret",
+		ArtifactsURL: "https://ci.example.com/runs/7",
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return n
+}
+
+func TestNotifyCreatesComment(t *testing.T) {
+	tests := []struct {
+		provider     string
+		wantRequests []string
+	}{
+		{"github", []string{
+			"GET /repos/acme/shop/issues/42/comments",
+			"POST /repos/acme/shop/issues/42/comments",
+		}},
+		{"gitlab", []string{
+			"GET /projects/acme%2Fshop/merge_requests/42/notes",
+			"POST /projects/acme%2Fshop/merge_requests/42/notes",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			fake, srv := newFakeServer(t, tt.provider, comment{ID: 1, Body: "LGTM"})
+
+			if err := newTestNotifier(t, tt.provider, srv.URL).Notify(context.Background(), flakyReport()); err != nil {
+				t.Fatalf("Notify failed: %v", err)
+			}
+
+			if strings.Join(fake.requests, "\n") != strings.Join(tt.wantRequests, "\n") {
+				t.Errorf("requests =\n%s\nwant\n%s", strings.Join(fake.requests, "\n"), strings.Join(tt.wantRequests, "\n"))
+			}
+			if len(fake.comments) != 2 {
+				t.Fatalf("got %d comments, want 2", len(fake.comments))
+			}
+			body := fake.comments[1].Body
+			for _, want := range []string{Marker, "## Flakehunt: 1 flaky test", "src/a.test.js::A works", "[Full report and run artifacts](https://ci.example.com/runs/7)"} {
+				if !strings.Contains(body, want) {
+					t.Errorf("comment body missing %q:\n%s", want, body)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyUpdatesStickyComment(t *testing.T) {
+	tests := []struct {
+		provider    string
+		wantRequest string
+	}{
+		{"github", "PATCH /repos/acme/shop/issues/comments/7"},
+		{"gitlab", "PUT /projects/acme%2Fshop/merge_requests/42/notes/7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			fake, srv := newFakeServer(t, tt.provider,
+				comment{ID: 1, Body: "LGTM"},
+				comment{ID: 7, Body: Marker + "\nold results"},
+			)
+
+			// An existing comment is updated even when no flakes remain
+			stable := &model.Report{Tool: "jest", RunsExecuted: 10, StableCount: 3}
+			if err := newTestNotifier(t, tt.provider, srv.URL).Notify(context.Background(), stable); err != nil {
+				t.Fatalf("Notify failed: %v", err)
+			}
+
+			if got := fake.requests[len(fake.requests)-1]; got != tt.wantRequest {
+				t.Errorf("last request = %q, want %q", got, tt.wantRequest)
+			}
+			if len(fake.comments) != 2 {
+				t.Fatalf("got %d comments, want 2", len(fake.comments))
+			}
+			if body := fake.comments[1].Body; !strings.HasPrefix(body, Marker+"\n## Flakehunt: all tests stable") {
+				t.Errorf("updated body = %q", body)
+			}
+		})
+	}
+}
+
+func TestNotifySkipsCommentWithoutFindings(t *testing.T) {
+	fake, srv := newFakeServer(t, "github")
+
+	stable := &model.Report{Tool: "jest", RunsExecuted: 10, StableCount: 3}
+	if err := newTestNotifier(t, "github", srv.URL).Notify(context.Background(), stable); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(fake.comments) != 0 {
+		t.Errorf("got %d comments, want none for a stable session", len(fake.comments))
+	}
+}
+
+func TestNotifyAPIError(t *testing.T) {
+	fake, srv := newFakeServer(t, "github")
+	fake.fail = true
+
+	err := newTestNotifier(t, "github", srv.URL).Notify(context.Background(), flakyReport())
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	for _, want := range []string{"failed to list pull request comments", "401 Unauthorized", "Bad credentials"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	valid := Config{Provider: "github", Repo: "acme/shop", Number: 42, Token: "secret"}
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr string
+	}{
+		{"unknown provider", func(cfg *Config) { cfg.Provider = "bitbucket" }, `unknown notify provider "bitbucket"`},
+		{"no repo", func(cfg *Config) { cfg.Repo = "" }, "set notify.repo"},
+		{"no pull request", func(cfg *Config) { cfg.Number = 0 }, "set notify.pr"},
+		{"no token", func(cfg *Config) { cfg.Token = "" }, "no github API token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+			_, err := New(cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("New() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	env := map[string]string{
+		"GITHUB_API_URL":       "https://github.example.com/api/v3",
+		"GITHUB_REPOSITORY":    "acme/shop",
+		"GITHUB_REF":           "refs/pull/42/merge",
+		"GITHUB_SERVER_URL":    "https://github.example.com",
+		"GITHUB_RUN_ID":        "9001",
+		"CI_API_V4_URL":        "https://gitlab.example.com/api/v4",
+		"CI_PROJECT_ID":        "17",
+		"CI_MERGE_REQUEST_IID": "5",
+		"CI_JOB_URL":           "https://gitlab.example.com/acme/shop/-/jobs/3",
+	}
+	getenv := func(key string) string { return env[key] }
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want Config
+	}{
+		{
+			name: "github",
+			cfg:  Config{Provider: "github"},
+			want: Config{Provider: "github", BaseURL: "https://github.example.com/api/v3", Repo: "acme/shop", Number: 42,
+				ArtifactsURL: "https://github.example.com/acme/shop/actions/runs/9001"},
+		},
+		{
+			name: "gitlab",
+			cfg:  Config{Provider: "gitlab"},
+			want: Config{Provider: "gitlab", BaseURL: "https://gitlab.example.com/api/v4", Repo: "17", Number: 5,
+				ArtifactsURL: "https://gitlab.example.com/acme/shop/-/jobs/3/artifacts/browse"},
+		},
+		{
+			name: "configured values win",
+			cfg:  Config{Provider: "github", Repo: "acme/other", Number: 7},
+			want: Config{Provider: "github", BaseURL: "https://github.example.com/api/v3", Repo: "acme/other", Number: 7,
+				ArtifactsURL: "https://github.example.com/acme/shop/actions/runs/9001"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromEnv(tt.cfg, getenv); got != tt.want {
+				t.Errorf("FromEnv() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// A push build has no pull request
+	got := FromEnv(Config{Provider: "github"}, func(key string) string {
+		if key == "GITHUB_REF" {
+			return "refs/heads/main"
+		}
+		return env[key]
+	})
+	if got.Number != 0 {
+		t.Errorf("Number = %d for a push build, want 0", got.Number)
+	}
+}
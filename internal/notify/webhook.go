@@ -0,0 +1,244 @@
+package notify
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// WebhookConfig configures a chat webhook.
+type WebhookConfig struct {
+	URL string
+	// Format is the payload format: slack, teams or json.
+	Format string
+	// When is the condition for sending: new-flakes, flakes or always.
+	When string
+	// Template is a text/template for the message text, executed with a
+	// Summary; DefaultWebhookTemplate if empty.
+	Template string
+
+	// Client sends requests; http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// DefaultWebhookTemplate is the message template of webhooks without one.
+const DefaultWebhookTemplate = `Flakehunt: {{.FlakyCount}} flaky ({{.NewFlakyCount}} new), {{.FailingCount}} failing, {{.StableCount}} stable in {{.Runs}} runs of {{.Target}}
+{{- range .TopFlakes}}
+• {{if .New}}[new] {{end}}{{.TestID}}: {{percent .FlakeRate}} ({{.FailCount}}/{{.Runs}} failed){{with .Signature}}, {{.}}{{end}}{{with .Owners}} [{{join . ", "}}]{{end}}
+{{- end}}
+{{- if .MoreFlakes}}
+…and {{.MoreFlakes}} more
+{{- end}}
+{{- if .Owners}}
+Wasted time by owner:
+{{- range .Owners}}
+• {{or .Owner "Unowned"}}: {{.FlakyCount}} flaky, {{.FailingCount}} failing, {{duration .WastedTime}}
+{{- end}}
+{{- end}}`
+
+// webhookFuncs are the functions available to message templates.
+var webhookFuncs = template.FuncMap{
+	"percent":  func(rate float64) string { return fmt.Sprintf("%.1f%%", rate*100) },
+	"duration": func(d time.Duration) string { return d.Round(time.Second).String() },
+	"join":     strings.Join,
+}
+
+// webhookPayloads builds the request body of each payload format.
+var webhookPayloads = map[string]func(text string, summary *Summary) any{
+	"slack": slackPayload,
+	"teams": teamsPayload,
+	"json":  jsonPayload,
+}
+
+// Summary is the session summary sent to webhooks and available to message
+// templates.
+type Summary struct {
+	Tool          string         `json:"tool"`
+	Target        string         `json:"target"`
+	Runs          int            `json:"runs"`
+	FlakyCount    int            `json:"flakyCount"`
+	NewFlakyCount int            `json:"newFlakyCount"`
+	FailingCount  int            `json:"failingCount"`
+	StableCount   int            `json:"stableCount"`
+	TopFlakes     []FlakeSummary `json:"topFlakes"`
+	// MoreFlakes is the number of flaky tests beyond TopFlakes.
+	MoreFlakes int `json:"moreFlakes,omitempty"`
+	// Owners are the flaky and failing tests of each CODEOWNERS owner.
+	Owners []model.OwnerSummary `json:"owners,omitempty"`
+}
+
+// FlakeSummary is a flaky test in a Summary.
+type FlakeSummary struct {
+	TestID    string  `json:"testId"`
+	FlakeRate float64 `json:"flakeRate"`
+	FailCount int     `json:"failCount"`
+	Runs      int     `json:"runs"`
+	// Signature is the signature of the most common failure, if any.
+	Signature model.FailureSignature `json:"signature,omitempty"`
+	// New is true if no earlier session found the test flaky.
+	New bool `json:"new"`
+	// Owners are the owners of the test in CODEOWNERS.
+	Owners []string `json:"owners,omitempty"`
+}
+
+// Summarize returns the summary of report. Flakes missing from known are
+// marked new; a nil known marks none. Test IDs are relative to the project
+// root, since the paths of the machine that ran the session mean little in
+// a chat message.
+func Summarize(report *model.Report, known *KnownFlakes) *Summary {
+	summary := &Summary{
+		Tool:         report.Tool,
+		Target:       report.Target,
+		Runs:         report.RunsExecuted,
+		FlakyCount:   report.FlakyCount,
+		FailingCount: report.DetFailCount,
+		StableCount:  report.StableCount,
+		TopFlakes:    []FlakeSummary{},
+		MoreFlakes:   max(report.FlakyCount-len(report.TopFlakes), 0),
+	}
+	for _, owner := range report.Owners {
+		testIDs := make([]string, len(owner.TestIDs))
+		for i, id := range owner.TestIDs {
+			testIDs[i] = model.RelativeTestID(id, report.ProjectRoot)
+		}
+		owner.TestIDs = testIDs
+		summary.Owners = append(summary.Owners, owner)
+	}
+	for _, test := range report.Tests {
+		if test.Classification == model.ClassificationFlaky && known != nil && !known.IsKnown(test.TestID, report.ProjectRoot) {
+			summary.NewFlakyCount++
+		}
+	}
+	for _, test := range report.TopFlakes {
+		flake := FlakeSummary{
+			TestID:    model.RelativeTestID(test.TestID, report.ProjectRoot),
+			FlakeRate: test.FlakeRate,
+			FailCount: test.FailCount,
+			Runs:      test.TotalRuns,
+			New:       known != nil && !known.IsKnown(test.TestID, report.ProjectRoot),
+			Owners:    test.Owners,
+		}
+		if len(test.FailureClusters) > 0 {
+			flake.Signature = test.FailureClusters[0].Signature
+		}
+		summary.TopFlakes = append(summary.TopFlakes, flake)
+	}
+	return summary
+}
+
+// Webhook posts session summaries to a chat webhook.
+type Webhook struct {
+	cfg      WebhookConfig
+	template *template.Template
+	api      *apiClient
+}
+
+// NewWebhook returns the webhook for cfg. Format defaults to json and When
+// to new-flakes.
+func NewWebhook(cfg WebhookConfig) (*Webhook, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("webhook URL is required")
+	}
+	if cfg.Format == "" {
+		cfg.Format = "json"
+	}
+	if _, ok := webhookPayloads[cfg.Format]; !ok {
+		return nil, fmt.Errorf("unknown webhook format %q. Supported formats: slack, teams, json", cfg.Format)
+	}
+	switch cfg.When {
+	case "":
+		cfg.When = "new-flakes"
+	case "new-flakes", "flakes", "always":
+	default:
+		return nil, fmt.Errorf("unknown webhook condition %q. Supported conditions: new-flakes, flakes, always", cfg.When)
+	}
+	tmpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(cmp.Or(cfg.Template, DefaultWebhookTemplate))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook template: %w", err)
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &Webhook{
+		cfg:      cfg,
+		template: tmpl,
+		api:      &apiClient{client: cfg.Client, service: "webhook"},
+	}, nil
+}
+
+// ShouldSend reports whether summary meets the webhook's condition.
+func (w *Webhook) ShouldSend(summary *Summary) bool {
+	switch w.cfg.When {
+	case "always":
+		return true
+	case "flakes":
+		return summary.FlakyCount > 0
+	default:
+		return summary.NewFlakyCount > 0
+	}
+}
+
+// Send posts summary to the webhook if it meets the webhook's condition.
+func (w *Webhook) Send(ctx context.Context, summary *Summary) error {
+	if !w.ShouldSend(summary) {
+		return nil
+	}
+	text, err := w.Render(summary)
+	if err != nil {
+		return err
+	}
+	if err := w.api.do(ctx, http.MethodPost, w.cfg.URL, webhookPayloads[w.cfg.Format](text, summary), nil); err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	return nil
+}
+
+// Render returns the message text for summary.
+func (w *Webhook) Render(summary *Summary) (string, error) {
+	var sb strings.Builder
+	if err := w.template.Execute(&sb, summary); err != nil {
+		return "", fmt.Errorf("failed to render webhook template: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// slackPayload returns a Slack incoming webhook message. Mattermost and
+// Rocket.Chat accept the same payload.
+func slackPayload(text string, _ *Summary) any {
+	return map[string]string{"text": text}
+}
+
+// teamsPayload returns a Microsoft Teams workflow message with the text in
+// an Adaptive Card.
+func teamsPayload(text string, _ *Summary) any {
+	return map[string]any{
+		"type": "message",
+		"attachments": []any{map[string]any{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body": []any{map[string]any{
+					"type": "TextBlock",
+					"text": text,
+					"wrap": true,
+				}},
+			},
+		}},
+	}
+}
+
+// jsonPayload returns the summary along with the message text.
+func jsonPayload(text string, summary *Summary) any {
+	return struct {
+		Text string `json:"text"`
+		*Summary
+	}{text, summary}
+}
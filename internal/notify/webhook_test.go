@@ -0,0 +1,248 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// webhookServer records the bodies of the requests it receives.
+func webhookServer(t *testing.T) (*[]map[string]any, *httptest.Server) {
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", got)
+		}
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("failed to decode webhook body %s: %v", data, err)
+		}
+		bodies = append(bodies, body)
+	}))
+	t.Cleanup(srv.Close)
+	return &bodies, srv
+}
+
+func knownFlakes(testIDs ...string) *KnownFlakes {
+	known := NewKnownFlakes()
+	for _, id := range testIDs {
+		known.Tests[id] = KnownFlake{}
+	}
+	return known
+}
+
+func TestSummarize(t *testing.T) {
+	rpt := flakyReport()
+	rpt.FlakyCount = 3
+	for _, id := range []string{"src/b.test.js::B", "src/c.test.js::C"} {
+		rpt.Tests = append(rpt.Tests, model.AggregatedTest{TestID: id, Classification: model.ClassificationFlaky})
+	}
+
+	summary := Summarize(rpt, knownFlakes("src/a.test.js::A works", "src/b.test.js::B"))
+	if summary.NewFlakyCount != 1 {
+		t.Errorf("NewFlakyCount = %d, want 1", summary.NewFlakyCount)
+	}
+	if summary.MoreFlakes != 2 {
+		t.Errorf("MoreFlakes = %d, want 2", summary.MoreFlakes)
+	}
+	want := FlakeSummary{TestID: "src/a.test.js::A works", FlakeRate: 0.3, FailCount: 3, Runs: 10, Signature: model.SignatureTimeout}
+	if len(summary.TopFlakes) != 1 || !reflect.DeepEqual(summary.TopFlakes[0], want) {
+		t.Errorf("TopFlakes = %+v, want [%+v]", summary.TopFlakes, want)
+	}
+
+	if got := Summarize(rpt, knownFlakes()); !got.TopFlakes[0].New || got.NewFlakyCount != 3 {
+		t.Errorf("with no known flakes: NewFlakyCount = %d, New = %v; want 3, true", got.NewFlakyCount, got.TopFlakes[0].New)
+	}
+	if got := Summarize(rpt, nil); got.TopFlakes[0].New || got.NewFlakyCount != 0 {
+		t.Errorf("without state: NewFlakyCount = %d, New = %v; want 0, false", got.NewFlakyCount, got.TopFlakes[0].New)
+	}
+}
+
+func TestSummarizeRelativeTestIDs(t *testing.T) {
+	rpt := flakyReport()
+	rpt.ProjectRoot = "/home/ci/shop"
+	rpt.TopFlakes[0].TestID = "/home/ci/shop/src/a.test.js::A works"
+	rpt.Tests = rpt.TopFlakes
+	rpt.Owners = []model.OwnerSummary{{Owner: "@acme/web", FlakyCount: 1, TestIDs: []string{"/home/ci/shop/src/a.test.js::A works"}}}
+
+	summary := Summarize(rpt, knownFlakes("src/a.test.js::A works"))
+	if got := summary.TopFlakes[0].TestID; got != "src/a.test.js::A works" {
+		t.Errorf("TopFlakes[0].TestID = %q, want the project-relative ID", got)
+	}
+	if got := summary.Owners[0].TestIDs[0]; got != "src/a.test.js::A works" {
+		t.Errorf("Owners[0].TestIDs[0] = %q, want the project-relative ID", got)
+	}
+	if summary.TopFlakes[0].New {
+		t.Error("known flake marked new")
+	}
+	if got := rpt.Owners[0].TestIDs[0]; got != "/home/ci/shop/src/a.test.js::A works" {
+		t.Errorf("Summarize modified the report's owners: %q", got)
+	}
+}
+
+func TestWebhookPayloads(t *testing.T) {
+	summary := Summarize(flakyReport(), knownFlakes())
+	wantText := "Flakehunt: 1 flaky (1 new), 0 failing, 0 stable in 10 runs of npx jest\n" +
+		"• [new] src/a.test.js::A works: 30.0% (3/10 failed), TIMEOUT"
+
+	tests := []struct {
+		format string
+		text   func(body map[string]any) any
+	}{
+		{"slack", func(body map[string]any) any { return body["text"] }},
+		{"teams", func(body map[string]any) any {
+			card := body["attachments"].([]any)[0].(map[string]any)["content"].(map[string]any)
+			return card["body"].([]any)[0].(map[string]any)["text"]
+		}},
+		{"json", func(body map[string]any) any { return body["text"] }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			bodies, srv := webhookServer(t)
+			webhook, err := NewWebhook(WebhookConfig{URL: srv.URL, Format: tt.format})
+			if err != nil {
+				t.Fatalf("NewWebhook failed: %v", err)
+			}
+			if err := webhook.Send(context.Background(), summary); err != nil {
+				t.Fatalf("Send failed: %v", err)
+			}
+			if len(*bodies) != 1 {
+				t.Fatalf("got %d requests, want 1", len(*bodies))
+			}
+			if got := tt.text((*bodies)[0]); got != wantText {
+				t.Errorf("text = %q, want %q", got, wantText)
+			}
+		})
+	}
+
+	// The generic payload carries the summary fields
+	bodies, srv := webhookServer(t)
+	webhook, _ := NewWebhook(WebhookConfig{URL: srv.URL})
+	if err := webhook.Send(context.Background(), summary); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	body := (*bodies)[0]
+	if body["newFlakyCount"] != 1.0 || body["topFlakes"].([]any)[0].(map[string]any)["new"] != true {
+		t.Errorf("json payload = %v, want newFlakyCount 1 and a new top flake", body)
+	}
+}
+
+func TestWebhookConditions(t *testing.T) {
+	newFlake := Summarize(flakyReport(), knownFlakes())
+	knownFlake := Summarize(flakyReport(), knownFlakes("src/a.test.js::A works"))
+	stable := Summarize(&model.Report{RunsExecuted: 10, StableCount: 3}, knownFlakes())
+
+	tests := []struct {
+		when string
+		want []bool // new flake, known flake, stable
+	}{
+		{"", []bool{true, false, false}},
+		{"new-flakes", []bool{true, false, false}},
+		{"flakes", []bool{true, true, false}},
+		{"always", []bool{true, true, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.when, func(t *testing.T) {
+			webhook, err := NewWebhook(WebhookConfig{URL: "https://example.com/hook", When: tt.when})
+			if err != nil {
+				t.Fatalf("NewWebhook failed: %v", err)
+			}
+			for i, summary := range []*Summary{newFlake, knownFlake, stable} {
+				if got := webhook.ShouldSend(summary); got != tt.want[i] {
+					t.Errorf("ShouldSend(summary %d) = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookTemplate(t *testing.T) {
+	webhook, err := NewWebhook(WebhookConfig{
+		URL:      "https://example.com/hook",
+		Template: `{{.NewFlakyCount}} new in {{.Target}}:{{range .TopFlakes}} {{.TestID}} {{percent .FlakeRate}}{{end}}`,
+	})
+	if err != nil {
+		t.Fatalf("NewWebhook failed: %v", err)
+	}
+	got, err := webhook.Render(Summarize(flakyReport(), knownFlakes()))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if want := "1 new in npx jest: src/a.test.js::A works 30.0%"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestWebhookOwners(t *testing.T) {
+	rpt := flakyReport()
+	rpt.TopFlakes[0].Owners = []string{"@acme/web", "@alice"}
+	rpt.Owners = []model.OwnerSummary{
+		{Owner: "@acme/web", FlakyCount: 1, WastedTime: 90 * time.Second, TestIDs: []string{"src/a.test.js::A works"}},
+		{Owner: "", FailingCount: 2, TestIDs: []string{"src/b.test.js::B", "src/c.test.js::C"}},
+	}
+
+	webhook, err := NewWebhook(WebhookConfig{URL: "https://example.com/hook", When: "always"})
+	if err != nil {
+		t.Fatalf("NewWebhook failed: %v", err)
+	}
+	got, err := webhook.Render(Summarize(rpt, nil))
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	want := "Flakehunt: 1 flaky (0 new), 0 failing, 0 stable in 10 runs of npx jest\n" +
+		"• src/a.test.js::A works: 30.0% (3/10 failed), TIMEOUT [@acme/web, @alice]\n" +
+		"Wasted time by owner:\n" +
+		"• @acme/web: 1 flaky, 0 failing, 1m30s\n" +
+		"• Unowned: 0 flaky, 2 failing, 0s"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestNewWebhookErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     WebhookConfig
+		wantErr string
+	}{
+		{"no url", WebhookConfig{}, "webhook URL is required"},
+		{"unknown format", WebhookConfig{URL: "https://example.com", Format: "discord"}, `unknown webhook format "discord"`},
+		{"unknown condition", WebhookConfig{URL: "https://example.com", When: "sometimes"}, `unknown webhook condition "sometimes"`},
+		{"bad template", WebhookConfig{URL: "https://example.com", Template: "{{.Flaky"}, "failed to parse webhook template"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewWebhook(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewWebhook() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebhookError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	t.Cleanup(srv.Close)
+
+	webhook, _ := NewWebhook(WebhookConfig{URL: srv.URL, Format: "slack", When: "always"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := webhook.Send(ctx, Summarize(flakyReport(), nil))
+	if err == nil || !strings.Contains(err.Error(), "403 Forbidden: invalid_token") {
+		t.Errorf("Send() error = %v, want the status and response", err)
+	}
+}
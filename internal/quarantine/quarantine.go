@@ -0,0 +1,194 @@
+// Package quarantine maintains the list of flaky tests that normal test runs
+// skip until they are fixed.
+package quarantine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// DefaultPath is the quarantine file used unless configured otherwise.
+const DefaultPath = ".flakehunt-quarantine.yaml"
+
+// Version is the current version of the quarantine file format.
+const Version = 1
+
+// dateLayout is the format of dates in the quarantine file.
+const dateLayout = time.DateOnly
+
+// File is the content of a quarantine file.
+type File struct {
+	Version int     `yaml:"version"`
+	Tests   []Entry `yaml:"tests"`
+}
+
+// Entry is a quarantined test.
+type Entry struct {
+	TestID string `yaml:"id"`
+	Reason string `yaml:"reason,omitempty"`
+	Owner  string `yaml:"owner,omitempty"`
+	// Added and Expires are dates in YYYY-MM-DD format. An entry expires at
+	// the start of its expiry date.
+	Added   string `yaml:"added"`
+	Expires string `yaml:"expires"`
+}
+
+// Load reads the quarantine file at path. A missing file yields an empty
+// quarantine.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{Version: Version}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine file %s: %w", path, err)
+	}
+
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse quarantine file %s: %w", path, err)
+	}
+	if f.Version == 0 && len(f.Tests) == 0 {
+		f.Version = Version
+	}
+	if err := f.validate(); err != nil {
+		return nil, fmt.Errorf("invalid quarantine file %s: %w", path, err)
+	}
+	return &f, nil
+}
+
+// validate checks the version and entries of f.
+func (f *File) validate() error {
+	if f.Version != Version {
+		return fmt.Errorf("unsupported version %d, expected %d", f.Version, Version)
+	}
+	seen := make(map[string]bool, len(f.Tests))
+	for i, e := range f.Tests {
+		if e.TestID == "" {
+			return fmt.Errorf("tests[%d].id is required", i)
+		}
+		if seen[e.TestID] {
+			return fmt.Errorf("tests[%d]: %s is listed twice", i, e.TestID)
+		}
+		seen[e.TestID] = true
+		if _, err := time.Parse(dateLayout, e.Added); err != nil {
+			return fmt.Errorf("tests[%d].added must be a YYYY-MM-DD date, got %q", i, e.Added)
+		}
+		if _, err := time.Parse(dateLayout, e.Expires); err != nil {
+			return fmt.Errorf("tests[%d].expires must be a YYYY-MM-DD date, got %q", i, e.Expires)
+		}
+	}
+	return nil
+}
+
+// Save writes f to path, with entries sorted by test ID so that diffs stay
+// small.
+func (f *File) Save(path string) error {
+	sort.Slice(f.Tests, func(i, j int) bool { return f.Tests[i].TestID < f.Tests[j].TestID })
+
+	var buf bytes.Buffer
+	buf.WriteString("# Tests skipped in normal runs until they expire. Maintained by\n# `flakehunt quarantine`; expired entries fail `flakehunt quarantine apply`.\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return fmt.Errorf("failed to encode quarantine file: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write quarantine file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Add quarantines e, replacing the entry of the same test if there is one.
+// It reports whether the test was newly quarantined.
+func (f *File) Add(e Entry) bool {
+	for i := range f.Tests {
+		if f.Tests[i].TestID == e.TestID {
+			f.Tests[i] = e
+			return false
+		}
+	}
+	f.Tests = append(f.Tests, e)
+	return true
+}
+
+// Remove releases the tests with the given IDs and returns how many were
+// quarantined.
+func (f *File) Remove(testIDs ...string) int {
+	remove := make(map[string]bool, len(testIDs))
+	for _, id := range testIDs {
+		remove[id] = true
+	}
+	kept := f.Tests[:0]
+	for _, e := range f.Tests {
+		if !remove[e.TestID] {
+			kept = append(kept, e)
+		}
+	}
+	removed := len(f.Tests) - len(kept)
+	f.Tests = kept
+	return removed
+}
+
+// Expired returns the entries that have expired at now.
+func (f *File) Expired(now time.Time) []Entry {
+	today := now.Format(dateLayout)
+	var expired []Entry
+	for _, e := range f.Tests {
+		// Dates in the fixed layout compare chronologically as strings
+		if e.Expires <= today {
+			expired = append(expired, e)
+		}
+	}
+	return expired
+}
+
+// Expiries returns the expiry date of each quarantined test ID.
+func (f *File) Expiries() map[string]string {
+	expiries := make(map[string]string, len(f.Tests))
+	for _, e := range f.Tests {
+		expiries[e.TestID] = e.Expires
+	}
+	return expiries
+}
+
+// NewEntry returns an entry for testID added at now and expiring after ttl.
+func NewEntry(testID, reason, owner string, now time.Time, ttl time.Duration) Entry {
+	return Entry{
+		TestID:  testID,
+		Reason:  reason,
+		Owner:   owner,
+		Added:   now.Format(dateLayout),
+		Expires: now.Add(ttl).Format(dateLayout),
+	}
+}
+
+// FlakyEntries returns an entry for each flaky test of report, with test
+// files relative to the project root so that the file works on any machine.
+// Suite setups are left out, as they cannot be skipped like tests. Entries
+// without a reason describe the test's flake rate and most common failure.
+func FlakyEntries(report *model.Report, reason, owner string, now time.Time, ttl time.Duration) []Entry {
+	var entries []Entry
+	for _, test := range report.Tests {
+		if test.Classification != model.ClassificationFlaky || test.Suite {
+			continue
+		}
+		testReason := reason
+		if testReason == "" {
+			testReason = fmt.Sprintf("flaky: %.1f%% flake rate", test.FlakeRate*100)
+			if len(test.FailureClusters) > 0 {
+				testReason += ", mostly " + string(test.FailureClusters[0].Signature)
+			}
+		}
+		entries = append(entries, NewEntry(model.RelativeTestID(test.TestID, report.ProjectRoot), testReason, owner, now, ttl))
+	}
+	return entries
+}
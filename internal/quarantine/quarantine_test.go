@@ -0,0 +1,159 @@
+package quarantine
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+func TestLoadSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultPath)
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load of a missing file failed: %v", err)
+	}
+	if f.Version != Version || len(f.Tests) != 0 {
+		t.Fatalf("Load of a missing file = %+v, want an empty version %d file", f, Version)
+	}
+
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	f.Add(NewEntry("src/b.test.js::B", "", "", now, 24*time.Hour))
+	f.Add(NewEntry("src/a.test.js::A works", "races the server", "web-team", now, 30*24*time.Hour))
+	if err := f.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	want := Entry{TestID: "src/a.test.js::A works", Reason: "races the server", Owner: "web-team", Added: "2026-10-01", Expires: "2026-10-31"}
+	if len(loaded.Tests) != 2 || loaded.Tests[0] != want {
+		t.Errorf("Tests = %+v, want %+v first", loaded.Tests, want)
+	}
+	if got := loaded.Tests[1].TestID; got != "src/b.test.js::B" {
+		t.Errorf("Tests[1].TestID = %q, want sorted IDs", got)
+	}
+	if got := loaded.Expiries()["src/b.test.js::B"]; got != "2026-10-02" {
+		t.Errorf("Expiries() of src/b.test.js::B = %q, want 2026-10-02", got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unsupported version",
+			content: "version: 2\ntests: []\n",
+			wantErr: "unsupported version 2",
+		},
+		{
+			name:    "missing id",
+			content: "version: 1\ntests:\n  - added: 2026-10-01\n    expires: 2026-10-31\n",
+			wantErr: "tests[0].id is required",
+		},
+		{
+			name:    "duplicate id",
+			content: "version: 1\ntests:\n  - {id: a, added: 2026-10-01, expires: 2026-10-31}\n  - {id: a, added: 2026-10-02, expires: 2026-11-01}\n",
+			wantErr: "tests[1]: a is listed twice",
+		},
+		{
+			name:    "invalid expiry",
+			content: "version: 1\ntests:\n  - {id: a, added: 2026-10-01, expires: next month}\n",
+			wantErr: "tests[0].expires must be a YYYY-MM-DD date",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultPath)
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("failed to write quarantine file: %v", err)
+			}
+			_, err := Load(path)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error %q does not contain %q", err.Error(), tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestAddRemoveExpired(t *testing.T) {
+	day := 24 * time.Hour
+	added := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	f := &File{Version: Version}
+
+	if !f.Add(NewEntry("a", "", "", added, day)) || !f.Add(NewEntry("b", "", "", added, 10*day)) {
+		t.Fatal("Add of new tests reported false")
+	}
+
+	// Expires at the start of the expiry date
+	if got := f.Expired(added); len(got) != 0 {
+		t.Errorf("Expired(added) = %v, want none", got)
+	}
+	if got := f.Expired(added.Add(day)); len(got) != 1 || got[0].TestID != "a" {
+		t.Errorf("Expired(next day) = %v, want a", got)
+	}
+
+	// Adding again renews
+	if f.Add(NewEntry("a", "", "", added.Add(day), 10*day)) {
+		t.Error("Add of a quarantined test reported true")
+	}
+	if got := f.Expired(added.Add(day)); len(got) != 0 {
+		t.Errorf("Expired() after renewal = %v, want none", got)
+	}
+
+	if removed := f.Remove("a", "missing"); removed != 1 {
+		t.Errorf("Remove() = %d, want 1", removed)
+	}
+	if len(f.Tests) != 1 || f.Tests[0].TestID != "b" {
+		t.Errorf("Tests after Remove = %+v, want only b", f.Tests)
+	}
+}
+
+func TestFlakyEntries(t *testing.T) {
+	rpt := &model.Report{
+		ProjectRoot: "/repo",
+		Tests: []model.AggregatedTest{
+			{
+				TestID:          "/repo/src/a.test.js::A works",
+				Classification:  model.ClassificationFlaky,
+				FlakeRate:       0.25,
+				FailureClusters: []model.FailureCluster{{Signature: model.SignatureTimeout}},
+			},
+			{TestID: "/repo/src/b.test.js::B", Classification: model.ClassificationStable},
+			{TestID: "cypress/e2e/login.cy.js::logs in", Classification: model.ClassificationFlaky, FlakeRate: 0.1},
+			{TestID: "/repo/src/c.test.js::<suite setup>", Classification: model.ClassificationFlaky, FlakeRate: 0.2, Suite: true},
+		},
+	}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := FlakyEntries(rpt, "", "qa", now, 7*24*time.Hour)
+	want := []Entry{
+		{TestID: "src/a.test.js::A works", Reason: "flaky: 25.0% flake rate, mostly TIMEOUT", Owner: "qa", Added: "2026-10-01", Expires: "2026-10-08"},
+		{TestID: "cypress/e2e/login.cy.js::logs in", Reason: "flaky: 10.0% flake rate", Owner: "qa", Added: "2026-10-01", Expires: "2026-10-08"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entries[%d] = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+
+	if got := FlakyEntries(rpt, "tracked in #12", "", now, time.Hour)[0].Reason; got != "tracked in #12" {
+		t.Errorf("Reason = %q, want the given reason", got)
+	}
+}
@@ -0,0 +1,70 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// maxCommentMessage is the length at which failures are truncated in comments.
+const maxCommentMessage = 100
+
+// RenderComment renders a short Markdown summary for a pull request comment:
+// the test counts, a table of the top flakes and the wasted time of each
+// owner, with a link to artifactsURL if set.
+func RenderComment(report *model.Report, artifactsURL string) string {
+	if report == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	switch {
+	case report.FlakyCount > 0:
+		sb.WriteString(fmt.Sprintf("## Flakehunt: %d flaky %s\n\n", report.FlakyCount, pluralize(report.FlakyCount, "test", "tests")))
+	case report.DetFailCount > 0:
+		sb.WriteString("## Flakehunt: no flaky tests\n\n")
+	default:
+		sb.WriteString("## Flakehunt: all tests stable\n\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("%d %s of `%s` with %s: %d flaky, %d failing, %d stable.\n\n",
+		report.RunsExecuted, pluralize(report.RunsExecuted, "run", "runs"),
+		strings.ReplaceAll(report.Target, "`", "'"), report.Tool,
+		report.FlakyCount, report.DetFailCount, report.StableCount))
+
+	if len(report.TopFlakes) > 0 {
+		sb.WriteString("| Test | Flake Rate | Failed | Wasted Time | Most Common Failure |\n")
+		sb.WriteString("|------|------------|--------|-------------|---------------------|\n")
+		for _, flake := range report.TopFlakes {
+			failure := "-"
+			if len(flake.FailureClusters) > 0 {
+				c := flake.FailureClusters[0]
+				failure = fmt.Sprintf("[%s] %s", c.Signature, escapeMarkdown(truncateForTerminal(formatClusterMessage(c), maxCommentMessage)))
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %.1f%% | %s | %s | %s |\n",
+				escapeMarkdown(flake.TestID),
+				flake.FlakeRate*100,
+				formatFailCount(flake),
+				formatDuration(flake.WastedTime),
+				failure,
+			))
+		}
+		if report.FlakyCount > len(report.TopFlakes) {
+			sb.WriteString(fmt.Sprintf("\n%d more flaky tests are listed in the full report.\n", report.FlakyCount-len(report.TopFlakes)))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(report.Owners) > 0 {
+		sb.WriteString("### By Owner\n\n")
+		writeMarkdownOwnerTable(&sb, report)
+	}
+
+	if artifactsURL != "" {
+		sb.WriteString(fmt.Sprintf("[Full report and run artifacts](%s)\n", artifactsURL))
+	}
+
+	return sb.String()
+}
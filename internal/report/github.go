@@ -0,0 +1,166 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// maxGitHubAnnotations is the number of annotations of each level emitted per
+// session. GitHub shows at most 10 warnings and 10 errors per step.
+const maxGitHubAnnotations = 10
+
+// InGitHubActions reports whether flakehunt is running in a GitHub Actions workflow.
+func InGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// WriteGitHubActions reports to the enclosing GitHub Actions workflow: it
+// writes annotations for flaky and failing tests to w, which the runner reads
+// from the step's stdout, and appends a summary to $GITHUB_STEP_SUMMARY if set.
+func WriteGitHubActions(w io.Writer, report *model.Report) error {
+	if report == nil {
+		return fmt.Errorf("report is required")
+	}
+
+	RenderGitHubAnnotations(w, report)
+
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open step summary %s: %w", path, err)
+	}
+	if _, err := f.WriteString(RenderGitHubSummary(report)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write step summary %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write step summary %s: %w", path, err)
+	}
+	return nil
+}
+
+// RenderGitHubAnnotations writes workflow commands that annotate the test
+// files: a warning for each flaky test and an error for each deterministic
+// failure, at the failing line when the stack trace points into the file.
+func RenderGitHubAnnotations(w io.Writer, report *model.Report) {
+	levels := []struct {
+		command        string
+		classification model.Classification
+	}{
+		{"error", model.ClassificationDeterministicFail},
+		{"warning", model.ClassificationFlaky},
+	}
+	workspace := os.Getenv("GITHUB_WORKSPACE")
+	for _, level := range levels {
+		var tests []model.AggregatedTest
+		for _, test := range report.Tests {
+			if test.Classification == level.classification {
+				tests = append(tests, test)
+			}
+		}
+		// The most wasteful tests are annotated first, like the top flakes
+		sort.SliceStable(tests, func(i, j int) bool {
+			return tests[i].WastedTime > tests[j].WastedTime
+		})
+
+		for i, test := range tests {
+			if i == maxGitHubAnnotations {
+				fmt.Fprintf(w, "::notice title=flakehunt::%s\n", escapeWorkflowData(fmt.Sprintf(
+					"%d more %s tests are not annotated; see the flakehunt report.", len(tests)-i, level.classification)))
+				break
+			}
+			fmt.Fprintf(w, "::%s %s::%s\n", level.command, workflowProperties(test, report.ProjectRoot, workspace), escapeWorkflowData(annotationMessage(test)))
+		}
+	}
+}
+
+// RenderGitHubSummary renders a compact Markdown summary for the workflow run
+// page: the summary, top flakes, suite setup failures, co-failing tests and
+// infrastructure errors sections of the Markdown report. Links to run
+// artifacts are left out, as the summary is shown apart from them.
+func RenderGitHubSummary(report *model.Report) string {
+	if report == nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Flakehunt Report\n\n")
+	writeMarkdownSummary(&sb, report)
+	writeMarkdownTopFlakes(&sb, report, false)
+	writeMarkdownSuiteFailures(&sb, report)
+	writeMarkdownCoFailures(&sb, report)
+	writeMarkdownInfraErrors(&sb, report)
+	return sb.String()
+}
+
+// workflowProperties returns the file, line and title properties of a test's
+// annotation. GitHub resolves the file against workspace, the checkout
+// directory, which may be a parent of the project root.
+func workflowProperties(test model.AggregatedTest, projectRoot, workspace string) string {
+	file, loc := testFileLocation(test, projectRoot)
+	if workspace != "" {
+		if !filepath.IsAbs(file) && projectRoot != "" {
+			file = filepath.Join(projectRoot, filepath.FromSlash(file))
+		}
+		file = model.RelativeTestID(file, workspace)
+	}
+	props := []string{"file=" + escapeWorkflowProperty(file)}
+	if loc != nil && loc.Line > 0 {
+		props = append(props, fmt.Sprintf("line=%d", loc.Line))
+		if loc.Column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", loc.Column))
+		}
+	}
+
+	title := "Failing test: "
+	if test.Classification == model.ClassificationFlaky {
+		title = "Flaky test: "
+	}
+	_, name, ok := strings.Cut(test.TestID, "::")
+	if !ok {
+		name = test.TestID
+	}
+	props = append(props, "title="+escapeWorkflowProperty(title+name))
+	return strings.Join(props, ",")
+}
+
+// annotationMessage describes how a test failed and its failure modes.
+func annotationMessage(test model.AggregatedTest) string {
+	var sb strings.Builder
+	if test.Classification == model.ClassificationFlaky {
+		sb.WriteString(fmt.Sprintf("%s (flake rate %.1f%%)", formatFailCount(test), test.FlakeRate*100))
+	} else {
+		sb.WriteString(fmt.Sprintf("failed in all %d runs", test.TotalRuns))
+	}
+	for _, c := range test.FailureClusters {
+		sb.WriteString(fmt.Sprintf("\n[%s] %d %s (%s)", c.Signature, c.Count, pluralize(c.Count, "failure", "failures"), formatClusterRuns(c)))
+		if c.Location != nil {
+			sb.WriteString(fmt.Sprintf(" at %s", c.Location))
+		}
+		sb.WriteString(": " + clusterHeadline(c))
+	}
+	return sb.String()
+}
+
+// escapeWorkflowData escapes the message of a workflow command.
+func escapeWorkflowData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+// escapeWorkflowProperty escapes a property value of a workflow command.
+func escapeWorkflowProperty(s string) string {
+	s = escapeWorkflowData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
@@ -0,0 +1,247 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// htmlTemplateText is the HTML report template. Styles and scripts are
+// inlined so that the report works offline as a single file.
+//
+//go:embed html.tmpl
+var htmlTemplateText string
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"duration":    formatDuration,
+	"percent":     func(f float64) string { return fmt.Sprintf("%.1f%%", f*100) },
+	"runDir":      runDir,
+	"clusterRuns": formatClusterRuns,
+	"message":     formatClusterMessage,
+	"snippet":     formatSnippet,
+	"patterns":    formatTemporalPatterns,
+	"runList":     formatRunIndices,
+	"infraSrc":    formatInfraErrorSource,
+	"owner":       formatOwner,
+	"join":        strings.Join,
+}).Parse(htmlTemplateText))
+
+// Dimensions of the per-test duration chart, in pixels.
+const (
+	chartBarWidth = 4
+	chartHeight   = 24
+)
+
+// htmlReport is the data rendered by the HTML template.
+type htmlReport struct {
+	*model.Report
+	Timeout    string
+	Runs       []int
+	Tests      []htmlTest
+	Failing    []htmlTest // tests with failure modes
+	Signatures []signatureCount
+}
+
+// htmlTest is a row of the test table and the heatmap.
+type htmlTest struct {
+	model.AggregatedTest
+	Name  string // TestID, or the file of a suite setup
+	Cells []htmlCell
+	Chart htmlChart
+}
+
+// htmlCell is the outcome of a test in one run of the heatmap.
+type htmlCell struct {
+	Run     int
+	Class   string // pass, fail, retry, skip or none
+	Title   string
+	LogPath string
+}
+
+// htmlChart is a bar chart of a test's durations across runs.
+type htmlChart struct {
+	Width  int
+	Height int
+	Bars   []htmlBar
+}
+
+// htmlBar is a single run in a duration chart.
+type htmlBar struct {
+	X, Y, Width, Height int
+	Class               string
+	Title               string
+}
+
+// WriteHTML writes the report as a self-contained HTML page to the specified
+// output directory. The file is written to <outDir>/report.html
+func WriteHTML(outDir string, report *model.Report) error {
+	if report == nil {
+		return fmt.Errorf("report is required")
+	}
+
+	// Ensure output directory exists
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", outDir, err)
+	}
+
+	path := filepath.Join(outDir, "report.html")
+
+	content, err := RenderHTML(report)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write report to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// RenderHTML renders the report as an HTML page. Links to run artifacts are
+// relative to the session output directory, where the page is written.
+func RenderHTML(report *model.Report) (string, error) {
+	if report == nil {
+		return "", fmt.Errorf("report is required")
+	}
+
+	data := htmlReport{
+		Report:     report,
+		Signatures: sortedSignatures(report.SignatureSummary),
+	}
+	if report.TestTimeout > 0 {
+		data.Timeout = formatTestTimeout(report)
+	}
+	for i := 1; i <= report.RunsExecuted; i++ {
+		data.Runs = append(data.Runs, i)
+	}
+
+	tests := make([]model.AggregatedTest, 0, len(report.Tests))
+	for _, test := range report.Tests {
+		// Passing suite setups are implied by their tests
+		if test.Suite && test.Classification == model.ClassificationStable {
+			continue
+		}
+		tests = append(tests, test)
+	}
+	// Flakiest tests first, so that the top of the heatmap is the most useful
+	sort.SliceStable(tests, func(i, j int) bool {
+		orderI := classificationOrder(tests[i].Classification)
+		orderJ := classificationOrder(tests[j].Classification)
+		if orderI != orderJ {
+			return orderI < orderJ
+		}
+		if tests[i].FlakeRate != tests[j].FlakeRate {
+			return tests[i].FlakeRate > tests[j].FlakeRate
+		}
+		return tests[i].TestID < tests[j].TestID
+	})
+	for _, test := range tests {
+		name := test.TestID
+		if test.Suite {
+			name = suiteFile(test.TestID) + " (suite setup)"
+		}
+		data.Tests = append(data.Tests, htmlTest{
+			AggregatedTest: test,
+			Name:           name,
+			Cells:          heatmapCells(test, report.RunsExecuted),
+			Chart:          durationChart(test),
+		})
+	}
+	data.Failing = failingTests(data.Tests)
+
+	var sb strings.Builder
+	if err := htmlTemplate.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("failed to render HTML report: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// heatmapCells returns the outcome of a test in each of numRuns runs.
+func heatmapCells(test model.AggregatedTest, numRuns int) []htmlCell {
+	samples := make(map[int]model.RunSample, len(test.Runs))
+	for _, run := range test.Runs {
+		samples[run.RunIndex] = run
+	}
+	logs := make(map[int]string, len(test.FailureEvidence))
+	for _, ev := range test.FailureEvidence {
+		if ev.LogPath != "" {
+			logs[ev.RunIndex] = ev.LogPath
+		}
+	}
+
+	cells := make([]htmlCell, numRuns)
+	for i := range cells {
+		run := i + 1
+		cell := htmlCell{Run: run, Class: "none", Title: fmt.Sprintf("Run %d: not run", run), LogPath: logs[run]}
+		if sample, ok := samples[run]; ok {
+			cell.Class = string(sample.Outcome)
+			cell.Title = fmt.Sprintf("Run %d: %s (%s)", run, sample.Outcome, formatDuration(sample.Duration))
+			if sample.FlakyInRun && sample.Outcome == model.OutcomePass {
+				cell.Class = "retry"
+				cell.Title = fmt.Sprintf("Run %d: passed on retry after %d attempts (%s)", run, sample.Attempts, formatDuration(sample.Duration))
+			}
+		}
+		cells[i] = cell
+	}
+	return cells
+}
+
+// durationChart returns a bar chart of a test's durations, scaled to the
+// slowest run. Skipped runs are left out.
+func durationChart(test model.AggregatedTest) htmlChart {
+	var longest time.Duration
+	for _, run := range test.Runs {
+		longest = max(longest, run.Duration)
+	}
+
+	chart := htmlChart{Height: chartHeight}
+	for i, run := range test.Runs {
+		chart.Width = (i + 1) * chartBarWidth
+		if run.Outcome == model.OutcomeSkip {
+			continue
+		}
+		height := 1
+		if longest > 0 {
+			height = max(height, int(int64(chartHeight)*int64(run.Duration)/int64(longest)))
+		}
+		class := "pass"
+		if run.Failed() {
+			class = "fail"
+		}
+		chart.Bars = append(chart.Bars, htmlBar{
+			X:      i * chartBarWidth,
+			Y:      chartHeight - height,
+			Width:  chartBarWidth - 1,
+			Height: height,
+			Class:  class,
+			Title:  fmt.Sprintf("Run %d: %s", run.RunIndex, formatDuration(run.Duration)),
+		})
+	}
+	return chart
+}
+
+// runDir returns the artifact directory of a run, relative to the session
+// output directory.
+func runDir(run int) string {
+	return fmt.Sprintf("runs/%03d/", run)
+}
+
+// failingTests returns the tests that have failure modes to show in the
+// HTML report.
+func failingTests(tests []htmlTest) []htmlTest {
+	var result []htmlTest
+	for _, test := range tests {
+		if len(test.FailureClusters) > 0 {
+			result = append(result, test)
+		}
+	}
+	return result
+}
@@ -0,0 +1,248 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// maxIssueTitleLength is the length at which issue titles are truncated.
+const maxIssueTitleLength = 200
+
+// TestFingerprint returns a short stable identifier of a test ID, used to
+// match tickets to tests. The ID is made relative to projectRoot first, so
+// sessions run from different checkouts agree.
+func TestFingerprint(testID, projectRoot string) string {
+	sum := sha256.Sum256([]byte(model.RelativeTestID(testID, projectRoot)))
+	return hex.EncodeToString(sum[:6])
+}
+
+// IssueTitle returns the ticket title for a flaky test. The absolute paths
+// of the machine that ran the session mean little in a ticket, so the test
+// ID is relative to the project root.
+func IssueTitle(report *model.Report, test model.AggregatedTest) string {
+	return truncateForTerminal("Flaky test: "+model.RelativeTestID(test.TestID, report.ProjectRoot), maxIssueTitleLength)
+}
+
+// RenderIssue renders the Markdown body of a ticket for a flaky test: its
+// flake rate, failure modes, failing runs and a command to reproduce it.
+func RenderIssue(report *model.Report, test model.AggregatedTest) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("`%s` is flaky: %s (%.1f%% flake rate) with `%s`.\n\n",
+		strings.ReplaceAll(model.RelativeTestID(test.TestID, report.ProjectRoot), "`", "'"), formatFailCount(test), test.FlakeRate*100, report.Tool))
+
+	sb.WriteString("| Metric | Value |\n")
+	sb.WriteString("|--------|-------|\n")
+	for _, row := range issueMetrics(test) {
+		sb.WriteString(fmt.Sprintf("| %s | %s |\n", row[0], escapeMarkdown(row[1])))
+	}
+	sb.WriteString("\n")
+
+	if len(test.FailureClusters) > 0 {
+		sb.WriteString("## Failure Modes\n\n")
+		for i, c := range test.FailureClusters {
+			sb.WriteString(fmt.Sprintf("%d. [%s] %d %s in %s", i+1, c.Signature, c.Count,
+				pluralize(c.Count, "failure", "failures"), formatClusterRuns(c)))
+			if c.Location != nil {
+				sb.WriteString(fmt.Sprintf(" at `%s`", c.Location))
+			}
+			sb.WriteString("\n")
+			if c.Snippet != nil {
+				sb.WriteString(fmt.Sprintf("   ```%s\n", snippetLanguage(c.Location.File)))
+				for _, line := range formatSnippet(c.Snippet) {
+					sb.WriteString(fmt.Sprintf("   %s\n", line))
+				}
+				sb.WriteString("   ```\n")
+			}
+			sb.WriteString("   ```\n")
+			for _, line := range clusterMessageLines(c) {
+				sb.WriteString(strings.TrimRight("   "+line, " ") + "\n")
+			}
+			sb.WriteString("   ```\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if runs := issueFailingRuns(test); len(runs) > 0 {
+		sb.WriteString("## Failing Runs\n\n")
+		for _, run := range runs {
+			sb.WriteString(fmt.Sprintf("- Run %d: [%s]", run.RunIndex, run.Signature))
+			if run.LogPath != "" {
+				sb.WriteString(fmt.Sprintf(" `%s`", run.LogPath))
+			}
+			if run.Retried {
+				sb.WriteString(" (passed on retry)")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## Reproduce\n\n")
+	sb.WriteString("```sh\n" + reproduceCommand(report, test) + "\n```\n\n")
+
+	sb.WriteString(fmt.Sprintf("Filed by flakehunt. Test fingerprint: `%s`\n", TestFingerprint(test.TestID, report.ProjectRoot)))
+	return sb.String()
+}
+
+// RenderJiraIssue renders the ticket body of RenderIssue in Jira wiki markup.
+func RenderJiraIssue(report *model.Report, test model.AggregatedTest) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("{{%s}} is flaky: %s (%.1f%% flake rate) with {{%s}}.\n\n",
+		jiraEscape(model.RelativeTestID(test.TestID, report.ProjectRoot)), formatFailCount(test), test.FlakeRate*100, report.Tool))
+
+	sb.WriteString("||Metric||Value||\n")
+	for _, row := range issueMetrics(test) {
+		sb.WriteString(fmt.Sprintf("|%s|%s|\n", row[0], jiraEscape(row[1])))
+	}
+	sb.WriteString("\n")
+
+	if len(test.FailureClusters) > 0 {
+		sb.WriteString("h2. Failure Modes\n\n")
+		for _, c := range test.FailureClusters {
+			sb.WriteString(fmt.Sprintf("# \\[%s\\] %d %s in %s", c.Signature, c.Count,
+				pluralize(c.Count, "failure", "failures"), formatClusterRuns(c)))
+			if c.Location != nil {
+				sb.WriteString(fmt.Sprintf(" at {{%s}}", jiraEscape(c.Location.String())))
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+		for _, c := range test.FailureClusters {
+			if c.Snippet != nil {
+				sb.WriteString("{code}\n" + strings.Join(formatSnippet(c.Snippet), "\n") + "\n{code}\n")
+			}
+			sb.WriteString("{noformat}\n" + strings.Join(clusterMessageLines(c), "\n") + "\n{noformat}\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if runs := issueFailingRuns(test); len(runs) > 0 {
+		sb.WriteString("h2. Failing Runs\n\n")
+		for _, run := range runs {
+			sb.WriteString(fmt.Sprintf("* Run %d: \\[%s\\]", run.RunIndex, run.Signature))
+			if run.LogPath != "" {
+				sb.WriteString(fmt.Sprintf(" {{%s}}", jiraEscape(run.LogPath)))
+			}
+			if run.Retried {
+				sb.WriteString(" (passed on retry)")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("h2. Reproduce\n\n")
+	sb.WriteString("{noformat}\n" + reproduceCommand(report, test) + "\n{noformat}\n\n")
+
+	sb.WriteString(fmt.Sprintf("Filed by flakehunt. Test fingerprint: {{%s}}\n", TestFingerprint(test.TestID, report.ProjectRoot)))
+	return sb.String()
+}
+
+// issueMetrics returns the metric rows of a ticket.
+func issueMetrics(test model.AggregatedTest) [][2]string {
+	var rows [][2]string
+	if len(test.Owners) > 0 {
+		rows = append(rows, [2]string{"Owners", strings.Join(test.Owners, ", ")})
+	}
+	rows = append(rows,
+		[2]string{"Flake Rate", fmt.Sprintf("%.1f%%", test.FlakeRate*100)},
+		[2]string{"Runs", fmt.Sprintf("%d passed, %d failed, %d skipped", test.PassCount, test.FailCount, test.SkipCount)},
+	)
+	if test.FlakyInRunCount > 0 {
+		rows = append(rows, [2]string{"Flaky on Retry", fmt.Sprintf("%d of %d retried runs", test.FlakyInRunCount, test.RetriedRuns)})
+	}
+	rows = append(rows,
+		[2]string{"Average Duration", formatDuration(test.AvgDuration)},
+		[2]string{"Wasted Time", formatDuration(test.WastedTime)},
+	)
+	if patterns := formatTemporalPatterns(test.Temporal); patterns != "" {
+		rows = append(rows, [2]string{"Run-Order Pattern", patterns})
+	}
+	return rows
+}
+
+// issueFailingRuns returns the failure evidence of a test, sorted by run.
+func issueFailingRuns(test model.AggregatedTest) []model.FailureEvidence {
+	runs := append([]model.FailureEvidence(nil), test.FailureEvidence...)
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].RunIndex < runs[j].RunIndex })
+	return runs
+}
+
+// reproduceCommand returns the flakehunt command that repeats the session's
+// test command for test alone.
+func reproduceCommand(report *model.Report, test model.AggregatedTest) string {
+	command := scopedCommand(report, test)
+	quoted := make([]string, len(command))
+	for i, arg := range command {
+		quoted[i] = shellQuote(arg)
+	}
+	return fmt.Sprintf("flakehunt --runs %d -- %s", max(report.RunsExecuted, 1), strings.Join(quoted, " "))
+}
+
+// scopedCommand returns the session's test command narrowed to test: Jest
+// runs the test's file and only tests named exactly like it, Cypress runs
+// the test's spec. Filters already in the command are replaced. Commands of
+// other tools are returned unchanged.
+func scopedCommand(report *model.Report, test model.AggregatedTest) []string {
+	file, name, _ := strings.Cut(model.RelativeTestID(test.TestID, report.ProjectRoot), "::")
+	if file == "" {
+		return report.Command
+	}
+
+	switch model.Tool(report.Tool) {
+	case model.ToolJest:
+		command := append(withoutFlag(report.Command, "-t", "--testNamePattern"), file)
+		// Suite setups fail for every test in the file
+		if !test.Suite && name != "" {
+			command = append(command, "-t", "^"+regexp.QuoteMeta(name)+"$")
+		}
+		return command
+	case model.ToolCypress:
+		return append(withoutFlag(report.Command, "-s", "--spec"), "--spec", file)
+	default:
+		return report.Command
+	}
+}
+
+// withoutFlag returns a copy of args without the given flag names and their
+// values, in both "--flag value" and "--flag=value" form.
+func withoutFlag(args []string, names ...string) []string {
+	result := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		name, _, _ := strings.Cut(args[i], "=")
+		if !slices.Contains(names, name) {
+			result = append(result, args[i])
+			continue
+		}
+		if name == args[i] {
+			i++ // skip the value
+		}
+	}
+	return result
+}
+
+// shellSafe matches arguments that need no quoting in a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes arg for a POSIX shell.
+func shellQuote(arg string) string {
+	if shellSafe.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// jiraEscape escapes the characters that Jira wiki markup treats as
+// formatting in inline text.
+func jiraEscape(s string) string {
+	return strings.NewReplacer("{", "\\{", "}", "\\}", "[", "\\[", "]", "\\]", "|", "\\|", "*", "\\*", "_", "\\_").Replace(s)
+}
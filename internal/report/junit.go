@@ -0,0 +1,237 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// JUnit XML elements. Flaky tests pass and carry a <flakyFailure> per failure
+// mode, as Maven Surefire reports tests that passed on rerun; deterministic
+// failures are <failure>s.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name          string              `xml:"name,attr"`
+	ClassName     string              `xml:"classname,attr"`
+	Time          string              `xml:"time,attr"`
+	Properties    []junitProperty     `xml:"properties>property"`
+	Failure       *junitFailure       `xml:"failure"`
+	FlakyFailures []junitFlakyFailure `xml:"flakyFailure"`
+	Skipped       *struct{}           `xml:"skipped"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitFlakyFailure struct {
+	Message    string `xml:"message,attr"`
+	Type       string `xml:"type,attr"`
+	StackTrace string `xml:"stackTrace"`
+}
+
+// maxHeadlineLength is the length at which the first line of a failure
+// message is truncated in machine-readable reports.
+const maxHeadlineLength = 200
+
+// WriteJUnit writes the report as JUnit XML to the specified output directory.
+// The file is written to <outDir>/report.junit.xml
+func WriteJUnit(outDir string, report *model.Report) error {
+	if report == nil {
+		return fmt.Errorf("report is required")
+	}
+
+	// Ensure output directory exists
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", outDir, err)
+	}
+
+	path := filepath.Join(outDir, "report.junit.xml")
+
+	data, err := MarshalJUnit(report)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// MarshalJUnit returns the report as JUnit XML, with one testcase per
+// aggregated test and one testsuite per test file.
+func MarshalJUnit(report *model.Report) ([]byte, error) {
+	if report == nil {
+		return nil, fmt.Errorf("report is required")
+	}
+
+	root := junitTestSuites{Name: "flakehunt"}
+	suiteIndex := make(map[string]int)
+	var suiteTimes []time.Duration
+	var total time.Duration
+	for _, test := range report.Tests {
+		// Passing suite setups are implied by their tests
+		if test.Suite && test.Classification == model.ClassificationStable {
+			continue
+		}
+		// Suites are named after the project-relative test file, not the
+		// absolute path on the machine that ran the session
+		testID := model.RelativeTestID(test.TestID, report.ProjectRoot)
+		file, name, ok := strings.Cut(testID, "::")
+		if !ok {
+			file, name = report.Tool, testID
+		}
+
+		i, ok := suiteIndex[file]
+		if !ok {
+			i = len(root.Suites)
+			suiteIndex[file] = i
+			root.Suites = append(root.Suites, junitTestSuite{Name: file})
+			suiteTimes = append(suiteTimes, 0)
+		}
+		suite := &root.Suites[i]
+
+		testCase := junitTestCase{
+			Name:       name,
+			ClassName:  file,
+			Time:       junitSeconds(test.AvgDuration),
+			Properties: junitProperties(test),
+		}
+		switch {
+		case test.Classification == model.ClassificationDeterministicFail:
+			testCase.Failure = junitFailureOf(test)
+			suite.Failures++
+		case test.Classification == model.ClassificationFlaky:
+			for _, c := range test.FailureClusters {
+				testCase.FlakyFailures = append(testCase.FlakyFailures, junitFlakyFailure{
+					Message:    clusterHeadline(c),
+					Type:       string(c.Signature),
+					StackTrace: junitClusterText(c),
+				})
+			}
+		case test.TotalRuns == 0 && test.SkipCount > 0:
+			testCase.Skipped = &struct{}{}
+			suite.Skipped++
+		}
+
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+		suiteTimes[i] += test.AvgDuration
+		total += test.AvgDuration
+	}
+
+	for i := range root.Suites {
+		suite := &root.Suites[i]
+		suite.Time = junitSeconds(suiteTimes[i])
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Skipped += suite.Skipped
+	}
+	root.Time = junitSeconds(total)
+
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JUnit report: %w", err)
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// junitProperties returns the flake statistics of a test as testcase properties.
+func junitProperties(test model.AggregatedTest) []junitProperty {
+	props := []junitProperty{
+		{"flakehunt.classification", string(test.Classification)},
+		{"flakehunt.flakeRate", fmt.Sprintf("%.4f", test.FlakeRate)},
+		{"flakehunt.runs", fmt.Sprintf("%d", test.TotalRuns+test.SkipCount)},
+		{"flakehunt.passCount", fmt.Sprintf("%d", test.PassCount)},
+		{"flakehunt.failCount", fmt.Sprintf("%d", test.FailCount)},
+		{"flakehunt.skipCount", fmt.Sprintf("%d", test.SkipCount)},
+	}
+	if test.FlakyInRunCount > 0 {
+		props = append(props, junitProperty{"flakehunt.flakyOnRetry", fmt.Sprintf("%d", test.FlakyInRunCount)})
+	}
+	if test.WastedTime > 0 {
+		props = append(props, junitProperty{"flakehunt.wastedTime", junitSeconds(test.WastedTime)})
+	}
+	if patterns := formatTemporalPatterns(test.Temporal); patterns != "" {
+		props = append(props, junitProperty{"flakehunt.runOrderPattern", patterns})
+	}
+	return props
+}
+
+// junitFailureOf returns the failure of a deterministically failing test,
+// describing every failure mode in the element text.
+func junitFailureOf(test model.AggregatedTest) *junitFailure {
+	if len(test.FailureClusters) == 0 {
+		return &junitFailure{Message: fmt.Sprintf("failed in %d of %d runs", test.FailCount, test.TotalRuns)}
+	}
+	texts := make([]string, len(test.FailureClusters))
+	for i, c := range test.FailureClusters {
+		texts[i] = junitClusterText(c)
+	}
+	first := test.FailureClusters[0]
+	return &junitFailure{
+		Message: clusterHeadline(first),
+		Type:    string(first.Signature),
+		Text:    strings.Join(texts, "\n\n"),
+	}
+}
+
+// clusterHeadline returns the first line of a failure mode's message,
+// truncated for use as a one-line summary.
+func clusterHeadline(c model.FailureCluster) string {
+	line, _, _ := strings.Cut(formatClusterMessage(c), "\n")
+	return truncateForTerminal(line, maxHeadlineLength)
+}
+
+// junitClusterText describes a failure mode with its runs, location and
+// full message.
+func junitClusterText(c model.FailureCluster) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("[%s] %d %s (%s)", c.Signature, c.Count, pluralize(c.Count, "failure", "failures"), formatClusterRuns(c)))
+	if c.Location != nil {
+		sb.WriteString(fmt.Sprintf(" at %s", c.Location))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(formatClusterMessage(c))
+	if c.LogPath != "" {
+		sb.WriteString(fmt.Sprintf("\nFull failure log: %s", c.LogPath))
+	}
+	return sb.String()
+}
+
+// junitSeconds formats a duration in seconds, as JUnit time attributes expect.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -2,11 +2,13 @@ package report
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/boyarskiy/flakehunt/internal/classify"
 	"github.com/boyarskiy/flakehunt/internal/model"
 )
 
@@ -44,19 +46,86 @@ func RenderMarkdown(report *model.Report) string {
 	// Header
 	sb.WriteString("# Flakehunt Report\n\n")
 
-	// Summary section
+	writeMarkdownSummary(&sb, report)
+	writeMarkdownOwners(&sb, report)
+	writeMarkdownTopFlakes(&sb, report, true)
+	writeMarkdownSuiteFailures(&sb, report)
+	writeMarkdownCoFailures(&sb, report)
+	writeMarkdownTemporal(&sb, report)
+	writeMarkdownSlowUnstable(&sb, report)
+	writeMarkdownHeadroom(&sb, report)
+	writeMarkdownSignatures(&sb, report)
+	writeMarkdownInfraErrors(&sb, report)
+	writeMarkdownAllTests(&sb, report)
+
+	return sb.String()
+}
+
+// writeMarkdownSummary writes the Summary section: the run settings and test counts.
+func writeMarkdownSummary(sb *strings.Builder, report *model.Report) {
 	sb.WriteString("## Summary\n\n")
 	sb.WriteString("| Metric | Value |\n")
 	sb.WriteString("|--------|-------|\n")
 	sb.WriteString(fmt.Sprintf("| Tool | %s |\n", report.Tool))
 	sb.WriteString(fmt.Sprintf("| Target | %s |\n", escapeMarkdown(report.Target)))
 	sb.WriteString(fmt.Sprintf("| Runs Executed | %d |\n", report.RunsExecuted))
+	if report.TestTimeout > 0 {
+		sb.WriteString(fmt.Sprintf("| Test Timeout | %s |\n", formatTestTimeout(report)))
+	}
 	sb.WriteString(fmt.Sprintf("| Flaky Tests | %d |\n", report.FlakyCount))
 	sb.WriteString(fmt.Sprintf("| Deterministic Failures | %d |\n", report.DetFailCount))
 	sb.WriteString(fmt.Sprintf("| Stable Tests | %d |\n", report.StableCount))
+	if report.FlakySuiteCount > 0 || report.DetFailSuiteCount > 0 {
+		sb.WriteString(fmt.Sprintf("| Flaky Suite Setups | %d |\n", report.FlakySuiteCount))
+		sb.WriteString(fmt.Sprintf("| Failing Suite Setups | %d |\n", report.DetFailSuiteCount))
+	}
+	sb.WriteString("\n")
+}
+
+// writeMarkdownOwners writes the Owners section: the wasted time of each
+// CODEOWNERS owner and their flaky and failing tests.
+func writeMarkdownOwners(sb *strings.Builder, report *model.Report) {
+	if len(report.Owners) == 0 {
+		return
+	}
+	sb.WriteString("## Owners\n\n")
+	writeMarkdownOwnerTable(sb, report)
+
+	tests := make(map[string]model.AggregatedTest, len(report.Tests))
+	for _, test := range report.Tests {
+		tests[test.TestID] = test
+	}
+	for _, owner := range report.Owners {
+		sb.WriteString(fmt.Sprintf("### %s\n\n", formatOwner(owner.Owner)))
+		for _, id := range owner.TestIDs {
+			test := tests[id]
+			sb.WriteString(fmt.Sprintf("- `%s`: ", strings.ReplaceAll(id, "`", "'")))
+			if test.Classification == model.ClassificationFlaky {
+				sb.WriteString(fmt.Sprintf("flaky, %.1f%% flake rate, %s wasted\n", test.FlakeRate*100, formatDuration(test.WastedTime)))
+			} else {
+				sb.WriteString("deterministic failure\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeMarkdownOwnerTable writes a table of the flaky and failing tests and
+// wasted time of each owner.
+func writeMarkdownOwnerTable(sb *strings.Builder, report *model.Report) {
+	sb.WriteString("| Owner | Flaky | Failing | Wasted Time |\n")
+	sb.WriteString("|-------|-------|---------|-------------|\n")
+	for _, owner := range report.Owners {
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %s |\n",
+			escapeMarkdown(formatOwner(owner.Owner)), owner.FlakyCount, owner.FailingCount, formatDuration(owner.WastedTime)))
+	}
 	sb.WriteString("\n")
+}
 
-	// Top Flakes section
+// writeMarkdownTopFlakes writes the Top Flakes section: the top flakes with
+// their failure modes. Links to failure logs and media are relative to the
+// session output directory, and are left out unless artifactLinks is set.
+func writeMarkdownTopFlakes(sb *strings.Builder, report *model.Report, artifactLinks bool) {
 	if len(report.TopFlakes) > 0 {
 		sb.WriteString("## Top Flakes\n\n")
 		sb.WriteString("Ranked by wasted time (flakeRate x avgDuration x runs).\n\n")
@@ -66,31 +135,62 @@ func RenderMarkdown(report *model.Report) string {
 
 			sb.WriteString("| Metric | Value |\n")
 			sb.WriteString("|--------|-------|\n")
+			if len(flake.Owners) > 0 {
+				sb.WriteString(fmt.Sprintf("| Owners | %s |\n", escapeMarkdown(strings.Join(flake.Owners, ", "))))
+			}
 			sb.WriteString(fmt.Sprintf("| Flake Rate | %.1f%% |\n", flake.FlakeRate*100))
 			sb.WriteString(fmt.Sprintf("| Pass Count | %d |\n", flake.PassCount))
 			sb.WriteString(fmt.Sprintf("| Fail Count | %d |\n", flake.FailCount))
 			sb.WriteString(fmt.Sprintf("| Skip Count | %d |\n", flake.SkipCount))
+			if flake.RetriedRuns > 0 {
+				sb.WriteString(fmt.Sprintf("| Attempts | %d |\n", flake.Attempts))
+				sb.WriteString(fmt.Sprintf("| Retried Runs | %d |\n", flake.RetriedRuns))
+				sb.WriteString(fmt.Sprintf("| Flaky on Retry | %d |\n", flake.FlakyInRunCount))
+			}
 			sb.WriteString(fmt.Sprintf("| Average Duration | %s |\n", formatDuration(flake.AvgDuration)))
 			sb.WriteString(fmt.Sprintf("| Wasted Time | %s |\n", formatDuration(flake.WastedTime)))
+			if patterns := formatTemporalPatterns(flake.Temporal); patterns != "" {
+				sb.WriteString(fmt.Sprintf("| Run-Order Pattern | %s |\n", patterns))
+			}
 			sb.WriteString("\n")
 
-			// Failure evidence
-			if len(flake.FailureEvidence) > 0 {
-				sb.WriteString("**Failed Runs:**\n\n")
-
-				// Sort evidence by run index
-				sortedEvidence := make([]model.FailureEvidence, len(flake.FailureEvidence))
-				copy(sortedEvidence, flake.FailureEvidence)
-				sort.Slice(sortedEvidence, func(i, j int) bool {
-					return sortedEvidence[i].RunIndex < sortedEvidence[j].RunIndex
-				})
-
-				for _, ev := range sortedEvidence {
-					sb.WriteString(fmt.Sprintf("- **Run %d** [%s]\n", ev.RunIndex, ev.Signature))
-					if ev.Excerpt != "" {
-						// Indent excerpt
-						excerpt := truncateForTerminal(ev.Excerpt, 200)
-						sb.WriteString(fmt.Sprintf("  ```\n  %s\n  ```\n", excerpt))
+			// Failure modes
+			if len(flake.FailureClusters) > 0 {
+				sb.WriteString(fmt.Sprintf("**%s**\n\n", formatFailureModeCount(len(flake.FailureClusters))))
+
+				for j, c := range flake.FailureClusters {
+					sb.WriteString(fmt.Sprintf("%d. [%s] %d %s (%s)", j+1, c.Signature, c.Count, pluralize(c.Count, "failure", "failures"), formatClusterRuns(c)))
+					if c.Location != nil {
+						sb.WriteString(fmt.Sprintf(" at `%s`", c.Location))
+					}
+					sb.WriteString("\n")
+					if c.Snippet != nil {
+						sb.WriteString(fmt.Sprintf("   ```%s\n", snippetLanguage(c.Location.File)))
+						for _, line := range formatSnippet(c.Snippet) {
+							sb.WriteString(fmt.Sprintf("   %s\n", line))
+						}
+						sb.WriteString("   ```\n")
+					}
+					sb.WriteString("   ```\n")
+					for _, line := range clusterMessageLines(c) {
+						if line == "" {
+							sb.WriteString("\n")
+							continue
+						}
+						sb.WriteString(fmt.Sprintf("   %s\n", line))
+					}
+					sb.WriteString("   ```\n")
+					if !artifactLinks {
+						continue
+					}
+					if c.LogPath != "" {
+						sb.WriteString(fmt.Sprintf("   [Full failure log](%s)\n", c.LogPath))
+					}
+					if c.Screenshot != "" {
+						sb.WriteString(fmt.Sprintf("\n   ![Screenshot at failure](%s)\n", markdownURL(c.Screenshot)))
+					}
+					if c.Video != "" {
+						sb.WriteString(fmt.Sprintf("   [Video of the failing run](%s)\n", markdownURL(c.Video)))
 					}
 				}
 				sb.WriteString("\n")
@@ -100,8 +200,127 @@ func RenderMarkdown(report *model.Report) string {
 		sb.WriteString("## Top Flakes\n\n")
 		sb.WriteString("No flaky tests detected.\n\n")
 	}
+}
 
-	// Failure Signatures section
+// writeMarkdownSuiteFailures writes the Suite Setup Failures section: the suite setups that failed in any run.
+func writeMarkdownSuiteFailures(sb *strings.Builder, report *model.Report) {
+	if failing := failingSuites(report.Tests); len(failing) > 0 {
+		sb.WriteString("## Suite Setup Failures\n\n")
+		sb.WriteString("These test files failed outside of any single test: while loading ")
+		sb.WriteString("the file or in a beforeAll or afterAll hook.\n\n")
+		sb.WriteString("| Test File | Classification | Flake Rate | Failed Runs | Failure |\n")
+		sb.WriteString("|-----------|----------------|------------|-------------|---------|\n")
+		for _, suite := range failing {
+			failure := "-"
+			if len(suite.FailureClusters) > 0 {
+				c := suite.FailureClusters[0]
+				failure = fmt.Sprintf("[%s] %s", c.Signature, escapeMarkdown(truncateForTerminal(formatClusterMessage(c), 120)))
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %.1f%% | %s | %s |\n",
+				escapeMarkdown(suiteFile(suite.TestID)),
+				suite.Classification,
+				suite.FlakeRate*100,
+				formatRunIndices(failedRuns(suite)),
+				failure,
+			))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeMarkdownCoFailures writes the Co-failing Tests section: the groups of tests that fail together.
+func writeMarkdownCoFailures(sb *strings.Builder, report *model.Report) {
+	if len(report.CoFailureGroups) > 0 {
+		sb.WriteString("## Co-failing Tests\n\n")
+		sb.WriteString("The tests in each group fail in the same runs far more often than chance, ")
+		sb.WriteString("which usually means a shared root cause such as a fixture or service. ")
+		sb.WriteString("Triage each group together.\n\n")
+		for i, group := range report.CoFailureGroups {
+			sb.WriteString(fmt.Sprintf("%d. [%s] %d tests failed together in runs %s (similarity %.2f)\n",
+				i+1, group.Signature, len(group.TestIDs), formatRunIndices(group.RunIndices), group.Jaccard))
+			for _, id := range group.TestIDs {
+				sb.WriteString(fmt.Sprintf("   - %s\n", escapeMarkdown(id)))
+			}
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeMarkdownTemporal writes the Run-Order Patterns section: the flaky tests whose failures follow a run-order pattern.
+func writeMarkdownTemporal(sb *strings.Builder, report *model.Report) {
+	if patterned := classify.FilterTemporal(report.Tests); len(patterned) > 0 {
+		sb.WriteString("## Run-Order Patterns\n\n")
+		sb.WriteString("These flaky tests do not fail at random: the order of their failures ")
+		sb.WriteString("points to warm-up effects or state leaking between runs.\n\n")
+		sb.WriteString("| Test ID | Pattern | Failed Runs |\n")
+		sb.WriteString("|---------|---------|-------------|\n")
+		for _, test := range patterned {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n",
+				escapeMarkdown(test.TestID),
+				formatTemporalPatterns(test.Temporal),
+				formatRunIndices(failedRuns(test)),
+			))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeMarkdownSlowUnstable writes the Performance Flakes section: the passing tests with unstable durations.
+func writeMarkdownSlowUnstable(sb *strings.Builder, report *model.Report) {
+	if slow := classify.FilterSlowUnstable(report.Tests); len(slow) > 0 {
+		sb.WriteString("## Performance Flakes\n\n")
+		sb.WriteString("These tests pass, but their durations vary widely between runs.\n\n")
+		sb.WriteString("| Test ID | Median | p95 | Max | Max/Median | CV |\n")
+		sb.WriteString("|---------|--------|-----|-----|------------|----|\n")
+		for _, test := range slow {
+			stats := test.DurationStats
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %.1fx | %.2f |\n",
+				escapeMarkdown(test.TestID),
+				formatDuration(stats.Median),
+				formatDuration(stats.P95),
+				formatDuration(stats.Max),
+				stats.MaxMedianRatio,
+				stats.CV,
+			))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeMarkdownHeadroom writes the Timeout Headroom section: the tests ranked by timeout headroom.
+func writeMarkdownHeadroom(sb *strings.Builder, report *model.Report) {
+	if ranked := classify.RankByTimeoutHeadroom(report.Tests); len(ranked) > 0 {
+		sb.WriteString("## Timeout Headroom\n\n")
+		sb.WriteString("Tests ranked by how close their p95 duration runs to their timeout. ")
+		sb.WriteString("Tests at risk may time out under load.\n\n")
+		sb.WriteString("| Test ID | p95 | Timeout | p95 / Timeout | At Risk |\n")
+		sb.WriteString("|---------|-----|---------|---------------|---------|\n")
+		for i, test := range ranked {
+			if i == maxHeadroomRows {
+				break
+			}
+			stats := test.DurationStats
+			risk := "no"
+			if test.TimeoutRisk {
+				risk = "**yes**"
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s | %.0f%% | %s |\n",
+				escapeMarkdown(test.TestID),
+				formatDuration(stats.P95),
+				formatDuration(stats.Timeout),
+				stats.TimeoutShare*100,
+				risk,
+			))
+		}
+		if len(ranked) > maxHeadroomRows {
+			sb.WriteString(fmt.Sprintf("\n%d more tests have more headroom.\n", len(ranked)-maxHeadroomRows))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writeMarkdownSignatures writes the Failure Signatures section: the failure counts by signature.
+func writeMarkdownSignatures(sb *strings.Builder, report *model.Report) {
 	if len(report.SignatureSummary) > 0 {
 		sb.WriteString("## Failure Signatures\n\n")
 		sb.WriteString("| Signature | Count |\n")
@@ -113,8 +332,27 @@ func RenderMarkdown(report *model.Report) string {
 		}
 		sb.WriteString("\n")
 	}
+}
+
+// writeMarkdownInfraErrors writes the Infrastructure Errors section: the hook and execution failures.
+func writeMarkdownInfraErrors(sb *strings.Builder, report *model.Report) {
+	if len(report.InfraErrors) > 0 {
+		sb.WriteString("## Infrastructure Errors\n\n")
+		sb.WriteString("These failures come from hooks or test execution, not from the tests themselves.\n\n")
+		sb.WriteString("| Source | Message |\n")
+		sb.WriteString("|--------|---------|\n")
+		for _, ie := range report.InfraErrors {
+			sb.WriteString(fmt.Sprintf("| %s | %s |\n",
+				formatInfraErrorSource(ie),
+				escapeMarkdown(truncateForTerminal(ie.Message, 300)),
+			))
+		}
+		sb.WriteString("\n")
+	}
+}
 
-	// All Tests section
+// writeMarkdownAllTests writes the All Tests section: every test with its classification and counts.
+func writeMarkdownAllTests(sb *strings.Builder, report *model.Report) {
 	if len(report.Tests) > 0 {
 		sb.WriteString("## All Tests\n\n")
 		sb.WriteString("| Test ID | Classification | Flake Rate | Pass | Fail | Skip |\n")
@@ -133,6 +371,10 @@ func RenderMarkdown(report *model.Report) string {
 		})
 
 		for _, test := range sortedTests {
+			// Passing suite setups are implied by their tests
+			if test.Suite && test.Classification == model.ClassificationStable {
+				continue
+			}
 			flakeRateStr := "-"
 			if test.Classification == model.ClassificationFlaky {
 				flakeRateStr = fmt.Sprintf("%.1f%%", test.FlakeRate*100)
@@ -148,8 +390,50 @@ func RenderMarkdown(report *model.Report) string {
 		}
 		sb.WriteString("\n")
 	}
+}
 
-	return sb.String()
+// failingSuites returns the suite setup results that failed in any run,
+// flaky suites first.
+func failingSuites(tests []model.AggregatedTest) []model.AggregatedTest {
+	var result []model.AggregatedTest
+	for _, class := range []model.Classification{model.ClassificationFlaky, model.ClassificationDeterministicFail} {
+		result = append(result, classify.FilterByClassification(classify.FilterSuites(tests), class)...)
+	}
+	return result
+}
+
+// maxHeadroomRows is the number of tests shown in the timeout headroom ranking.
+const maxHeadroomRows = 10
+
+// maxMessageLines is the number of lines of a failure message shown in Markdown.
+const maxMessageLines = 20
+
+// clusterMessageLines returns the representative message of a failure mode,
+// limited to maxMessageLines lines.
+func clusterMessageLines(c model.FailureCluster) []string {
+	lines := strings.Split(strings.ReplaceAll(formatClusterMessage(c), "\r", ""), "\n")
+	if len(lines) > maxMessageLines {
+		more := len(lines) - maxMessageLines
+		lines = append(lines[:maxMessageLines], fmt.Sprintf("... (%d more lines)", more))
+	}
+	return lines
+}
+
+// pluralize returns singular if n is 1, and plural otherwise.
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// formatOwner returns the display name of a CODEOWNERS owner, where an
+// empty owner groups the tests without one.
+func formatOwner(owner string) string {
+	if owner == "" {
+		return "Unowned"
+	}
+	return owner
 }
 
 // classificationOrder returns a sort order for classifications.
@@ -166,6 +450,12 @@ func classificationOrder(c model.Classification) int {
 	}
 }
 
+// markdownURL returns a link destination for a relative path. Cypress media
+// names contain spaces and parentheses, which must be escaped.
+func markdownURL(path string) string {
+	return (&url.URL{Path: path}).EscapedPath()
+}
+
 // escapeMarkdown escapes special Markdown characters in a string.
 func escapeMarkdown(s string) string {
 	// Escape pipe characters which break tables
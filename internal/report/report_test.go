@@ -3,8 +3,15 @@ package report
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -48,6 +55,10 @@ func fixtureReport() *model.Report {
 					{RunIndex: 5, Excerpt: "Unable to find element with text 'Click me'", Signature: model.SignatureSelector},
 					{RunIndex: 8, Excerpt: "Timeout waiting for element", Signature: model.SignatureTimeout},
 				},
+				FailureClusters: []model.FailureCluster{
+					{Fingerprint: "Unable to find element with text 'Click me'", Signature: model.SignatureSelector, Count: 2, FirstRun: 2, LastRun: 5, RunIndices: []int{2, 5}, Message: "Unable to find element with text 'Click me'"},
+					{Fingerprint: "Timeout waiting for element", Signature: model.SignatureTimeout, Count: 1, FirstRun: 8, LastRun: 8, RunIndices: []int{8}, Message: "Timeout waiting for element\n\n  at waitFor (src/test-utils.ts:12:5)", Location: &model.SourceLocation{File: "src/test-utils.ts", Line: 12, Column: 5}, Snippet: fixtureSnippet()},
+				},
 			},
 			{
 				TestID:         "src/components/Button.test.tsx::Button should submit form",
@@ -66,6 +77,9 @@ func fixtureReport() *model.Report {
 					{RunIndex: 7, Excerpt: "Network error: ECONNREFUSED", Signature: model.SignatureNetwork},
 					{RunIndex: 9, Excerpt: "Network error: ECONNREFUSED", Signature: model.SignatureNetwork},
 				},
+				FailureClusters: []model.FailureCluster{
+					{Fingerprint: "Network error: ECONNREFUSED", Signature: model.SignatureNetwork, Count: 5, FirstRun: 1, LastRun: 9, RunIndices: []int{1, 3, 4, 7, 9}, Message: "Network error: ECONNREFUSED", LogPath: "runs/001/failures/Button.test.tsx__Button_should_submit_form-1a2b3c4d.txt"},
+				},
 			},
 		},
 		TopFlakes: []model.AggregatedTest{
@@ -86,6 +100,9 @@ func fixtureReport() *model.Report {
 					{RunIndex: 7, Excerpt: "Network error: ECONNREFUSED", Signature: model.SignatureNetwork},
 					{RunIndex: 9, Excerpt: "Network error: ECONNREFUSED", Signature: model.SignatureNetwork},
 				},
+				FailureClusters: []model.FailureCluster{
+					{Fingerprint: "Network error: ECONNREFUSED", Signature: model.SignatureNetwork, Count: 5, FirstRun: 1, LastRun: 9, RunIndices: []int{1, 3, 4, 7, 9}, Message: "Network error: ECONNREFUSED", LogPath: "runs/001/failures/Button.test.tsx__Button_should_submit_form-1a2b3c4d.txt"},
+				},
 			},
 			{
 				TestID:         "src/components/Button.test.tsx::Button should handle click",
@@ -102,6 +119,10 @@ func fixtureReport() *model.Report {
 					{RunIndex: 5, Excerpt: "Unable to find element with text 'Click me'", Signature: model.SignatureSelector},
 					{RunIndex: 8, Excerpt: "Timeout waiting for element", Signature: model.SignatureTimeout},
 				},
+				FailureClusters: []model.FailureCluster{
+					{Fingerprint: "Unable to find element with text 'Click me'", Signature: model.SignatureSelector, Count: 2, FirstRun: 2, LastRun: 5, RunIndices: []int{2, 5}, Message: "Unable to find element with text 'Click me'"},
+					{Fingerprint: "Timeout waiting for element", Signature: model.SignatureTimeout, Count: 1, FirstRun: 8, LastRun: 8, RunIndices: []int{8}, Message: "Timeout waiting for element\n\n  at waitFor (src/test-utils.ts:12:5)", Location: &model.SourceLocation{File: "src/test-utils.ts", Line: 12, Column: 5}, Snippet: fixtureSnippet()},
+				},
 			},
 		},
 		SignatureSummary: map[string]int{
@@ -112,6 +133,21 @@ func fixtureReport() *model.Report {
 	}
 }
 
+// fixtureSnippet creates a sample code snippet for testing.
+func fixtureSnippet() *model.CodeSnippet {
+	return &model.CodeSnippet{
+		StartLine: 10,
+		Highlight: 12,
+		Lines: []string{
+			"export async function waitFor(check: () => boolean) {",
+			"  for (let i = 0; i < 10; i++) {",
+			"    if (check()) return;",
+			"    await sleep(100);",
+			"  }",
+		},
+	}
+}
+
 // TestTerminalOutputGolden is a golden test for terminal output.
 func TestTerminalOutputGolden(t *testing.T) {
 	report := fixtureReport()
@@ -266,6 +302,7 @@ func TestTruncateForTerminal(t *testing.T) {
 		{"this is a longer string", 10, "this is..."},
 		{"multi\nline\ntext", 20, "multi line text"},
 		{"", 10, ""},
+		{"ожидалось значение", 10, "ожидало..."},
 	}
 
 	for _, tt := range tests {
@@ -340,3 +377,1144 @@ func TestSortedSignatures(t *testing.T) {
 		}
 	}
 }
+
+// TestInfraErrorsRendered tests that infrastructure errors are listed separately from test failures.
+func TestInfraErrorsRendered(t *testing.T) {
+	report := fixtureReport()
+	report.InfraErrors = []model.InfraError{
+		{RunIndex: 3, Stage: "before-run", Message: "before-run hook failed: exit status 1"},
+		{Stage: "after-session", Message: "after-session hook failed: exit status 2"},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderTerminal(&TerminalConfig{Writer: &buf, TopN: 5}, report, ".flakehunt/latest"); err != nil {
+		t.Fatalf("RenderTerminal failed: %v", err)
+	}
+	md := RenderMarkdown(report)
+
+	for _, want := range []string{
+		"Run 3 [before-run]: before-run hook failed: exit status 1",
+		"Session [after-session]: after-session hook failed: exit status 2",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("terminal output missing %q", want)
+		}
+	}
+	for _, want := range []string{
+		"## Infrastructure Errors",
+		"| Run 3 [before-run] | before-run hook failed: exit status 1 |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown output missing %q", want)
+		}
+	}
+}
+
+// TestFailureModesLimited tests that long failure mode lists and messages are shortened.
+func TestFailureModesLimited(t *testing.T) {
+	report := fixtureReport()
+	flake := &report.TopFlakes[0]
+	flake.FailureClusters = nil
+	for i := 1; i <= 5; i++ {
+		flake.FailureClusters = append(flake.FailureClusters, model.FailureCluster{
+			Signature: model.SignatureUnknown,
+			Count:     1,
+			FirstRun:  i,
+			LastRun:   i,
+			Message:   strings.Repeat("line\n", 30),
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := RenderTerminal(&TerminalConfig{Writer: &buf, TopN: 5}, report, ".flakehunt/latest"); err != nil {
+		t.Fatalf("RenderTerminal failed: %v", err)
+	}
+	md := RenderMarkdown(report)
+
+	if !strings.Contains(buf.String(), "5 distinct failure modes:") {
+		t.Error("terminal output should count failure modes")
+	}
+	if !strings.Contains(buf.String(), "... and 2 more") {
+		t.Error("terminal output should limit failure modes")
+	}
+	if !strings.Contains(md, "... (11 more lines)") {
+		t.Error("markdown output should limit message lines")
+	}
+}
+
+// TestDurationFlagsRendered tests that performance flakes and timeout risks are listed.
+func TestDurationFlagsRendered(t *testing.T) {
+	report := fixtureReport()
+	report.Tests[0].SlowUnstable = true
+	report.Tests[0].TimeoutRisk = true
+	report.Tests[0].DurationStats = &model.DurationStats{
+		Samples:        10,
+		Median:         210 * time.Millisecond,
+		P95:            4500 * time.Millisecond,
+		Max:            9 * time.Second,
+		CV:             1.25,
+		MaxMedianRatio: 42.9,
+		Timeout:        5 * time.Second,
+		TimeoutShare:   0.9,
+	}
+	report.TestTimeout = 5 * time.Second
+	report.TestTimeoutSource = "jest.config.js testTimeout"
+
+	var buf bytes.Buffer
+	if err := RenderTerminal(&TerminalConfig{Writer: &buf, TopN: 5}, report, ".flakehunt/latest"); err != nil {
+		t.Fatalf("RenderTerminal failed: %v", err)
+	}
+	md := RenderMarkdown(report)
+
+	for _, want := range []string{
+		"Performance Flakes (passing, but durations vary widely):",
+		"Median 210ms, p95 4.5s, max 9.0s (42.9x median)",
+		"p95 4.5s of 5.0s timeout (90%)",
+		"Test Timeout:  5.0s (jest.config.js testTimeout)",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("terminal output missing %q", want)
+		}
+	}
+	for _, want := range []string{
+		"## Performance Flakes",
+		"| src/components/Button.test.tsx::Button should render correctly | 210ms | 4.5s | 9.0s | 42.9x | 1.25 |",
+		"| Test Timeout | 5.0s (jest.config.js testTimeout) |",
+		"## Timeout Headroom",
+		"| src/components/Button.test.tsx::Button should render correctly | 4.5s | 5.0s | 90% | **yes** |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown output missing %q", want)
+		}
+	}
+}
+
+func TestTemporalPatternsRendered(t *testing.T) {
+	report := fixtureReport()
+	temporal := &model.TemporalAnalysis{
+		Patterns:          []model.TemporalPattern{model.PatternDegrading},
+		TrendZ:            2.4,
+		LongestFailStreak: 2,
+	}
+	report.Tests[1].Temporal = temporal
+	report.TopFlakes[0].Temporal = temporal
+
+	var buf bytes.Buffer
+	if err := RenderTerminal(&TerminalConfig{Writer: &buf, TopN: 5}, report, ".flakehunt/latest"); err != nil {
+		t.Fatalf("RenderTerminal failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "     Pattern: degrades over time\n") {
+		t.Errorf("terminal output missing pattern:\n%s", buf.String())
+	}
+
+	md := RenderMarkdown(report)
+	for _, want := range []string{
+		"| Run-Order Pattern | degrades over time |",
+		"## Run-Order Patterns",
+		"| src/components/Button.test.tsx::Button should handle click | degrades over time | 2, 5, 8 |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown output missing %q", want)
+		}
+	}
+}
+
+func TestCoFailureGroupsRendered(t *testing.T) {
+	report := fixtureReport()
+	report.CoFailureGroups = []model.CoFailureGroup{
+		{
+			TestIDs:    []string{"src/components/Button.test.tsx::Button should handle click", "src/components/Button.test.tsx::Button should submit form"},
+			RunIndices: []int{2, 5},
+			Signature:  model.SignatureNetwork,
+			Jaccard:    0.75,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderTerminal(&TerminalConfig{Writer: &buf, TopN: 5}, report, ".flakehunt/latest"); err != nil {
+		t.Fatalf("RenderTerminal failed: %v", err)
+	}
+	for _, want := range []string{
+		"Co-failing Tests (likely a shared root cause):",
+		"  1. 2 tests failed together in runs 2, 5 (NETWORK)",
+		"     src/components/Button.test.tsx::Button should submit form",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("terminal output missing %q", want)
+		}
+	}
+
+	md := RenderMarkdown(report)
+	for _, want := range []string{
+		"## Co-failing Tests",
+		"1. [NETWORK] 2 tests failed together in runs 2, 5 (similarity 0.75)",
+		"   - src/components/Button.test.tsx::Button should handle click",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown output missing %q", want)
+		}
+	}
+}
+
+func TestOwnersRendered(t *testing.T) {
+	report := fixtureReport()
+	for i := range report.TopFlakes {
+		report.TopFlakes[i].Owners = []string{"@acme/web"}
+	}
+	for i := range report.Tests {
+		report.Tests[i].Owners = []string{"@acme/web"}
+	}
+	report.Owners = []model.OwnerSummary{
+		{
+			Owner:      "@acme/web",
+			FlakyCount: 2,
+			WastedTime: 2500 * time.Millisecond,
+			TestIDs:    []string{"src/components/Button.test.tsx::Button should submit form", "src/components/Button.test.tsx::Button should handle click"},
+		},
+		{Owner: "", FailingCount: 1},
+	}
+
+	md := RenderMarkdown(report)
+	for _, want := range []string{
+		"## Owners\n\n| Owner | Flaky | Failing | Wasted Time |\n|-------|-------|---------|-------------|\n| @acme/web | 2 | 0 | 2.5s |\n| Unowned | 0 | 1 | 0ms |\n",
+		"### @acme/web\n\n- `src/components/Button.test.tsx::Button should submit form`: flaky, 50.0% flake rate, 1.0s wasted\n",
+		"| Owners | @acme/web |\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown output missing %q:\n%s", want, md)
+		}
+	}
+
+	html, err := RenderHTML(report)
+	if err != nil {
+		t.Fatalf("RenderHTML failed: %v", err)
+	}
+	for _, want := range []string{
+		"<h2>Owners</h2>",
+		`<tr><td>@acme/web</td><td class="num">2</td><td class="num">0</td><td class="num">2.5s</td>`,
+		`<th class="sortable" data-type="text">Owners</th>`,
+		"<td>@acme/web</td>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML output missing %q", want)
+		}
+	}
+
+	if comment := RenderComment(report, ""); !strings.Contains(comment, "### By Owner\n\n| Owner | Flaky | Failing | Wasted Time |\n") {
+		t.Errorf("comment missing owner table:\n%s", comment)
+	}
+}
+
+func TestSuiteSetupFailuresRendered(t *testing.T) {
+	report := fixtureReport()
+	report.FlakySuiteCount = 1
+	report.Tests = append(report.Tests,
+		model.AggregatedTest{
+			TestID:          "src/db.test.js::" + model.SuiteSetupName,
+			Suite:           true,
+			PassCount:       8,
+			FailCount:       2,
+			TotalRuns:       10,
+			Classification:  model.ClassificationFlaky,
+			FlakeRate:       0.2,
+			FailureEvidence: []model.FailureEvidence{{RunIndex: 3}, {RunIndex: 7}},
+			FailureClusters: []model.FailureCluster{
+				{Signature: model.SignatureNetwork, Count: 2, FirstRun: 3, LastRun: 7, RunIndices: []int{3, 7}, Message: "connect ECONNREFUSED 127.0.0.1:5432"},
+			},
+		},
+		model.AggregatedTest{
+			TestID:         "src/math.test.js::" + model.SuiteSetupName,
+			Suite:          true,
+			PassCount:      10,
+			TotalRuns:      10,
+			Classification: model.ClassificationStable,
+		},
+	)
+
+	var buf bytes.Buffer
+	if err := RenderTerminal(&TerminalConfig{Writer: &buf, TopN: 5}, report, ".flakehunt/latest"); err != nil {
+		t.Fatalf("RenderTerminal failed: %v", err)
+	}
+	for _, want := range []string{
+		"Suite Setup Failures:\n  Flaky:              1\n",
+		"Flaky Suite Setup (file load, beforeAll and afterAll):\n  src/db.test.js\n",
+		"     Failed Runs: 3, 7\n",
+		"     NETWORK: connect ECONNREFUSED 127.0.0.1:5432\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("terminal output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	md := RenderMarkdown(report)
+	for _, want := range []string{
+		"| Flaky Suite Setups | 1 |",
+		"## Suite Setup Failures",
+		"| src/db.test.js | flaky | 20.0% | 3, 7 | [NETWORK] connect ECONNREFUSED 127.0.0.1:5432 |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown output missing %q", want)
+		}
+	}
+	if strings.Contains(md, "src/math.test.js::<suite setup>") {
+		t.Error("markdown should not list passing suite setups")
+	}
+}
+
+func TestRetryAttemptsRendered(t *testing.T) {
+	report := fixtureReport()
+	flake := &report.TopFlakes[0]
+	flake.Attempts = 13
+	flake.RetriedRuns = 3
+	flake.FlakyInRunCount = 2
+
+	var buf bytes.Buffer
+	if err := RenderTerminal(&TerminalConfig{Writer: &buf, TopN: 5}, report, ".flakehunt/latest"); err != nil {
+		t.Fatalf("RenderTerminal failed: %v", err)
+	}
+	for _, want := range []string{
+		"     Flake Rate: 50.0% (5/10 failed, 2 flaky on retry)\n",
+		"     Attempts: 13 in 10 runs (3 retried)\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("terminal output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	md := RenderMarkdown(report)
+	for _, want := range []string{"| Attempts | 13 |", "| Retried Runs | 3 |", "| Flaky on Retry | 2 |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown output missing %q", want)
+		}
+	}
+}
+
+func TestFailureMediaRendered(t *testing.T) {
+	report := fixtureReport()
+	cluster := &report.TopFlakes[0].FailureClusters[0]
+	cluster.Screenshot = "runs/001/screenshots/form.cy.js/Form -- submits (failed).png"
+	cluster.Video = "runs/001/videos/form.cy.js.mp4"
+
+	md := RenderMarkdown(report)
+	for _, want := range []string{
+		"   ![Screenshot at failure](runs/001/screenshots/form.cy.js/Form%20--%20submits%20%28failed%29.png)\n",
+		"   [Video of the failing run](runs/001/videos/form.cy.js.mp4)\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown output missing %q", want)
+		}
+	}
+}
+
+// fixtureRuns fills in the per-run outcomes of the fixture report's tests
+// from their failure evidence.
+func fixtureRuns(report *model.Report) {
+	for i := range report.Tests {
+		test := &report.Tests[i]
+		failed := make(map[int]bool)
+		for _, ev := range test.FailureEvidence {
+			failed[ev.RunIndex] = true
+		}
+		for run := 1; run <= report.RunsExecuted; run++ {
+			sample := model.RunSample{RunIndex: run, Outcome: model.OutcomePass, Duration: test.AvgDuration}
+			if failed[run] {
+				sample.Outcome = model.OutcomeFail
+				sample.Duration = 2 * test.AvgDuration
+			}
+			test.Runs = append(test.Runs, sample)
+		}
+	}
+}
+
+// TestHTMLOutputGolden is a golden test for HTML output.
+func TestHTMLOutputGolden(t *testing.T) {
+	report := fixtureReport()
+	fixtureRuns(report)
+
+	got, err := RenderHTML(report)
+	if err != nil {
+		t.Fatalf("RenderHTML failed: %v", err)
+	}
+	goldenPath := filepath.Join("testdata", "html_output.golden")
+
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		err := os.MkdirAll(filepath.Dir(goldenPath), 0755)
+		if err != nil {
+			t.Fatalf("failed to create testdata dir: %v", err)
+		}
+		err = os.WriteFile(goldenPath, []byte(got), 0644)
+		if err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		t.Logf("Updated golden file: %s", goldenPath)
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s: %v\nRun with UPDATE_GOLDEN=1 to create it", goldenPath, err)
+	}
+
+	if got != string(want) {
+		t.Errorf("HTML output mismatch.\n\nGot:\n%s\n\nWant:\n%s", got, string(want))
+	}
+}
+
+func TestHTMLReport(t *testing.T) {
+	report := fixtureReport()
+	fixtureRuns(report)
+	report.Tests[1].Runs[2] = model.RunSample{RunIndex: 3, Outcome: model.OutcomePass, Attempts: 2, FlakyInRun: true}
+	report.Tests[2].TestID = "src/form.test.tsx::<script>alert(1)</script>"
+	report.Tests[2].FailureEvidence[0].LogPath = "runs/001/failures/form.test.tsx-1a2b3c4d.txt"
+	report.Tests[2].FailureClusters[0].Screenshot = "runs/001/screenshots/form.cy.js/Form -- submits (failed).png"
+	report.Tests[2].FailureClusters[0].Video = "runs/001/videos/form.cy.js.mp4"
+
+	html, err := RenderHTML(report)
+	if err != nil {
+		t.Fatalf("RenderHTML failed: %v", err)
+	}
+
+	for _, want := range []string{
+		`<th class="run"><a href="runs/010/">10</a></th>`,
+		`<a class="cell fail" href="runs/001/failures/form.test.tsx-1a2b3c4d.txt" title="Run 1: fail (400ms)"></a>`,
+		`<span class="cell retry" title="Run 3: passed on retry after 2 attempts (0ms)"></span>`,
+		`&lt;script&gt;alert(1)&lt;/script&gt;`,
+		`<img src="runs/001/screenshots/form.cy.js/Form%20--%20submits%20%28failed%29.png"`,
+		`<video src="runs/001/videos/form.cy.js.mp4" controls preload="none"></video>`,
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML output missing %q", want)
+		}
+	}
+	if strings.Contains(html, "<script>alert(1)") {
+		t.Error("HTML output does not escape test IDs")
+	}
+	// The report must work offline
+	for _, external := range []string{`src="http`, `href="http`, "@import"} {
+		if strings.Contains(html, external) {
+			t.Errorf("HTML output references an external resource: %q", external)
+		}
+	}
+}
+
+// TestJUnitOutputGolden is a golden test for JUnit XML output.
+func TestJUnitOutputGolden(t *testing.T) {
+	data, err := MarshalJUnit(fixtureReport())
+	if err != nil {
+		t.Fatalf("MarshalJUnit failed: %v", err)
+	}
+
+	got := string(data)
+	goldenPath := filepath.Join("testdata", "junit_output.golden")
+
+	if os.Getenv("UPDATE_GOLDEN") == "1" {
+		err := os.MkdirAll(filepath.Dir(goldenPath), 0755)
+		if err != nil {
+			t.Fatalf("failed to create testdata dir: %v", err)
+		}
+		err = os.WriteFile(goldenPath, []byte(got), 0644)
+		if err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		t.Logf("Updated golden file: %s", goldenPath)
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s: %v\nRun with UPDATE_GOLDEN=1 to create it", goldenPath, err)
+	}
+
+	if got != string(want) {
+		t.Errorf("JUnit output mismatch.\n\nGot:\n%s\n\nWant:\n%s", got, string(want))
+	}
+}
+
+func TestJUnitClassifications(t *testing.T) {
+	report := &model.Report{
+		Tool: "jest",
+		Tests: []model.AggregatedTest{
+			{TestID: "a.test.js::flaky", Classification: model.ClassificationFlaky, PassCount: 3, FailCount: 1, TotalRuns: 4, FlakeRate: 0.25,
+				FailureClusters: []model.FailureCluster{{Signature: model.SignatureTimeout, Count: 1, FirstRun: 2, LastRun: 2, Message: "Exceeded timeout\n  at a.test.js:3"}}},
+			{TestID: "a.test.js::broken", Classification: model.ClassificationDeterministicFail, FailCount: 4, TotalRuns: 4, FlakeRate: 1,
+				FailureClusters: []model.FailureCluster{{Signature: model.SignatureAssertion, Count: 4, FirstRun: 1, LastRun: 4, Message: "expected 1 < 2"}}},
+			{TestID: "b.test.js::skipped", Classification: model.ClassificationStable, SkipCount: 4},
+			{TestID: "b.test.js::" + model.SuiteSetupName, Suite: true, Classification: model.ClassificationStable, PassCount: 4, TotalRuns: 4},
+		},
+	}
+
+	data, err := MarshalJUnit(report)
+	if err != nil {
+		t.Fatalf("MarshalJUnit failed: %v", err)
+	}
+
+	var parsed junitTestSuites
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("JUnit output is not valid XML: %v", err)
+	}
+	if parsed.Tests != 3 || parsed.Failures != 1 || parsed.Skipped != 1 {
+		t.Errorf("totals = %d tests, %d failures, %d skipped, want 3, 1, 1", parsed.Tests, parsed.Failures, parsed.Skipped)
+	}
+	if len(parsed.Suites) != 2 {
+		t.Fatalf("got %d test suites, want 2", len(parsed.Suites))
+	}
+
+	flaky := parsed.Suites[0].TestCases[0]
+	if flaky.Failure != nil || len(flaky.FlakyFailures) != 1 {
+		t.Errorf("flaky test: failure = %v, %d flaky failures, want none and 1", flaky.Failure, len(flaky.FlakyFailures))
+	} else if ff := flaky.FlakyFailures[0]; ff.Message != "Exceeded timeout" || ff.Type != "TIMEOUT" {
+		t.Errorf("flaky failure = %q (%s), want %q (TIMEOUT)", ff.Message, ff.Type, "Exceeded timeout")
+	}
+	if got := flaky.Properties[1]; got != (junitProperty{"flakehunt.flakeRate", "0.2500"}) {
+		t.Errorf("flake rate property = %+v", got)
+	}
+
+	broken := parsed.Suites[0].TestCases[1]
+	if broken.Failure == nil || broken.Failure.Type != "ASSERTION" || !strings.Contains(broken.Failure.Text, "expected 1 < 2") {
+		t.Errorf("deterministic failure = %+v", broken.Failure)
+	}
+
+	skipped := parsed.Suites[1].TestCases[0]
+	if skipped.Skipped == nil || len(parsed.Suites[1].TestCases) != 1 {
+		t.Errorf("skipped test = %+v, want a single skipped testcase", parsed.Suites[1].TestCases)
+	}
+}
+
+func TestJUnitRelativeTestIDs(t *testing.T) {
+	// Jest test IDs carry the absolute path of the test file
+	report := &model.Report{
+		Tool:        "jest",
+		ProjectRoot: "/work/app",
+		Tests: []model.AggregatedTest{
+			{TestID: "/work/app/src/sum.test.js::sum adds", Classification: model.ClassificationStable, PassCount: 2, TotalRuns: 2},
+			{TestID: "/other/checkout/src/sum.test.js::sum subtracts", Classification: model.ClassificationStable, PassCount: 2, TotalRuns: 2},
+		},
+	}
+
+	data, err := MarshalJUnit(report)
+	if err != nil {
+		t.Fatalf("MarshalJUnit failed: %v", err)
+	}
+	var parsed junitTestSuites
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("JUnit output is not valid XML: %v", err)
+	}
+
+	want := []string{"src/sum.test.js", "/other/checkout/src/sum.test.js"}
+	if len(parsed.Suites) != len(want) {
+		t.Fatalf("got %d test suites, want %d", len(parsed.Suites), len(want))
+	}
+	for i, suite := range parsed.Suites {
+		if suite.Name != want[i] || suite.TestCases[0].ClassName != want[i] {
+			t.Errorf("suite %d = %q with classname %q, want %q", i, suite.Name, suite.TestCases[0].ClassName, want[i])
+		}
+	}
+}
+
+// sarifSchemaPath is the official SARIF 2.1.0 JSON schema (OASIS errata 01).
+var sarifSchemaPath = filepath.Join("testdata", "sarif-schema-2.1.0.json")
+
+// validateJSONSchema returns the violations of value against a JSON Schema
+// draft-04 schema, each prefixed with its path. root resolves $ref. Only the
+// keywords used by the SARIF schema are supported.
+func validateJSONSchema(root, schema map[string]any, path string, value any) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name, ok := strings.CutPrefix(ref, "#/definitions/")
+		definitions, _ := root["definitions"].(map[string]any)
+		resolved, _ := definitions[name].(map[string]any)
+		if !ok || resolved == nil {
+			return []string{fmt.Sprintf("%s: unsupported $ref %q", path, ref)}
+		}
+		return validateJSONSchema(root, resolved, path, value)
+	}
+
+	if types := schema["type"]; types != nil && !matchesJSONType(types, value) {
+		return []string{fmt.Sprintf("%s: %v is not of type %v", path, value, types)}
+	}
+
+	var errs []string
+	if enum, ok := schema["enum"].([]any); ok && !slices.ContainsFunc(enum, func(v any) bool { return reflect.DeepEqual(v, value) }) {
+		errs = append(errs, fmt.Sprintf("%s: %v is not one of %v", path, value, enum))
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok && countJSONSchemaMatches(root, anyOf, path, value) == 0 {
+		errs = append(errs, fmt.Sprintf("%s: matches none of anyOf", path))
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		if n := countJSONSchemaMatches(root, oneOf, path, value); n != 1 {
+			errs = append(errs, fmt.Sprintf("%s: matches %d of oneOf, want exactly 1", path, n))
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range jsonSchemaStrings(schema["required"]) {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, prop := range v {
+			if nested, ok := properties[name].(map[string]any); ok {
+				errs = append(errs, validateJSONSchema(root, nested, path+"."+name, prop)...)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					errs = append(errs, fmt.Sprintf("%s: property %q is not allowed", path, name))
+				}
+			case map[string]any:
+				errs = append(errs, validateJSONSchema(root, additional, path+"."+name, prop)...)
+			}
+		}
+	case []any:
+		if min, ok := schema["minItems"].(float64); ok && float64(len(v)) < min {
+			errs = append(errs, fmt.Sprintf("%s: has %d items, want at least %v", path, len(v), min))
+		}
+		if unique, _ := schema["uniqueItems"].(bool); unique {
+			seen := make(map[string]bool)
+			for _, item := range v {
+				key, _ := json.Marshal(item)
+				if seen[string(key)] {
+					errs = append(errs, fmt.Sprintf("%s: duplicate item %s", path, key))
+				}
+				seen[string(key)] = true
+			}
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validateJSONSchema(root, items, fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	case float64:
+		if min, ok := schema["minimum"].(float64); ok && v < min {
+			errs = append(errs, fmt.Sprintf("%s: %v is less than %v", path, v, min))
+		}
+		if max, ok := schema["maximum"].(float64); ok && v > max {
+			errs = append(errs, fmt.Sprintf("%s: %v is greater than %v", path, v, max))
+		}
+	case string:
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(v) {
+			errs = append(errs, fmt.Sprintf("%s: %q does not match %s", path, v, pattern))
+		}
+		if format, ok := schema["format"].(string); ok && !matchesJSONFormat(format, v) {
+			errs = append(errs, fmt.Sprintf("%s: %q is not a valid %s", path, v, format))
+		}
+	}
+	return errs
+}
+
+// countJSONSchemaMatches returns how many of schemas value is valid against.
+func countJSONSchemaMatches(root map[string]any, schemas []any, path string, value any) int {
+	n := 0
+	for _, s := range schemas {
+		if schema, ok := s.(map[string]any); ok && len(validateJSONSchema(root, schema, path, value)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// matchesJSONType reports whether value has one of the JSON Schema types,
+// given as a name or a list of names.
+func matchesJSONType(types, value any) bool {
+	names := jsonSchemaStrings(types)
+	if name, ok := types.(string); ok {
+		names = []string{name}
+	}
+	for _, name := range names {
+		switch v := value.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case float64:
+			if name == "number" || (name == "integer" && v == math.Trunc(v)) {
+				return true
+			}
+		case []any:
+			if name == "array" {
+				return true
+			}
+		case map[string]any:
+			if name == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesJSONFormat reports whether s is valid in a JSON Schema format.
+func matchesJSONFormat(format, s string) bool {
+	switch format {
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	case "uri-reference":
+		_, err := url.Parse(s)
+		return err == nil && !strings.ContainsAny(s, " \\")
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	}
+	return true
+}
+
+// jsonSchemaStrings returns the strings of a decoded JSON array.
+func jsonSchemaStrings(value any) []string {
+	items, _ := value.([]any)
+	var strs []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+// validateSARIF checks decoded SARIF output against the official schema.
+func validateSARIF(t *testing.T, value any) {
+	t.Helper()
+	data, err := os.ReadFile(sarifSchemaPath)
+	if err != nil {
+		t.Fatalf("failed to read SARIF schema: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("failed to parse SARIF schema: %v", err)
+	}
+	for _, e := range validateJSONSchema(schema, schema, "$", value) {
+		t.Errorf("SARIF output violates the schema: %s", e)
+	}
+}
+
+func TestSARIFOutputValid(t *testing.T) {
+	report := fixtureReport()
+	report.Tests = append(report.Tests, model.AggregatedTest{
+		TestID:         "src/Form test.tsx::Form submits",
+		Classification: model.ClassificationDeterministicFail,
+		FailCount:      10,
+		TotalRuns:      10,
+		FlakeRate:      1,
+		FailureClusters: []model.FailureCluster{{Signature: model.SignatureAssertion, Count: 10, FirstRun: 1, LastRun: 10,
+			RunIndices: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, Message: "expected true", Location: &model.SourceLocation{File: "src/Form test.tsx", Line: 14, Column: 3}}},
+	})
+
+	data, err := MarshalSARIF(report)
+	if err != nil {
+		t.Fatalf("MarshalSARIF failed: %v", err)
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("SARIF output is not valid JSON: %v", err)
+	}
+	validateSARIF(t, decoded)
+
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("failed to decode SARIF output: %v", err)
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 3 {
+		t.Fatalf("got %d results, want 3 (two flaky tests and one failing test)", len(run.Results))
+	}
+	for _, r := range run.Results {
+		if rule := run.Tool.Driver.Rules[r.RuleIndex]; rule.ID != r.RuleID {
+			t.Errorf("result ruleIndex %d points to rule %q, want %q", r.RuleIndex, rule.ID, r.RuleID)
+		}
+	}
+
+	tests := []struct {
+		index     int
+		ruleID    string
+		level     string
+		uri       string
+		region    *sarifRegion
+		related   int
+		inMessage string
+	}{
+		// Stack frame in a helper file: the test file has no line
+		{0, "SELECTOR", "warning", "src/components/Button.test.tsx", nil, 1, "3/10 failed"},
+		{1, "NETWORK", "error", "src/components/Button.test.tsx", nil, 0, "flake rate 50.0%"},
+		{2, "ASSERTION", "error", "src/Form%20test.tsx", &sarifRegion{StartLine: 14, StartColumn: 3}, 0, "failed in all 10 runs"},
+	}
+	for _, tt := range tests {
+		r := run.Results[tt.index]
+		loc := r.Locations[0].PhysicalLocation
+		if r.RuleID != tt.ruleID || r.Level != tt.level || loc.ArtifactLocation.URI != tt.uri {
+			t.Errorf("result %d = %s %s %s, want %s %s %s", tt.index, r.RuleID, r.Level, loc.ArtifactLocation.URI, tt.ruleID, tt.level, tt.uri)
+		}
+		if (loc.Region == nil) != (tt.region == nil) || (loc.Region != nil && *loc.Region != *tt.region) {
+			t.Errorf("result %d region = %+v, want %+v", tt.index, loc.Region, tt.region)
+		}
+		if len(r.RelatedLocations) != tt.related {
+			t.Errorf("result %d has %d related locations, want %d", tt.index, len(r.RelatedLocations), tt.related)
+		}
+		if !strings.Contains(r.Message.Text, tt.inMessage) {
+			t.Errorf("result %d message %q does not contain %q", tt.index, r.Message.Text, tt.inMessage)
+		}
+	}
+}
+
+func TestSARIFSchemaRejectsInvalidOutput(t *testing.T) {
+	data, err := os.ReadFile(sarifSchemaPath)
+	if err != nil {
+		t.Fatalf("failed to read SARIF schema: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("failed to parse SARIF schema: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		log  string
+		want string
+	}{
+		{"missing runs", `{"version": "2.1.0"}`, `missing required property "runs"`},
+		{"wrong version", `{"version": "2.0.0", "runs": []}`, "is not one of"},
+		{"unknown level", `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "x"}}, "results": [{"message": {"text": "m"}, "level": "fatal"}]}]}`, "$.runs[0].results[0].level"},
+		{"extra property", `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "x"}}, "results": [{"message": {"text": "m"}, "severity": 1}]}]}`, `property "severity" is not allowed`},
+		{"zero start line", `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "x"}}, "results": [{"message": {"text": "m"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "a.js"}, "region": {"startLine": 0}}}]}]}]}`, "is less than 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log any
+			if err := json.Unmarshal([]byte(tt.log), &log); err != nil {
+				t.Fatal(err)
+			}
+			errs := validateJSONSchema(schema, schema, "$", log)
+			if !slices.ContainsFunc(errs, func(e string) bool { return strings.Contains(e, tt.want) }) {
+				t.Errorf("errors = %q, want one containing %q", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestSARIFRelativizesAbsoluteTestIDs(t *testing.T) {
+	// Jest test IDs carry the absolute path of the test file
+	report := &model.Report{
+		ProjectRoot: "/work/app",
+		Tests: []model.AggregatedTest{{
+			TestID:         "/work/app/src/sum.test.js::sum adds",
+			Classification: model.ClassificationFlaky,
+			FailCount:      2,
+			TotalRuns:      10,
+			FlakeRate:      0.2,
+			FailureClusters: []model.FailureCluster{{Signature: model.SignatureAssertion, Count: 2, FirstRun: 1, LastRun: 4,
+				Message: "expected 3", Location: &model.SourceLocation{File: "src/sum.test.js", Line: 7, Column: 5}}},
+		}},
+	}
+
+	data, err := MarshalSARIF(report)
+	if err != nil {
+		t.Fatalf("MarshalSARIF failed: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("failed to decode SARIF output: %v", err)
+	}
+	r := log.Runs[0].Results[0]
+	loc := r.Locations[0].PhysicalLocation
+	if loc.ArtifactLocation.URI != "src/sum.test.js" {
+		t.Errorf("uri = %q, want src/sum.test.js", loc.ArtifactLocation.URI)
+	}
+	if loc.Region == nil || loc.Region.StartLine != 7 {
+		t.Errorf("region = %+v, want line 7", loc.Region)
+	}
+	if len(r.RelatedLocations) != 0 {
+		t.Errorf("got %d related locations, want none", len(r.RelatedLocations))
+	}
+}
+
+func TestSARIFRuleName(t *testing.T) {
+	tests := []struct {
+		sig  model.FailureSignature
+		want string
+	}{
+		{model.SignatureTimeout, "FlakyTimeout"},
+		{model.SignatureDOMDetach, "FlakyDomDetach"},
+		{"PRISMA", "FlakyPrisma"},
+	}
+	for _, tt := range tests {
+		if got := sarifRuleName(tt.sig); got != tt.want {
+			t.Errorf("sarifRuleName(%s) = %q, want %q", tt.sig, got, tt.want)
+		}
+	}
+}
+
+func TestGitHubActions(t *testing.T) {
+	summaryPath := filepath.Join(t.TempDir(), "step_summary.md")
+	if err := os.WriteFile(summaryPath, []byte("## Earlier step\n\n"), 0644); err != nil {
+		t.Fatalf("failed to write step summary: %v", err)
+	}
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+
+	report := fixtureReport()
+	report.Tests = append(report.Tests, model.AggregatedTest{
+		TestID:         "src/form.test.tsx::Form submits, then resets: 100%",
+		Classification: model.ClassificationDeterministicFail,
+		FailCount:      10,
+		TotalRuns:      10,
+		FailureClusters: []model.FailureCluster{{Signature: model.SignatureAssertion, Count: 10, FirstRun: 1, LastRun: 10,
+			RunIndices: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, Message: "expected 1\nreceived 2", Location: &model.SourceLocation{File: "src/form.test.tsx", Line: 7, Column: 9}}},
+	})
+
+	if !InGitHubActions() {
+		t.Fatal("InGitHubActions() = false with GITHUB_ACTIONS=true")
+	}
+	var buf bytes.Buffer
+	if err := WriteGitHubActions(&buf, report); err != nil {
+		t.Fatalf("WriteGitHubActions failed: %v", err)
+	}
+
+	wantAnnotations := []string{
+		"::error file=src/form.test.tsx,line=7,col=9,title=Failing test%3A Form submits%2C then resets%3A 100%25::failed in all 10 runs%0A[ASSERTION] 10 failures (runs 1, 2, 3, 4, 5, 6, 7, 8, 9, 10) at src/form.test.tsx:7:9: expected 1",
+		"::warning file=src/components/Button.test.tsx,title=Flaky test%3A Button should submit form::5/10 failed (flake rate 50.0%25)%0A[NETWORK] 5 failures (runs 1, 3, 4, 7, 9): Network error: ECONNREFUSED",
+		"::warning file=src/components/Button.test.tsx,title=Flaky test%3A Button should handle click::3/10 failed (flake rate 30.0%25)%0A[SELECTOR] 2 failures (runs 2, 5): Unable to find element with text 'Click me'%0A[TIMEOUT] 1 failure (run 8) at src/test-utils.ts:12:5: Timeout waiting for element",
+	}
+	if got := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n"); !slices.Equal(got, wantAnnotations) {
+		t.Errorf("annotations =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(wantAnnotations, "\n"))
+	}
+
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("failed to read step summary: %v", err)
+	}
+	summary := string(data)
+	if !strings.HasPrefix(summary, "## Earlier step\n\n# Flakehunt Report\n\n## Summary\n") {
+		t.Errorf("step summary does not append the report:\n%s", summary)
+	}
+	if !strings.Contains(summary, "## Top Flakes") {
+		t.Error("step summary is missing the top flakes")
+	}
+	for _, unwanted := range []string{"## All Tests", "[Full failure log]"} {
+		if strings.Contains(summary, unwanted) {
+			t.Errorf("step summary contains %q", unwanted)
+		}
+	}
+}
+
+func TestGitHubAnnotationsRelativeToWorkspace(t *testing.T) {
+	// The project is a subdirectory of the checkout
+	t.Setenv("GITHUB_WORKSPACE", "/work")
+	report := &model.Report{
+		ProjectRoot: "/work/web",
+		Tests: []model.AggregatedTest{{
+			TestID:         "/work/web/src/sum.test.js::sum adds",
+			Classification: model.ClassificationFlaky,
+			FailureClusters: []model.FailureCluster{{Signature: model.SignatureAssertion, Count: 1, FirstRun: 1, LastRun: 1,
+				Message: "expected 3", Location: &model.SourceLocation{File: "src/sum.test.js", Line: 7}}},
+		}},
+	}
+
+	var buf bytes.Buffer
+	RenderGitHubAnnotations(&buf, report)
+	if want := "::warning file=web/src/sum.test.js,line=7,"; !strings.HasPrefix(buf.String(), want) {
+		t.Errorf("annotation = %q, want prefix %q", buf.String(), want)
+	}
+}
+
+func TestGitHubAnnotationsLimited(t *testing.T) {
+	report := &model.Report{}
+	for i := range maxGitHubAnnotations + 3 {
+		report.Tests = append(report.Tests, model.AggregatedTest{
+			TestID:         fmt.Sprintf("a.test.js::test %02d", i),
+			Classification: model.ClassificationFlaky,
+			WastedTime:     time.Duration(i) * time.Millisecond,
+		})
+	}
+
+	var buf bytes.Buffer
+	RenderGitHubAnnotations(&buf, report)
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != maxGitHubAnnotations+1 {
+		t.Fatalf("got %d lines, want %d annotations and a notice", len(lines), maxGitHubAnnotations)
+	}
+	if !strings.Contains(lines[0], "title=Flaky test%3A test 12::") {
+		t.Errorf("first annotation = %q, want the most wasteful test", lines[0])
+	}
+	if want := "::notice title=flakehunt::3 more flaky tests are not annotated; see the flakehunt report."; lines[maxGitHubAnnotations] != want {
+		t.Errorf("notice = %q, want %q", lines[maxGitHubAnnotations], want)
+	}
+}
+
+func TestTestFileLocation(t *testing.T) {
+	loc := &model.SourceLocation{File: "src/a.test.js", Line: 5, Column: 17}
+	tests := []struct {
+		name     string
+		testID   string
+		root     string
+		wantFile string
+		wantLoc  *model.SourceLocation
+	}{
+		{"relative", "src/a.test.js::works", "", "src/a.test.js", loc},
+		{"absolute inside root", "/app/src/a.test.js::works", "/app", "src/a.test.js", loc},
+		{"absolute outside root", "/other/src/a.test.js::works", "/app", "/other/src/a.test.js", nil},
+		{"other file", "src/b.test.js::works", "/app", "src/b.test.js", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := model.AggregatedTest{TestID: tt.testID, FailureClusters: []model.FailureCluster{{Location: loc}}}
+			file, got := testFileLocation(test, tt.root)
+			if file != tt.wantFile || got != tt.wantLoc {
+				t.Errorf("testFileLocation() = %q, %v, want %q, %v", file, got, tt.wantFile, tt.wantLoc)
+			}
+		})
+	}
+}
+
+func TestRenderComment(t *testing.T) {
+	got := RenderComment(fixtureReport(), "https://ci.example.com/runs/7")
+	for _, want := range []string{
+		"## Flakehunt: 2 flaky tests\n\n10 runs of `src/components/Button.test.tsx` with jest: 2 flaky, 0 failing, 1 stable.\n",
+		"| src/components/Button.test.tsx::Button should submit form | 50.0% | 5/10 failed | 1.0s | [NETWORK] Network error: ECONNREFUSED |\n",
+		"[Full report and run artifacts](https://ci.example.com/runs/7)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("comment missing %q:\n%s", want, got)
+		}
+	}
+
+	stable := RenderComment(&model.Report{Tool: "jest", Target: "npx jest", RunsExecuted: 1, StableCount: 4}, "")
+	if want := "## Flakehunt: all tests stable\n\n1 run of `npx jest` with jest: 0 flaky, 0 failing, 4 stable.\n\n"; stable != want {
+		t.Errorf("stable comment = %q, want %q", stable, want)
+	}
+}
+
+func TestIssueOutputGolden(t *testing.T) {
+	report := fixtureReport()
+	report.Command = []string{"npx", "jest", "--config", "jest config.js", "--testNamePattern", "it's flaky"}
+	flake := report.TopFlakes[0]
+
+	tests := []struct {
+		name   string
+		render func(*model.Report, model.AggregatedTest) string
+		golden string
+	}{
+		{"markdown", RenderIssue, "issue_output.golden"},
+		{"jira", RenderJiraIssue, "jira_issue_output.golden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.render(report, flake)
+			goldenPath := filepath.Join("testdata", tt.golden)
+
+			if os.Getenv("UPDATE_GOLDEN") == "1" {
+				if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
+					t.Fatalf("failed to write golden file: %v", err)
+				}
+				t.Logf("Updated golden file: %s", goldenPath)
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("failed to read golden file %s: %v\nRun with UPDATE_GOLDEN=1 to create it", goldenPath, err)
+			}
+			if got != string(want) {
+				t.Errorf("issue output mismatch.\n\nGot:\n%s\n\nWant:\n%s", got, string(want))
+			}
+		})
+	}
+}
+
+func TestIssueTitleAndFingerprint(t *testing.T) {
+	test := model.AggregatedTest{TestID: "/work/shop/src/a.test.js::A works"}
+	report := &model.Report{ProjectRoot: "/work/shop"}
+	if got, want := IssueTitle(report, test), "Flaky test: src/a.test.js::A works"; got != want {
+		t.Errorf("IssueTitle() = %q, want %q", got, want)
+	}
+
+	fp := TestFingerprint(test.TestID, report.ProjectRoot)
+	if len(fp) != 12 || fp != TestFingerprint("/work/shop/src/a.test.js::A works", "/work/shop") {
+		t.Errorf("TestFingerprint() = %q, want 12 stable hex characters", fp)
+	}
+	// Another checkout of the same project
+	if got := TestFingerprint("/ci/build/src/a.test.js::A works", "/ci/build"); got != fp {
+		t.Errorf("TestFingerprint() in another checkout = %q, want %q", got, fp)
+	}
+	if fp == TestFingerprint("/work/shop/src/a.test.js::A works again", "/work/shop") {
+		t.Error("different tests share a fingerprint")
+	}
+}
+
+func TestReproduceCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		tool    string
+		command []string
+		test    model.AggregatedTest
+		want    string
+	}{
+		{
+			name:    "jest test",
+			tool:    "jest",
+			command: []string{"npx", "jest", "-t=login", "--ci"},
+			test:    model.AggregatedTest{TestID: "/work/shop/src/login.test.js::login (admin) works"},
+			want:    `flakehunt --runs 5 -- npx jest --ci src/login.test.js -t '^login \(admin\) works$'`,
+		},
+		{
+			name:    "jest suite setup",
+			tool:    "jest",
+			command: []string{"npx", "jest"},
+			test:    model.AggregatedTest{TestID: "/work/shop/src/db.test.js::<suite setup>", Suite: true},
+			want:    "flakehunt --runs 5 -- npx jest src/db.test.js",
+		},
+		{
+			name:    "cypress test",
+			tool:    "cypress",
+			command: []string{"npx", "cypress", "run", "--spec", "cypress/e2e/**", "--browser", "chrome"},
+			test:    model.AggregatedTest{TestID: "cypress/e2e/cart.cy.ts::cart -- adds an item"},
+			want:    "flakehunt --runs 5 -- npx cypress run --browser chrome --spec cypress/e2e/cart.cy.ts",
+		},
+		{
+			name:    "cypress spec flag with value",
+			tool:    "cypress",
+			command: []string{"npx", "cypress", "run", "--spec=cypress/e2e/**"},
+			test:    model.AggregatedTest{TestID: "cypress/e2e/cart.cy.ts::cart -- adds an item"},
+			want:    "flakehunt --runs 5 -- npx cypress run --spec cypress/e2e/cart.cy.ts",
+		},
+		{
+			name:    "unknown tool",
+			tool:    "mocha",
+			command: []string{"npx", "mocha"},
+			test:    model.AggregatedTest{TestID: "test/a.js::a works"},
+			want:    "flakehunt --runs 5 -- npx mocha",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &model.Report{Tool: tt.tool, RunsExecuted: 5, ProjectRoot: "/work/shop", Command: tt.command}
+			if got := reproduceCommand(report, tt.test); got != tt.want {
+				t.Errorf("reproduceCommand() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct{ arg, want string }{
+		{"npx", "npx"},
+		{"--spec=cypress/e2e/login.cy.js", "--spec=cypress/e2e/login.cy.js"},
+		{"two words", "'two words'"},
+		{"it's", `'it'\''s'`},
+		{"", "''"},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.arg); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// SARIF 2.1.0 identifiers.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifSrcRoot is the base of result locations: test files and stack
+	// frames are relative to the project root.
+	sarifSrcRoot = "%SRCROOT%"
+)
+
+// Flake rates at which flaky tests are reported as errors and warnings;
+// less flaky tests are notes. Deterministic failures are always errors.
+const (
+	sarifErrorRate   = 0.5
+	sarifWarningRate = 0.1
+)
+
+// SARIF log elements, limited to the properties flakehunt sets.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	RelatedLocations    []sarifLocation   `json:"relatedLocations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]any    `json:"properties"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// WriteSARIF writes the report as a SARIF log to the specified output directory.
+// The file is written to <outDir>/report.sarif
+func WriteSARIF(outDir string, report *model.Report) error {
+	if report == nil {
+		return fmt.Errorf("report is required")
+	}
+
+	// Ensure output directory exists
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory %s: %w", outDir, err)
+	}
+
+	path := filepath.Join(outDir, "report.sarif")
+
+	data, err := MarshalSARIF(report)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// MarshalSARIF returns the report as a SARIF 2.1.0 log with a result for
+// each flaky or deterministically failing test. Each failure signature is a
+// rule; a test's result uses the signature of its most common failure mode.
+func MarshalSARIF(report *model.Report) ([]byte, error) {
+	if report == nil {
+		return nil, fmt.Errorf("report is required")
+	}
+
+	var failing []model.AggregatedTest
+	for _, test := range report.Tests {
+		if test.Classification == model.ClassificationFlaky || test.Classification == model.ClassificationDeterministicFail {
+			failing = append(failing, test)
+		}
+	}
+
+	// Rules are sorted by signature so that rule indices are stable
+	ruleIndex := make(map[model.FailureSignature]int)
+	var signatures []model.FailureSignature
+	for _, test := range failing {
+		sig := sarifSignature(test)
+		if _, ok := ruleIndex[sig]; !ok {
+			ruleIndex[sig] = 0
+			signatures = append(signatures, sig)
+		}
+	}
+	sort.Slice(signatures, func(i, j int) bool { return signatures[i] < signatures[j] })
+	rules := make([]sarifRule, len(signatures))
+	for i, sig := range signatures {
+		ruleIndex[sig] = i
+		rules[i] = sarifRule{
+			ID:               string(sig),
+			Name:             sarifRuleName(sig),
+			ShortDescription: sarifMessage{Text: fmt.Sprintf("Test fails with a %s failure", sig)},
+			FullDescription: sarifMessage{Text: fmt.Sprintf("The test failed in repeated runs of the same code "+
+				"with a failure classified as %s. Flaky tests pass on some runs and fail on others.", sig)},
+		}
+	}
+
+	results := make([]sarifResult, 0, len(failing))
+	for _, test := range failing {
+		sig := sarifSignature(test)
+		results = append(results, sarifResult{
+			RuleID:    string(sig),
+			RuleIndex: ruleIndex[sig],
+			Level:     sarifLevel(test),
+			Message:   sarifMessage{Text: sarifResultMessage(test)},
+			Locations: []sarifLocation{sarifTestLocation(test, report.ProjectRoot)},
+			// Stack frames outside the test file, such as shared helpers
+			RelatedLocations: sarifRelatedLocations(test, report.ProjectRoot),
+			PartialFingerprints: map[string]string{
+				"flakehuntTestId/v1": test.TestID,
+			},
+			Properties: map[string]any{
+				"classification": test.Classification,
+				"flakeRate":      test.FlakeRate,
+				"passCount":      test.PassCount,
+				"failCount":      test.FailCount,
+				"skipCount":      test.SkipCount,
+				"runs":           test.TotalRuns + test.SkipCount,
+			},
+		})
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "flakehunt",
+				InformationURI: "https://github.com/boyarskiy/flakehunt",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal SARIF report: %w", err)
+	}
+	return data, nil
+}
+
+// sarifSignature returns the signature of a test's most common failure mode.
+func sarifSignature(test model.AggregatedTest) model.FailureSignature {
+	if len(test.FailureClusters) == 0 {
+		return model.SignatureUnknown
+	}
+	return test.FailureClusters[0].Signature
+}
+
+// sarifRuleName returns a rule name in the PascalCase SARIF recommends,
+// such as FlakyDomDetach for DOM_DETACH.
+func sarifRuleName(sig model.FailureSignature) string {
+	var sb strings.Builder
+	sb.WriteString("Flaky")
+	for _, word := range strings.FieldsFunc(strings.ToLower(string(sig)), func(r rune) bool {
+		return r == '_' || r == '-' || r == ' '
+	}) {
+		sb.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return sb.String()
+}
+
+// sarifLevel returns the severity of a test's result.
+func sarifLevel(test model.AggregatedTest) string {
+	switch {
+	case test.Classification == model.ClassificationDeterministicFail:
+		return "error"
+	case test.FlakeRate >= sarifErrorRate:
+		return "error"
+	case test.FlakeRate >= sarifWarningRate:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifResultMessage describes how a test failed.
+func sarifResultMessage(test model.AggregatedTest) string {
+	var sb strings.Builder
+	if test.Classification == model.ClassificationDeterministicFail {
+		sb.WriteString(fmt.Sprintf("Test %q failed in all %d runs.", test.TestID, test.TotalRuns))
+	} else {
+		sb.WriteString(fmt.Sprintf("Flaky test %q %s (flake rate %.1f%%).", test.TestID, formatFailCount(test), test.FlakeRate*100))
+	}
+	for _, c := range test.FailureClusters {
+		sb.WriteString(fmt.Sprintf("\n[%s] %d %s (%s): %s", c.Signature, c.Count, pluralize(c.Count, "failure", "failures"),
+			formatClusterRuns(c), clusterHeadline(c)))
+	}
+	return sb.String()
+}
+
+// sarifTestLocation returns the location of a test: its file, and the line
+// of the first failure location inside that file, if any.
+func sarifTestLocation(test model.AggregatedTest, projectRoot string) sarifLocation {
+	file, line := testFileLocation(test, projectRoot)
+	loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{URI: sarifURI(file), URIBaseID: sarifSrcRoot},
+	}}
+	if line != nil {
+		loc.PhysicalLocation.Region = sarifRegionOf(line)
+	}
+	return loc
+}
+
+// testFileLocation returns the file of a test, relative to the project root
+// when inside it, and the first failure location inside that file, or nil if
+// no stack trace points into it.
+func testFileLocation(test model.AggregatedTest, projectRoot string) (string, *model.SourceLocation) {
+	file := testFile(test.TestID, projectRoot)
+	for _, c := range test.FailureClusters {
+		if c.Location != nil && sameFile(c.Location.File, file) {
+			return file, c.Location
+		}
+	}
+	return file, nil
+}
+
+// testFile returns the file part of a test ID, relative to projectRoot if it
+// is an absolute path inside it.
+func testFile(testID, projectRoot string) string {
+	file, _, _ := strings.Cut(model.RelativeTestID(testID, projectRoot), "::")
+	return file
+}
+
+// sameFile reports whether two project-relative paths name the same file.
+func sameFile(a, b string) bool {
+	return path.Clean(filepath.ToSlash(a)) == path.Clean(filepath.ToSlash(b))
+}
+
+// sarifRelatedLocations returns the failure locations of a test outside its
+// test file.
+func sarifRelatedLocations(test model.AggregatedTest, projectRoot string) []sarifLocation {
+	file := testFile(test.TestID, projectRoot)
+	var related []sarifLocation
+	for _, c := range test.FailureClusters {
+		if c.Location == nil || sameFile(c.Location.File, file) {
+			continue
+		}
+		related = append(related, sarifLocation{
+			ID: len(related) + 1,
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(c.Location.File), URIBaseID: sarifSrcRoot},
+				Region:           sarifRegionOf(c.Location),
+			},
+			Message: &sarifMessage{Text: fmt.Sprintf("[%s] %s", c.Signature, clusterHeadline(c))},
+		})
+	}
+	return related
+}
+
+// sarifRegionOf returns the region of a source location, or nil if the line
+// is unknown.
+func sarifRegionOf(loc *model.SourceLocation) *sarifRegion {
+	if loc.Line < 1 {
+		return nil
+	}
+	return &sarifRegion{StartLine: loc.Line, StartColumn: loc.Column}
+}
+
+// sarifURI returns a relative URI reference for a file path.
+func sarifURI(path string) string {
+	return markdownURL(strings.TrimPrefix(filepath.ToSlash(path), "./"))
+}
@@ -4,13 +4,19 @@ package report
 import (
 	"fmt"
 	"io"
+	"path/filepath"
 	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/boyarskiy/flakehunt/internal/classify"
 	"github.com/boyarskiy/flakehunt/internal/model"
 )
 
+// maxTerminalClusters is the number of failure modes shown per test in the terminal.
+const maxTerminalClusters = 3
+
 // TerminalConfig holds configuration for terminal output.
 type TerminalConfig struct {
 	Writer  io.Writer
@@ -53,6 +59,9 @@ func RenderTerminal(cfg *TerminalConfig, report *model.Report, artifactPath stri
 
 	// Runs executed
 	fmt.Fprintf(w, "Runs Executed: %d\n", report.RunsExecuted)
+	if report.TestTimeout > 0 {
+		fmt.Fprintf(w, "Test Timeout:  %s\n", formatTestTimeout(report))
+	}
 	fmt.Fprintln(w)
 
 	// Counts
@@ -62,6 +71,13 @@ func RenderTerminal(cfg *TerminalConfig, report *model.Report, artifactPath stri
 	fmt.Fprintf(w, "  Stable:             %d\n", report.StableCount)
 	fmt.Fprintln(w)
 
+	if report.FlakySuiteCount > 0 || report.DetFailSuiteCount > 0 {
+		fmt.Fprintln(w, "Suite Setup Failures:")
+		fmt.Fprintf(w, "  Flaky:              %d\n", report.FlakySuiteCount)
+		fmt.Fprintf(w, "  Deterministic Fail: %d\n", report.DetFailSuiteCount)
+		fmt.Fprintln(w)
+	}
+
 	// Top flakes
 	if len(report.TopFlakes) > 0 {
 		fmt.Fprintln(w, "Top Flakes (by wasted time):")
@@ -72,24 +88,33 @@ func RenderTerminal(cfg *TerminalConfig, report *model.Report, artifactPath stri
 		for i := 0; i < displayed; i++ {
 			flake := report.TopFlakes[i]
 			fmt.Fprintf(w, "  %d. %s\n", i+1, flake.TestID)
-			fmt.Fprintf(w, "     Flake Rate: %.1f%% (%d/%d failed)\n",
-				flake.FlakeRate*100, flake.FailCount, flake.TotalRuns)
+			fmt.Fprintf(w, "     Flake Rate: %.1f%% (%s)\n", flake.FlakeRate*100, formatFailCount(flake))
 			fmt.Fprintf(w, "     Wasted Time: %s\n", formatDuration(flake.WastedTime))
+			if flake.RetriedRuns > 0 {
+				fmt.Fprintf(w, "     Attempts: %d in %d runs (%d retried)\n", flake.Attempts, flake.TotalRuns, flake.RetriedRuns)
+			}
 
 			// Show failure evidence
 			if len(flake.FailureEvidence) > 0 {
-				runIndices := make([]int, 0, len(flake.FailureEvidence))
-				for _, ev := range flake.FailureEvidence {
-					runIndices = append(runIndices, ev.RunIndex)
-				}
-				sort.Ints(runIndices)
-				fmt.Fprintf(w, "     Failed Runs: %s\n", formatRunIndices(runIndices))
-
-				// Show first excerpt (truncated)
-				excerpt := flake.FailureEvidence[0].Excerpt
-				if excerpt != "" {
-					truncated := truncateForTerminal(excerpt, 80)
-					fmt.Fprintf(w, "     Excerpt: %s\n", truncated)
+				fmt.Fprintf(w, "     Failed Runs: %s\n", formatRunIndices(failedRuns(flake)))
+			}
+			if patterns := formatTemporalPatterns(flake.Temporal); patterns != "" {
+				fmt.Fprintf(w, "     Pattern: %s\n", patterns)
+			}
+
+			// Show the most common failure modes
+			if len(flake.FailureClusters) > 0 {
+				fmt.Fprintf(w, "     %s:\n", formatFailureModeCount(len(flake.FailureClusters)))
+				for j, c := range flake.FailureClusters {
+					if j == maxTerminalClusters {
+						fmt.Fprintf(w, "       ... and %d more\n", len(flake.FailureClusters)-j)
+						break
+					}
+					fmt.Fprintf(w, "       %dx %s (%s): %s\n",
+						c.Count, c.Signature, formatClusterRuns(c), truncateForTerminal(formatClusterMessage(c), 80))
+					if c.Location != nil {
+						fmt.Fprintf(w, "          at %s\n", c.Location)
+					}
 				}
 			}
 			fmt.Fprintln(w)
@@ -99,6 +124,75 @@ func RenderTerminal(cfg *TerminalConfig, report *model.Report, artifactPath stri
 		fmt.Fprintln(w)
 	}
 
+	// Flaky suite setup
+	if suites := classify.FilterByClassification(classify.FilterSuites(report.Tests), model.ClassificationFlaky); len(suites) > 0 {
+		fmt.Fprintln(w, "Flaky Suite Setup (file load, beforeAll and afterAll):")
+		for i, suite := range suites {
+			if i == topN {
+				fmt.Fprintf(w, "  ... and %d more\n", len(suites)-i)
+				break
+			}
+			fmt.Fprintf(w, "  %s\n", suiteFile(suite.TestID))
+			fmt.Fprintf(w, "     Flake Rate: %.1f%% (%d/%d failed)\n",
+				suite.FlakeRate*100, suite.FailCount, suite.TotalRuns)
+			fmt.Fprintf(w, "     Failed Runs: %s\n", formatRunIndices(failedRuns(suite)))
+			if len(suite.FailureClusters) > 0 {
+				c := suite.FailureClusters[0]
+				fmt.Fprintf(w, "     %s: %s\n", c.Signature, truncateForTerminal(formatClusterMessage(c), 80))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Co-failing tests
+	if len(report.CoFailureGroups) > 0 {
+		fmt.Fprintln(w, "Co-failing Tests (likely a shared root cause):")
+		for i, group := range report.CoFailureGroups {
+			if i == topN {
+				fmt.Fprintf(w, "  ... and %d more groups\n", len(report.CoFailureGroups)-i)
+				break
+			}
+			fmt.Fprintf(w, "  %d. %d tests failed together in runs %s (%s)\n",
+				i+1, len(group.TestIDs), formatRunIndices(group.RunIndices), group.Signature)
+			for _, id := range group.TestIDs {
+				fmt.Fprintf(w, "     %s\n", id)
+			}
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Performance flakes
+	if slow := classify.FilterSlowUnstable(report.Tests); len(slow) > 0 {
+		fmt.Fprintln(w, "Performance Flakes (passing, but durations vary widely):")
+		for i, test := range slow {
+			if i == topN {
+				fmt.Fprintf(w, "  ... and %d more\n", len(slow)-i)
+				break
+			}
+			stats := test.DurationStats
+			fmt.Fprintf(w, "  %s\n", test.TestID)
+			fmt.Fprintf(w, "     Median %s, p95 %s, max %s (%.1fx median)\n",
+				formatDuration(stats.Median), formatDuration(stats.P95), formatDuration(stats.Max), stats.MaxMedianRatio)
+		}
+		fmt.Fprintln(w)
+	}
+
+	// Timeout risks
+	if risky := classify.FilterTimeoutRisk(report.Tests); len(risky) > 0 {
+		fmt.Fprintln(w, "Timeout Risk (p95 duration close to the timeout):")
+		for i, test := range risky {
+			if i == topN {
+				fmt.Fprintf(w, "  ... and %d more\n", len(risky)-i)
+				break
+			}
+			stats := test.DurationStats
+			fmt.Fprintf(w, "  %s\n", test.TestID)
+			fmt.Fprintf(w, "     p95 %s of %s timeout (%.0f%%)\n",
+				formatDuration(stats.P95), formatDuration(stats.Timeout), stats.TimeoutShare*100)
+		}
+		fmt.Fprintln(w)
+	}
+
 	// Signature summary
 	if len(report.SignatureSummary) > 0 {
 		fmt.Fprintln(w, "Failure Signatures:")
@@ -110,6 +204,15 @@ func RenderTerminal(cfg *TerminalConfig, report *model.Report, artifactPath stri
 		fmt.Fprintln(w)
 	}
 
+	// Infrastructure errors
+	if len(report.InfraErrors) > 0 {
+		fmt.Fprintf(w, "Infrastructure Errors: %d\n", len(report.InfraErrors))
+		for _, ie := range report.InfraErrors {
+			fmt.Fprintf(w, "  %s: %s\n", formatInfraErrorSource(ie), truncateForTerminal(ie.Message, 120))
+		}
+		fmt.Fprintln(w)
+	}
+
 	// Artifact path
 	fmt.Fprintf(w, "Artifacts: %s\n", artifactPath)
 	fmt.Fprintln(w)
@@ -152,6 +255,112 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%.1fm", mins)
 }
 
+// formatInfraErrorSource describes where an infrastructure error occurred.
+func formatInfraErrorSource(ie model.InfraError) string {
+	if ie.RunIndex == 0 {
+		return fmt.Sprintf("Session [%s]", ie.Stage)
+	}
+	return fmt.Sprintf("Run %d [%s]", ie.RunIndex, ie.Stage)
+}
+
+// formatFailureModeCount describes the number of distinct failure modes of a test.
+func formatFailureModeCount(n int) string {
+	if n == 1 {
+		return "1 failure mode"
+	}
+	return fmt.Sprintf("%d distinct failure modes", n)
+}
+
+// formatClusterRuns lists the runs in which a failure mode occurred.
+func formatClusterRuns(c model.FailureCluster) string {
+	if len(c.RunIndices) <= 1 {
+		return fmt.Sprintf("run %d", c.FirstRun)
+	}
+	return "runs " + formatRunIndices(c.RunIndices)
+}
+
+// formatClusterMessage returns the representative message of a failure mode.
+func formatClusterMessage(c model.FailureCluster) string {
+	if c.Message == "" {
+		return "(no failure message)"
+	}
+	return c.Message
+}
+
+// formatSnippet formats snippet lines with line numbers, marking the failing line with ">".
+func formatSnippet(snippet *model.CodeSnippet) []string {
+	lastLine := snippet.StartLine + len(snippet.Lines) - 1
+	width := len(fmt.Sprintf("%d", lastLine))
+
+	lines := make([]string, len(snippet.Lines))
+	for i, line := range snippet.Lines {
+		num := snippet.StartLine + i
+		marker := " "
+		if num == snippet.Highlight {
+			marker = ">"
+		}
+		lines[i] = strings.TrimRight(fmt.Sprintf("%s %*d | %s", marker, width, num, line), " ")
+	}
+	return lines
+}
+
+// snippetLanguage returns the Markdown code block language for a source file.
+func snippetLanguage(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".js", ".jsx", ".mjs", ".cjs":
+		return "js"
+	case ".ts", ".tsx", ".mts", ".cts":
+		return "ts"
+	default:
+		return ""
+	}
+}
+
+// formatTestTimeout describes the session-wide test timeout and its source.
+func formatTestTimeout(report *model.Report) string {
+	if report.TestTimeoutSource == "" {
+		return formatDuration(report.TestTimeout)
+	}
+	return fmt.Sprintf("%s (%s)", formatDuration(report.TestTimeout), report.TestTimeoutSource)
+}
+
+// formatFailCount describes how often a test failed, including runs in which
+// it failed an attempt and passed on retry.
+func formatFailCount(test model.AggregatedTest) string {
+	if test.FlakyInRunCount == 0 {
+		return fmt.Sprintf("%d/%d failed", test.FailCount, test.TotalRuns)
+	}
+	return fmt.Sprintf("%d/%d failed, %d flaky on retry", test.FailCount, test.TotalRuns, test.FlakyInRunCount)
+}
+
+// suiteFile returns the test file of a suite setup result.
+func suiteFile(testID string) string {
+	return strings.TrimSuffix(testID, "::"+model.SuiteSetupName)
+}
+
+// formatTemporalPatterns returns the labels of a test's run-order patterns,
+// or an empty string if none were detected.
+func formatTemporalPatterns(temporal *model.TemporalAnalysis) string {
+	if temporal == nil {
+		return ""
+	}
+	labels := make([]string, 0, len(temporal.Patterns))
+	for _, p := range temporal.Patterns {
+		labels = append(labels, p.Label())
+	}
+	return strings.Join(labels, ", ")
+}
+
+// failedRuns returns the sorted indices of the runs in which a test failed.
+func failedRuns(test model.AggregatedTest) []int {
+	indices := make([]int, 0, len(test.FailureEvidence))
+	for _, ev := range test.FailureEvidence {
+		indices = append(indices, ev.RunIndex)
+	}
+	sort.Ints(indices)
+	return indices
+}
+
 // formatRunIndices formats a slice of run indices as a comma-separated string.
 func formatRunIndices(indices []int) string {
 	if len(indices) == 0 {
@@ -175,8 +384,9 @@ func truncateForTerminal(s string, maxLen int) string {
 	}
 	s = strings.TrimSpace(s)
 
-	if len(s) <= maxLen {
+	// Cut on a rune boundary so multi-byte characters are not split
+	if utf8.RuneCountInString(s) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
+	return string([]rune(s)[:maxLen-3]) + "..."
 }
@@ -0,0 +1,256 @@
+package runner
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// failuresDirName is the run subdirectory holding full failure logs.
+const failuresDirName = "failures"
+
+// maxLogNameLen bounds the test-derived part of failure log file names.
+const maxLogNameLen = 80
+
+// writeFailureLogs writes the full failure text of each failed test in a run,
+// and of each test that passed on retry, with its stack trace and the slice
+// of output the adapter attributes to it, to <runDir>/failures/<test>.txt.
+// LogPath of each such test is set relative to latestDir.
+func writeFailureLogs(cfg *Config, latestDir, runDir string, result *model.RunResult) error {
+	slicer, canSlice := cfg.Adapter.(model.OutputSlicer)
+	var stdout, stderr string
+	outputRead := false
+
+	for i := range result.Tests {
+		test := &result.Tests[i]
+		if test.Outcome != model.OutcomeFail && !test.FlakyInRun {
+			continue
+		}
+
+		failuresDir := filepath.Join(runDir, failuresDirName)
+		if err := os.MkdirAll(failuresDir, 0755); err != nil {
+			return fmt.Errorf("failed to create failures directory %s: %w", failuresDir, err)
+		}
+
+		var output string
+		if canSlice {
+			// Captured output is only read when a run has failures
+			if !outputRead {
+				stdout = readOutput(filepath.Join(runDir, "stdout.txt"))
+				stderr = readOutput(filepath.Join(runDir, "stderr.txt"))
+				outputRead = true
+			}
+			output = slicer.SliceOutput(*test, stdout, stderr)
+		}
+
+		path := filepath.Join(failuresDir, failureLogName(test.TestID))
+		if err := os.WriteFile(path, []byte(formatFailureLog(result.RunIndex, *test, output)), 0644); err != nil {
+			return fmt.Errorf("failed to write failure log %s: %w", path, err)
+		}
+
+		rel, err := filepath.Rel(latestDir, path)
+		if err != nil {
+			return fmt.Errorf("failed to resolve failure log path %s: %w", path, err)
+		}
+		test.LogPath = filepath.ToSlash(rel)
+	}
+
+	return nil
+}
+
+// resolveMediaPaths rewrites the screenshot and video paths of a run's tests,
+// which adapters set relative to the run directory, relative to latestDir.
+func resolveMediaPaths(latestDir, runDir string, result *model.RunResult) error {
+	resolve := func(path string) (string, error) {
+		rel, err := filepath.Rel(latestDir, filepath.Join(runDir, filepath.FromSlash(path)))
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve media path %s: %w", path, err)
+		}
+		return filepath.ToSlash(rel), nil
+	}
+
+	for i := range result.Tests {
+		test := &result.Tests[i]
+		for j, path := range test.Screenshots {
+			rel, err := resolve(path)
+			if err != nil {
+				return err
+			}
+			test.Screenshots[j] = rel
+		}
+		if test.Video != "" {
+			rel, err := resolve(test.Video)
+			if err != nil {
+				return err
+			}
+			test.Video = rel
+		}
+	}
+	return nil
+}
+
+// readOutput returns the content of a captured output file, or an empty string.
+func readOutput(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// formatFailureLog renders the failure log of a single test.
+func formatFailureLog(runIndex int, test model.TestResult, output string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Test: %s\n", test.TestID)
+	fmt.Fprintf(&sb, "Run:  %d\n", runIndex)
+
+	if len(test.Attempts) > 1 {
+		// Retried tests show every failed attempt
+		for i, attempt := range test.Attempts {
+			fmt.Fprintf(&sb, "\n--- Attempt %d of %d: %s (%s) ---\n", i+1, len(test.Attempts), attempt.Outcome, attempt.Duration)
+			if attempt.Outcome == model.OutcomeFail {
+				writeFailure(&sb, attempt.FailureMessage, attempt.StackTrace)
+			}
+		}
+	} else {
+		writeFailure(&sb, test.FailureMessage, test.StackTrace)
+	}
+
+	if output != "" {
+		sb.WriteString("\n--- Output ---\n\n")
+		sb.WriteString(output)
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// writeFailure writes the failure message and stack trace sections of a log.
+func writeFailure(sb *strings.Builder, message, stackTrace string) {
+	sb.WriteString("\n--- Failure message ---\n\n")
+	if message != "" {
+		sb.WriteString(strings.TrimRight(message, "\n"))
+	} else {
+		sb.WriteString("(no failure message)")
+	}
+	sb.WriteString("\n")
+
+	if stackTrace != "" {
+		sb.WriteString("\n--- Stack trace ---\n\n")
+		sb.WriteString(strings.TrimRight(stackTrace, "\n"))
+		sb.WriteString("\n")
+	}
+}
+
+// failureLogName returns a file name for a test's failure log. Test IDs are
+// reduced to safe characters and suffixed with a hash to keep names unique.
+func failureLogName(testID string) string {
+	var sb strings.Builder
+	for _, r := range testID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune('_')
+		}
+	}
+
+	name := strings.Trim(sb.String(), "_.")
+	if len(name) > maxLogNameLen {
+		name = name[len(name)-maxLogNameLen:]
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(testID))
+	return fmt.Sprintf("%s-%08x.txt", name, h.Sum32())
+}
+
+// pruneMissingLogs clears log paths that no longer exist, such as logs of
+// runs removed by the keep-runs limit. Failure clusters fall back to the log
+// of their earliest run that was kept.
+func pruneMissingLogs(latestDir string, tests []model.AggregatedTest) {
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(latestDir, filepath.FromSlash(rel)))
+		return err == nil
+	}
+
+	for i := range tests {
+		logs := make(map[int]string)
+		for j := range tests[i].FailureEvidence {
+			ev := &tests[i].FailureEvidence[j]
+			if ev.LogPath != "" && !exists(ev.LogPath) {
+				ev.LogPath = ""
+			}
+			logs[ev.RunIndex] = ev.LogPath
+		}
+
+		for j := range tests[i].FailureClusters {
+			c := &tests[i].FailureClusters[j]
+			if c.LogPath == "" || exists(c.LogPath) {
+				continue
+			}
+			c.LogPath = ""
+			for _, runIndex := range c.RunIndices {
+				if logs[runIndex] != "" {
+					c.LogPath = logs[runIndex]
+					break
+				}
+			}
+		}
+	}
+}
+
+// pruneMissingMedia clears screenshot and video paths that no longer exist.
+// Failure clusters fall back to the media of another run that was kept.
+func pruneMissingMedia(latestDir string, tests []model.AggregatedTest) {
+	exists := func(rel string) bool {
+		_, err := os.Stat(filepath.Join(latestDir, filepath.FromSlash(rel)))
+		return err == nil
+	}
+
+	for i := range tests {
+		screenshots := make(map[int]string)
+		videos := make(map[int]string)
+		for j := range tests[i].FailureEvidence {
+			ev := &tests[i].FailureEvidence[j]
+			var kept []string
+			for _, path := range ev.Screenshots {
+				if exists(path) {
+					kept = append(kept, path)
+				}
+			}
+			ev.Screenshots = kept
+			if len(kept) > 0 {
+				screenshots[ev.RunIndex] = kept[0]
+			}
+			if ev.Video != "" && !exists(ev.Video) {
+				ev.Video = ""
+			}
+			videos[ev.RunIndex] = ev.Video
+		}
+
+		for j := range tests[i].FailureClusters {
+			c := &tests[i].FailureClusters[j]
+			if c.Screenshot != "" && !exists(c.Screenshot) {
+				c.Screenshot = firstKept(c.RunIndices, screenshots)
+			}
+			if c.Video != "" && !exists(c.Video) {
+				c.Video = firstKept(c.RunIndices, videos)
+			}
+		}
+	}
+}
+
+// firstKept returns the first non-empty path among runIndices.
+func firstKept(runIndices []int, paths map[int]string) string {
+	for _, runIndex := range runIndices {
+		if paths[runIndex] != "" {
+			return paths[runIndex]
+		}
+	}
+	return ""
+}
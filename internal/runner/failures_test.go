@@ -0,0 +1,223 @@
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// slicingAdapter is a fakeAdapter that attributes each output line
+// containing a test's ID to that test.
+type slicingAdapter struct {
+	fakeAdapter
+}
+
+func (a *slicingAdapter) SliceOutput(test model.TestResult, stdout, stderr string) string {
+	var lines []string
+	for _, line := range strings.Split(stdout+stderr, "\n") {
+		if strings.Contains(line, test.TestID) {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestFailureLogName(t *testing.T) {
+	tests := []struct {
+		name   string
+		testID string
+		prefix string
+	}{
+		{"jest test", "/project/src/login.test.js::login submits the form", "project_src_login.test.js__login_submits_the_form-"},
+		{"cypress test", "cypress/e2e/cart.cy.ts::cart -- adds an item", "cypress_e2e_cart.cy.ts__cart_--_adds_an_item-"},
+		{"unsafe characters", "a/../b::<suite setup>", "a_.._b___suite_setup-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := failureLogName(tt.testID)
+			if !strings.HasPrefix(got, tt.prefix) || !strings.HasSuffix(got, ".txt") {
+				t.Errorf("failureLogName(%q) = %q, want %q<hash>.txt", tt.testID, got, tt.prefix)
+			}
+			if got != failureLogName(tt.testID) {
+				t.Errorf("failureLogName(%q) is not stable", tt.testID)
+			}
+		})
+	}
+
+	t.Run("similar IDs get different names", func(t *testing.T) {
+		a, b := failureLogName("suite::a/b"), failureLogName("suite::a b")
+		if a == b {
+			t.Errorf("failureLogName() = %q for both IDs, want different names", a)
+		}
+	})
+
+	t.Run("long IDs keep their end", func(t *testing.T) {
+		testID := strings.Repeat("describe ", 20) + "the actual test"
+		got := failureLogName(testID)
+		name := strings.TrimSuffix(got, ".txt")
+		name = name[:strings.LastIndex(name, "-")]
+		if len(name) != maxLogNameLen {
+			t.Errorf("failureLogName() name part has %d characters, want %d", len(name), maxLogNameLen)
+		}
+		if !strings.HasSuffix(name, "the_actual_test") {
+			t.Errorf("failureLogName() = %q, want it to end with the test name", got)
+		}
+	})
+}
+
+func TestWriteFailureLogs(t *testing.T) {
+	latestDir := t.TempDir()
+	runDir := filepath.Join(latestDir, "runs", "002")
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	stdout := "PASS other\nlogin: request sent\nlogin: got 500\n"
+	if err := os.WriteFile(filepath.Join(runDir, "stdout.txt"), []byte(stdout), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := &model.RunResult{
+		RunIndex: 2,
+		Tests: []model.TestResult{
+			{TestID: "passing", Outcome: model.OutcomePass},
+			{
+				TestID:         "login",
+				Outcome:        model.OutcomeFail,
+				FailureMessage: "Expected 200, got 500\n",
+				StackTrace:     "at login.test.js:12:5",
+			},
+			{
+				TestID:     "retried",
+				Outcome:    model.OutcomePass,
+				FlakyInRun: true,
+				Attempts: []model.Attempt{
+					{Outcome: model.OutcomeFail, Duration: time.Second, FailureMessage: "Timed out"},
+					{Outcome: model.OutcomePass, Duration: 2 * time.Second},
+				},
+			},
+		},
+	}
+	cfg := &Config{Adapter: &slicingAdapter{}}
+
+	if err := writeFailureLogs(cfg, latestDir, runDir, result); err != nil {
+		t.Fatalf("writeFailureLogs() error = %v", err)
+	}
+
+	if result.Tests[0].LogPath != "" {
+		t.Errorf("passing test LogPath = %q, want none", result.Tests[0].LogPath)
+	}
+
+	tests := []struct {
+		index int
+		want  string
+	}{
+		{
+			index: 1,
+			want: "Test: login\nRun:  2\n" +
+				"\n--- Failure message ---\n\nExpected 200, got 500\n" +
+				"\n--- Stack trace ---\n\nat login.test.js:12:5\n" +
+				"\n--- Output ---\n\nlogin: request sent\nlogin: got 500\n",
+		},
+		{
+			index: 2,
+			want: "Test: retried\nRun:  2\n" +
+				"\n--- Attempt 1 of 2: fail (1s) ---\n" +
+				"\n--- Failure message ---\n\nTimed out\n" +
+				"\n--- Attempt 2 of 2: pass (2s) ---\n",
+		},
+	}
+	for _, tt := range tests {
+		test := result.Tests[tt.index]
+		wantPath := "runs/002/failures/" + failureLogName(test.TestID)
+		if test.LogPath != wantPath {
+			t.Errorf("%s LogPath = %q, want %q", test.TestID, test.LogPath, wantPath)
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(latestDir, filepath.FromSlash(test.LogPath)))
+		if err != nil {
+			t.Errorf("failed to read log of %s: %v", test.TestID, err)
+			continue
+		}
+		if got := string(data); got != tt.want {
+			t.Errorf("log of %s:\n%s\nwant:\n%s", test.TestID, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFailureLogsSkipsPassingRuns(t *testing.T) {
+	latestDir := t.TempDir()
+	runDir := filepath.Join(latestDir, "runs", "001")
+	result := &model.RunResult{
+		RunIndex: 1,
+		Tests:    []model.TestResult{{TestID: "a", Outcome: model.OutcomePass}},
+	}
+
+	if err := writeFailureLogs(&Config{Adapter: &fakeAdapter{}}, latestDir, runDir, result); err != nil {
+		t.Fatalf("writeFailureLogs() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(runDir, failuresDirName)); !os.IsNotExist(err) {
+		t.Errorf("failures directory exists for a run without failures")
+	}
+}
+
+func TestRunPrunesRemovedRuns(t *testing.T) {
+	// Test a fails in runs 1 and 3 with the same error and screenshots and
+	// videos of each failure; only run 3 is kept
+	failing := func() []model.TestResult {
+		return []model.TestResult{{
+			TestID:         "a",
+			Outcome:        model.OutcomeFail,
+			FailureMessage: "Expected true",
+			Screenshots:    []string{"screenshots/a.png"},
+			Video:          "videos/a.mp4",
+		}}
+	}
+	adapter := &fakeAdapter{runs: map[int][]model.TestResult{
+		1: failing(),
+		2: {{TestID: "a", Outcome: model.OutcomePass}},
+		3: failing(),
+	}}
+	media := `mkdir -p "$FLAKEHUNT_RUN_DIR/screenshots" "$FLAKEHUNT_RUN_DIR/videos" && ` +
+		`touch "$FLAKEHUNT_RUN_DIR/screenshots/a.png" "$FLAKEHUNT_RUN_DIR/videos/a.mp4"`
+	cfg := newTestConfig(t, 3, writeResults+" && "+media, adapter)
+	cfg.KeepRuns = 1
+
+	result, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.Tests) != 1 {
+		t.Fatalf("Tests = %v, want one", result.Tests)
+	}
+	test := result.Tests[0]
+
+	logPath := "runs/003/failures/" + failureLogName("a")
+	want := []model.FailureEvidence{
+		{RunIndex: 1},
+		{RunIndex: 3, LogPath: logPath, Screenshots: []string{"runs/003/screenshots/a.png"}, Video: "runs/003/videos/a.mp4"},
+	}
+	if len(test.FailureEvidence) != len(want) {
+		t.Fatalf("FailureEvidence = %+v, want %d entries", test.FailureEvidence, len(want))
+	}
+	for i, ev := range test.FailureEvidence {
+		w := want[i]
+		if ev.RunIndex != w.RunIndex || ev.LogPath != w.LogPath || ev.Video != w.Video ||
+			strings.Join(ev.Screenshots, ",") != strings.Join(w.Screenshots, ",") {
+			t.Errorf("FailureEvidence[%d] = %+v, want %+v", i, ev, w)
+		}
+	}
+
+	// The cluster's first occurrence was removed, so it falls back to run 3
+	if len(test.FailureClusters) != 1 {
+		t.Fatalf("FailureClusters = %+v, want one", test.FailureClusters)
+	}
+	c := test.FailureClusters[0]
+	if c.LogPath != logPath || c.Screenshot != "runs/003/screenshots/a.png" || c.Video != "runs/003/videos/a.mp4" {
+		t.Errorf("cluster paths = %q, %q, %q, want the paths of run 3", c.LogPath, c.Screenshot, c.Video)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/boyarskiy/flakehunt/internal/classify"
 	"github.com/boyarskiy/flakehunt/internal/model"
 )
 
@@ -24,6 +25,47 @@ type Config struct {
 	Tool     model.Tool
 	Command  []string
 	Adapter  model.Adapter
+
+	// Stdout and Stderr receive the test command output in addition to the
+	// per-run stdout.txt and stderr.txt files. Nil means os.Stdout and
+	// os.Stderr; use io.Discard to hide the output.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Observers receive session events as runs execute.
+	Observers []Observer
+
+	// Hooks are shell commands run around the session and each run.
+	Hooks Hooks
+
+	// Classify configures how test results are classified.
+	Classify classify.Options
+}
+
+// ProjectDir returns the project root, the parent of OutDir. The test
+// command and hooks run from it.
+func (c *Config) ProjectDir() string {
+	return filepath.Dir(c.OutDir)
+}
+
+// Hook stages, used in hook log file names and infra errors.
+const (
+	StageBeforeSession = "before-session"
+	StageBeforeRun     = "before-run"
+	StageAfterRun      = "after-run"
+	StageAfterSession  = "after-session"
+	StageRun           = "run"
+)
+
+// Hooks holds lifecycle commands executed through the shell from the project
+// root. Empty commands are skipped. Each hook receives FLAKEHUNT_TOTAL_RUNS and
+// FLAKEHUNT_OUT_DIR in its environment; run hooks and the test command also
+// receive FLAKEHUNT_RUN_INDEX and FLAKEHUNT_RUN_DIR.
+type Hooks struct {
+	BeforeSession string
+	BeforeRun     string
+	AfterRun      string
+	AfterSession  string
 }
 
 // Result holds the results of all runs.
@@ -31,8 +73,37 @@ type Result struct {
 	RunResults   []*model.RunResult
 	RunsExecuted int
 	LatestDir    string
+	Tests        []model.AggregatedTest // classified results across all runs
+	InfraErrors  []model.InfraError     // hook and run execution failures
 }
 
+// Observer receives session events from the runner.
+// Methods are called synchronously from the runner loop.
+type Observer interface {
+	// RunStarted is called before each run is executed.
+	RunStarted(runIndex, totalRuns int)
+
+	// RunFinished is called after each run has been parsed, with the
+	// classified results of all runs so far. Runs that failed to execute
+	// are reported with their Error set.
+	RunFinished(result *model.RunResult, tests []model.AggregatedTest)
+
+	// TestBecameFlaky is called when a run causes a test to be classified as flaky.
+	TestBecameFlaky(test model.AggregatedTest)
+
+	// SessionEnded is called once after the last run.
+	SessionEnded(result *Result)
+}
+
+// NopObserver implements Observer with no-op methods.
+// Embed it to implement only the events of interest.
+type NopObserver struct{}
+
+func (NopObserver) RunStarted(runIndex, totalRuns int)                                {}
+func (NopObserver) RunFinished(result *model.RunResult, tests []model.AggregatedTest) {}
+func (NopObserver) TestBecameFlaky(test model.AggregatedTest)                         {}
+func (NopObserver) SessionEnded(result *Result)                                       {}
+
 // Run executes the test command repeatedly and collects results.
 func Run(ctx context.Context, cfg *Config) (*Result, error) {
 	if cfg.Runs <= 0 {
@@ -58,7 +129,17 @@ func Run(ctx context.Context, cfg *Config) (*Result, error) {
 		return nil, fmt.Errorf("failed to create runs directory %s: %w", runsDir, err)
 	}
 
+	sessionEnv, err := hookEnv(cfg, latestDir, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := runHook(ctx, cfg, StageBeforeSession, cfg.Hooks.BeforeSession, latestDir, sessionEnv); err != nil {
+		return nil, err
+	}
+
 	var runResults []*model.RunResult
+	var infraErrors []model.InfraError
+	aggregator := classify.NewAggregator(cfg.Classify)
 	startTime := time.Now()
 
 	for i := 1; i <= cfg.Runs; i++ {
@@ -72,20 +153,29 @@ func Run(ctx context.Context, cfg *Config) (*Result, error) {
 			break
 		}
 
+		for _, obs := range cfg.Observers {
+			obs.RunStarted(i, cfg.Runs)
+		}
+
 		runDir := filepath.Join(runsDir, fmt.Sprintf("%03d", i))
 		if err := os.MkdirAll(runDir, 0755); err != nil {
 			return nil, fmt.Errorf("failed to create run directory %s: %w", runDir, err)
 		}
 
-		result, err := executeRun(ctx, cfg, runDir, i)
-		if err != nil {
-			// Record the error but continue with other runs
-			result = &model.RunResult{
-				RunIndex: i,
-				Error:    err.Error(),
+		result := executeRunWithHooks(ctx, cfg, latestDir, runDir, i)
+		runResults = append(runResults, result)
+		infraErrors = append(infraErrors, result.InfraErrors...)
+
+		becameFlaky := aggregator.Add(*result)
+		if len(cfg.Observers) > 0 {
+			snapshot := aggregator.Snapshot()
+			for _, obs := range cfg.Observers {
+				obs.RunFinished(result, snapshot)
+				for _, test := range becameFlaky {
+					obs.TestBecameFlaky(test)
+				}
 			}
 		}
-		runResults = append(runResults, result)
 	}
 
 	// Apply keep-runs cleanup
@@ -96,15 +186,159 @@ func Run(ctx context.Context, cfg *Config) (*Result, error) {
 		}
 	}
 
-	return &Result{
+	// The session was started, so always give the after-session hook a
+	// chance to clean up, even when interrupted
+	if err := runHook(context.WithoutCancel(ctx), cfg, StageAfterSession, cfg.Hooks.AfterSession, latestDir, sessionEnv); err != nil {
+		infraErrors = append(infraErrors, model.InfraError{
+			Stage:   StageAfterSession,
+			Message: err.Error(),
+		})
+	}
+
+	tests := aggregator.Snapshot()
+	if cfg.KeepRuns > 0 {
+		pruneMissingLogs(latestDir, tests)
+		pruneMissingMedia(latestDir, tests)
+	}
+
+	result := &Result{
 		RunResults:   runResults,
 		RunsExecuted: len(runResults),
 		LatestDir:    latestDir,
-	}, nil
+		Tests:        tests,
+		InfraErrors:  infraErrors,
+	}
+
+	for _, obs := range cfg.Observers {
+		obs.SessionEnded(result)
+	}
+
+	return result, nil
+}
+
+// executeRunWithHooks executes a single test run between its before-run and
+// after-run hooks. Failures are recorded on the returned result rather than
+// aborting the session.
+func executeRunWithHooks(ctx context.Context, cfg *Config, latestDir, runDir string, runIndex int) *model.RunResult {
+	env, err := hookEnv(cfg, latestDir, runDir, runIndex)
+	if err != nil {
+		return failedRun(runIndex, StageRun, err)
+	}
+
+	// A failed before-run hook leaves the environment in an unknown state,
+	// so the test command is not executed
+	if err := runHook(ctx, cfg, StageBeforeRun, cfg.Hooks.BeforeRun, runDir, env); err != nil {
+		return failedRun(runIndex, StageBeforeRun, err)
+	}
+
+	result, err := executeRun(ctx, cfg, runDir, runIndex, env)
+	if err != nil {
+		// Record the error but continue with other runs
+		result = failedRun(runIndex, StageRun, err)
+	} else {
+		// Missing media does not prevent writing the failure logs
+		if err := resolveMediaPaths(latestDir, runDir, result); err != nil {
+			result.InfraErrors = append(result.InfraErrors, model.InfraError{
+				RunIndex: runIndex,
+				Stage:    StageRun,
+				Message:  err.Error(),
+			})
+		}
+		if err := writeFailureLogs(cfg, latestDir, runDir, result); err != nil {
+			result.InfraErrors = append(result.InfraErrors, model.InfraError{
+				RunIndex: runIndex,
+				Stage:    StageRun,
+				Message:  err.Error(),
+			})
+		}
+	}
+
+	// After-run failures do not invalidate the parsed test results. The run
+	// was started, so the hook runs even when interrupted, like after-session
+	if err := runHook(context.WithoutCancel(ctx), cfg, StageAfterRun, cfg.Hooks.AfterRun, runDir, env); err != nil {
+		result.InfraErrors = append(result.InfraErrors, model.InfraError{
+			RunIndex: runIndex,
+			Stage:    StageAfterRun,
+			Message:  err.Error(),
+		})
+	}
+
+	return result
 }
 
-// executeRun executes a single test run.
-func executeRun(ctx context.Context, cfg *Config, runDir string, runIndex int) (*model.RunResult, error) {
+// failedRun builds the result of a run that produced no test results.
+func failedRun(runIndex int, stage string, err error) *model.RunResult {
+	return &model.RunResult{
+		RunIndex: runIndex,
+		Error:    err.Error(),
+		InfraErrors: []model.InfraError{{
+			RunIndex: runIndex,
+			Stage:    stage,
+			Message:  err.Error(),
+		}},
+	}
+}
+
+// hookEnv returns the environment variables passed to lifecycle hooks and
+// the test command. runDir is empty for session hooks.
+func hookEnv(cfg *Config, latestDir, runDir string, runIndex int) ([]string, error) {
+	absLatest, err := filepath.Abs(latestDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve output directory %s: %w", latestDir, err)
+	}
+	env := []string{
+		"FLAKEHUNT_TOTAL_RUNS=" + strconv.Itoa(cfg.Runs),
+		"FLAKEHUNT_OUT_DIR=" + absLatest,
+	}
+
+	if runDir != "" {
+		absRun, err := filepath.Abs(runDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve run directory %s: %w", runDir, err)
+		}
+		env = append(env,
+			"FLAKEHUNT_RUN_INDEX="+strconv.Itoa(runIndex),
+			"FLAKEHUNT_RUN_DIR="+absRun,
+		)
+	}
+
+	return env, nil
+}
+
+// runHook executes a lifecycle hook through the shell from the project root.
+// Its combined output is written to <dir>/hooks/<stage>.log.
+func runHook(ctx context.Context, cfg *Config, stage, command, dir string, env []string) error {
+	if command == "" {
+		return nil
+	}
+
+	hooksDir := filepath.Join(dir, "hooks")
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("failed to create hooks directory %s: %w", hooksDir, err)
+	}
+
+	logPath := filepath.Join(hooksDir, stage+".log")
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", logPath, err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = cfg.ProjectDir()
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s hook failed: %v. See %s", stage, err, logPath)
+	}
+
+	return nil
+}
+
+// executeRun executes a single test run with env added to its environment.
+func executeRun(ctx context.Context, cfg *Config, runDir string, runIndex int, env []string) (*model.RunResult, error) {
 	// Build the command with adapter-specific arguments
 	cmdArgs := cfg.Adapter.BuildCommand(runDir, cfg.Command)
 	if len(cmdArgs) == 0 {
@@ -112,7 +346,8 @@ func executeRun(ctx context.Context, cfg *Config, runDir string, runIndex int) (
 	}
 
 	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
-	cmd.Dir = filepath.Dir(cfg.OutDir) // Run from project root
+	cmd.Dir = cfg.ProjectDir()
+	cmd.Env = append(os.Environ(), env...)
 
 	// Capture stdout and stderr
 	stdoutFile, err := os.Create(filepath.Join(runDir, "stdout.txt"))
@@ -127,8 +362,16 @@ func executeRun(ctx context.Context, cfg *Config, runDir string, runIndex int) (
 	}
 	defer stderrFile.Close()
 
-	cmd.Stdout = io.MultiWriter(stdoutFile, os.Stdout)
-	cmd.Stderr = io.MultiWriter(stderrFile, os.Stderr)
+	stdout := cfg.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	stderr := cfg.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+	cmd.Stdout = io.MultiWriter(stdoutFile, stdout)
+	cmd.Stderr = io.MultiWriter(stderrFile, stderr)
 
 	// Execute the command
 	// Note: We don't treat non-zero exit as an error since tests may fail
@@ -0,0 +1,335 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// writeResults is a test command that produces the fake adapter's artifact.
+const writeResults = `touch "$FLAKEHUNT_RUN_DIR/results"`
+
+// fakeAdapter runs the test command through the shell and reports the tests
+// configured for each run index, without reading the artifact.
+type fakeAdapter struct {
+	runs map[int][]model.TestResult
+
+	// parsed is called after a run's results have been parsed, if set.
+	parsed func(runIndex int)
+}
+
+func (a *fakeAdapter) BuildCommand(runDir string, userCmd []string) []string {
+	return append([]string{"sh", "-c"}, userCmd...)
+}
+
+func (a *fakeAdapter) ExpectedArtifact(runDir string) string {
+	return filepath.Join(runDir, "results")
+}
+
+func (a *fakeAdapter) Parse(runDir string) (*model.RunResult, error) {
+	runIndex, err := ParseRunIndex(filepath.Base(runDir))
+	if err != nil {
+		return nil, err
+	}
+	if a.parsed != nil {
+		a.parsed(runIndex)
+	}
+	tests := append([]model.TestResult(nil), a.runs[runIndex]...)
+	return &model.RunResult{Tests: tests}, nil
+}
+
+// newTestConfig returns a config that runs command in a temporary project
+// directory, with the test command output discarded.
+func newTestConfig(t *testing.T, runs int, command string, adapter *fakeAdapter) *Config {
+	t.Helper()
+	return &Config{
+		Runs:    runs,
+		OutDir:  filepath.Join(t.TempDir(), ".flakehunt"),
+		Command: []string{command},
+		Adapter: adapter,
+		Stdout:  io.Discard,
+		Stderr:  io.Discard,
+	}
+}
+
+// readLines returns the lines of a file in the project directory.
+func readLines(t *testing.T, cfg *Config, name string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(cfg.ProjectDir(), name))
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", name, err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestRunHookOrder(t *testing.T) {
+	// Hooks and the test command run from the project directory, so the
+	// relative trace file is shared by all of them
+	trace := func(stage string) string {
+		return `echo "` + stage + ` ${FLAKEHUNT_RUN_INDEX:-0}" >> trace.txt`
+	}
+	cfg := newTestConfig(t, 2, writeResults+" && "+trace("run"), &fakeAdapter{})
+	cfg.Hooks = Hooks{
+		BeforeSession: trace(StageBeforeSession),
+		BeforeRun:     trace(StageBeforeRun),
+		AfterRun:      trace(StageAfterRun),
+		AfterSession:  trace(StageAfterSession),
+	}
+
+	result, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(result.InfraErrors) != 0 {
+		t.Errorf("InfraErrors = %v, want none", result.InfraErrors)
+	}
+
+	want := []string{
+		"before-session 0",
+		"before-run 1", "run 1", "after-run 1",
+		"before-run 2", "run 2", "after-run 2",
+		"after-session 0",
+	}
+	got := readLines(t, cfg, "trace.txt")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("stages ran in order %q, want %q", got, want)
+	}
+}
+
+func TestRunHookEnv(t *testing.T) {
+	printEnv := `echo "$FLAKEHUNT_RUN_INDEX|$FLAKEHUNT_RUN_DIR|$FLAKEHUNT_OUT_DIR|$FLAKEHUNT_TOTAL_RUNS"`
+	cfg := newTestConfig(t, 2, writeResults, &fakeAdapter{})
+	cfg.Hooks = Hooks{
+		BeforeSession: printEnv,
+		BeforeRun:     printEnv,
+	}
+
+	result, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	outDir, err := filepath.Abs(result.LatestDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		log  string
+		want string
+	}{
+		{
+			log:  filepath.Join(outDir, "hooks", "before-session.log"),
+			want: "||" + outDir + "|2",
+		},
+		{
+			log:  filepath.Join(outDir, "runs", "001", "hooks", "before-run.log"),
+			want: "1|" + filepath.Join(outDir, "runs", "001") + "|" + outDir + "|2",
+		},
+		{
+			log:  filepath.Join(outDir, "runs", "002", "hooks", "before-run.log"),
+			want: "2|" + filepath.Join(outDir, "runs", "002") + "|" + outDir + "|2",
+		},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(tt.log)
+		if err != nil {
+			t.Errorf("failed to read hook log: %v", err)
+			continue
+		}
+		if got := strings.TrimSpace(string(data)); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.log, got, tt.want)
+		}
+	}
+}
+
+func TestRunHookLogCapturesOutput(t *testing.T) {
+	cfg := newTestConfig(t, 1, writeResults, &fakeAdapter{})
+	cfg.Hooks.AfterRun = "echo to stdout; echo to stderr >&2"
+
+	result, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(result.LatestDir, "runs", "001", "hooks", "after-run.log"))
+	if err != nil {
+		t.Fatalf("failed to read hook log: %v", err)
+	}
+	if got := string(data); got != "to stdout\nto stderr\n" {
+		t.Errorf("after-run.log = %q, want stdout and stderr", got)
+	}
+}
+
+func TestRunBeforeRunFailureSkipsCommand(t *testing.T) {
+	adapter := &fakeAdapter{runs: map[int][]model.TestResult{
+		1: {{TestID: "a", Outcome: model.OutcomePass}},
+		2: {{TestID: "a", Outcome: model.OutcomePass}},
+	}}
+	cfg := newTestConfig(t, 2, writeResults+` && echo "$FLAKEHUNT_RUN_INDEX" >> ran.txt`, adapter)
+	cfg.Hooks.BeforeRun = `[ "$FLAKEHUNT_RUN_INDEX" != 1 ]`
+
+	result, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if got := readLines(t, cfg, "ran.txt"); len(got) != 1 || got[0] != "2" {
+		t.Errorf("test command ran in runs %q, want only run 2", got)
+	}
+	if result.RunsExecuted != 2 {
+		t.Errorf("RunsExecuted = %d, want 2", result.RunsExecuted)
+	}
+	if result.RunResults[0].Error == "" || len(result.RunResults[0].Tests) != 0 {
+		t.Errorf("run 1 = %+v, want an error and no tests", result.RunResults[0])
+	}
+
+	if len(result.InfraErrors) != 1 {
+		t.Fatalf("InfraErrors = %v, want one", result.InfraErrors)
+	}
+	infra := result.InfraErrors[0]
+	if infra.RunIndex != 1 || infra.Stage != StageBeforeRun {
+		t.Errorf("InfraError = %+v, want run 1 at stage %s", infra, StageBeforeRun)
+	}
+	if !strings.Contains(infra.Message, filepath.Join("runs", "001", "hooks", "before-run.log")) {
+		t.Errorf("InfraError.Message = %q, want the hook log path", infra.Message)
+	}
+}
+
+func TestRunHookFailuresAreInfraErrors(t *testing.T) {
+	adapter := &fakeAdapter{runs: map[int][]model.TestResult{
+		1: {{TestID: "a", Outcome: model.OutcomePass}},
+	}}
+	cfg := newTestConfig(t, 1, writeResults, adapter)
+	cfg.Hooks = Hooks{
+		AfterRun:     "exit 1",
+		AfterSession: "exit 2",
+	}
+
+	result, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	var stages []string
+	for _, infra := range result.InfraErrors {
+		stages = append(stages, infra.Stage)
+	}
+	if got, want := strings.Join(stages, ","), StageAfterRun+","+StageAfterSession; got != want {
+		t.Errorf("InfraErrors stages = %s, want %s", got, want)
+	}
+
+	// The run's test results are kept and no test fails
+	if len(result.Tests) != 1 {
+		t.Fatalf("Tests = %v, want one", result.Tests)
+	}
+	if test := result.Tests[0]; test.PassCount != 1 || test.FailCount != 0 {
+		t.Errorf("test a passed %d and failed %d times, want 1 pass", test.PassCount, test.FailCount)
+	}
+	if result.RunResults[0].Error != "" {
+		t.Errorf("run 1 Error = %q, want none", result.RunResults[0].Error)
+	}
+}
+
+func TestRunBeforeSessionFailureAbortsSession(t *testing.T) {
+	cfg := newTestConfig(t, 1, writeResults+" && touch ran.txt", &fakeAdapter{})
+	cfg.Hooks.BeforeSession = "exit 1"
+
+	if _, err := Run(context.Background(), cfg); err == nil {
+		t.Fatal("Run() error = nil, want before-session failure")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.ProjectDir(), "ran.txt")); err == nil {
+		t.Error("test command ran after the before-session hook failed")
+	}
+}
+
+func TestRunAfterHooksRunWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel while the first run is being processed, as an interrupt would
+	adapter := &fakeAdapter{parsed: func(int) { cancel() }}
+	cfg := newTestConfig(t, 3, writeResults, adapter)
+	cfg.Hooks = Hooks{
+		AfterRun:     `echo "after-run $FLAKEHUNT_RUN_INDEX" >> trace.txt`,
+		AfterSession: `echo "after-session" >> trace.txt`,
+	}
+
+	result, err := Run(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.RunsExecuted != 1 {
+		t.Errorf("RunsExecuted = %d, want 1", result.RunsExecuted)
+	}
+	if len(result.InfraErrors) != 0 {
+		t.Errorf("InfraErrors = %v, want none", result.InfraErrors)
+	}
+
+	want := []string{"after-run 1", "after-session"}
+	if got := readLines(t, cfg, "trace.txt"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("after hooks ran %q, want %q", got, want)
+	}
+}
+
+// recordingObserver records session events in the order they are received.
+type recordingObserver struct {
+	events []string
+}
+
+func (o *recordingObserver) RunStarted(runIndex, totalRuns int) {
+	o.events = append(o.events, fmt.Sprintf("run started %d/%d", runIndex, totalRuns))
+}
+
+func (o *recordingObserver) RunFinished(result *model.RunResult, tests []model.AggregatedTest) {
+	o.events = append(o.events, fmt.Sprintf("run finished %d (%d tests)", result.RunIndex, len(tests)))
+}
+
+func (o *recordingObserver) TestBecameFlaky(test model.AggregatedTest) {
+	o.events = append(o.events, "became flaky "+test.TestID)
+}
+
+func (o *recordingObserver) SessionEnded(result *Result) {
+	o.events = append(o.events, fmt.Sprintf("session ended (%d runs)", result.RunsExecuted))
+}
+
+func TestRunObserverEvents(t *testing.T) {
+	pass := func(id string) model.TestResult { return model.TestResult{TestID: id, Outcome: model.OutcomePass} }
+	fail := func(id string) model.TestResult { return model.TestResult{TestID: id, Outcome: model.OutcomeFail} }
+	adapter := &fakeAdapter{runs: map[int][]model.TestResult{
+		1: {pass("a"), pass("b"), pass("c")},
+		2: {fail("a"), pass("b"), pass("c")},
+		3: {pass("a"), fail("b"), pass("c")},
+		4: {fail("a"), pass("b"), pass("c")},
+	}}
+	cfg := newTestConfig(t, 4, writeResults, adapter)
+	obs := &recordingObserver{}
+	cfg.Observers = []Observer{obs}
+
+	if _, err := Run(context.Background(), cfg); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Each test is reported as flaky once, after the run that made it flaky
+	want := []string{
+		"run started 1/4",
+		"run finished 1 (3 tests)",
+		"run started 2/4",
+		"run finished 2 (3 tests)",
+		"became flaky a",
+		"run started 3/4",
+		"run finished 3 (3 tests)",
+		"became flaky b",
+		"run started 4/4",
+		"run finished 4 (3 tests)",
+		"session ended (4 runs)",
+	}
+	if got := strings.Join(obs.events, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("events:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
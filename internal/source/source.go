@@ -0,0 +1,139 @@
+// Package source reads source code excerpts around failure locations.
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// DefaultContext is the number of lines shown before and after the failing line.
+const DefaultContext = 3
+
+// maxLineLen is the maximum length of a snippet line in characters; longer
+// lines are truncated.
+const maxLineLen = 200
+
+// Reader reads snippets from files under a project root, caching file contents.
+type Reader struct {
+	root  string
+	files map[string][]string // nil entry for unreadable files
+}
+
+// NewReader creates a Reader for files under root.
+func NewReader(root string) *Reader {
+	return &Reader{
+		root:  root,
+		files: make(map[string][]string),
+	}
+}
+
+// Snippet returns the lines around loc, with context lines on each side.
+// Locations outside the project root are rejected.
+func (r *Reader) Snippet(loc model.SourceLocation, context int) (*model.CodeSnippet, error) {
+	lines, err := r.lines(loc.File)
+	if err != nil {
+		return nil, err
+	}
+	if loc.Line < 1 || loc.Line > len(lines) {
+		return nil, fmt.Errorf("line %d is out of range for %s (%d lines)", loc.Line, loc.File, len(lines))
+	}
+
+	start := max(loc.Line-context, 1)
+	end := min(loc.Line+context, len(lines))
+
+	snippet := &model.CodeSnippet{
+		StartLine: start,
+		Highlight: loc.Line,
+	}
+	for _, line := range lines[start-1 : end] {
+		snippet.Lines = append(snippet.Lines, truncateLine(line))
+	}
+	return snippet, nil
+}
+
+// truncateLine shortens line to maxLineLen characters. It cuts on a rune
+// boundary, so that multi-byte characters are not split.
+func truncateLine(line string) string {
+	if utf8.RuneCountInString(line) <= maxLineLen {
+		return line
+	}
+	return string([]rune(line)[:maxLineLen-3]) + "..."
+}
+
+// lines returns the lines of a file, reading it on first use.
+func (r *Reader) lines(file string) ([]string, error) {
+	path, err := r.resolve(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if lines, ok := r.files[path]; ok {
+		if lines == nil {
+			return nil, fmt.Errorf("failed to read %s", file)
+		}
+		return lines, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		r.files[path] = nil
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		r.files[path] = nil
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	r.files[path] = lines
+	return lines, nil
+}
+
+// resolve returns the absolute path of file, which must be inside the root.
+func (r *Reader) resolve(file string) (string, error) {
+	root, err := filepath.Abs(r.root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project root %s: %w", r.root, err)
+	}
+
+	path := filepath.FromSlash(file)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the project root %s", file, root)
+	}
+	return path, nil
+}
+
+// AttachSnippets sets the snippet of every failure cluster with a known location.
+// Clusters whose source cannot be read are left without a snippet.
+func AttachSnippets(tests []model.AggregatedTest, root string, context int) {
+	reader := NewReader(root)
+	for i := range tests {
+		for j := range tests[i].FailureClusters {
+			cluster := &tests[i].FailureClusters[j]
+			if cluster.Location == nil {
+				continue
+			}
+			if snippet, err := reader.Snippet(*cluster.Location, context); err == nil {
+				cluster.Snippet = snippet
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package source
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/boyarskiy/flakehunt/internal/model"
+)
+
+// writeSource writes a source file with numbered lines under root.
+func writeSource(t *testing.T, root, name string, lines int) {
+	t.Helper()
+	var sb strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	path := filepath.Join(root, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	root := t.TempDir()
+	writeSource(t, root, "src/a.test.js", 9)
+
+	tests := []struct {
+		name      string
+		line      int
+		wantStart int
+		wantLines int
+	}{
+		{name: "middle", line: 5, wantStart: 2, wantLines: 7},
+		{name: "first line", line: 1, wantStart: 1, wantLines: 4},
+		{name: "last line", line: 9, wantStart: 6, wantLines: 4},
+	}
+
+	reader := NewReader(root)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			snippet, err := reader.Snippet(model.SourceLocation{File: "src/a.test.js", Line: tc.line}, DefaultContext)
+			if err != nil {
+				t.Fatalf("Snippet failed: %v", err)
+			}
+			if snippet.StartLine != tc.wantStart {
+				t.Errorf("StartLine = %d, want %d", snippet.StartLine, tc.wantStart)
+			}
+			if len(snippet.Lines) != tc.wantLines {
+				t.Errorf("len(Lines) = %d, want %d", len(snippet.Lines), tc.wantLines)
+			}
+			if snippet.Highlight != tc.line {
+				t.Errorf("Highlight = %d, want %d", snippet.Highlight, tc.line)
+			}
+			if got := snippet.Lines[tc.line-snippet.StartLine]; got != fmt.Sprintf("line %d", tc.line) {
+				t.Errorf("highlighted line = %q", got)
+			}
+		})
+	}
+}
+
+func TestSnippetTruncatesLongLines(t *testing.T) {
+	root := t.TempDir()
+	long := strings.Repeat("ж", maxLineLen+10)
+	if err := os.WriteFile(filepath.Join(root, "a.js"), []byte("short\n"+long+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write source: %v", err)
+	}
+
+	snippet, err := NewReader(root).Snippet(model.SourceLocation{File: "a.js", Line: 2}, DefaultContext)
+	if err != nil {
+		t.Fatalf("Snippet failed: %v", err)
+	}
+	if snippet.Lines[0] != "short" {
+		t.Errorf("Lines[0] = %q, want it unchanged", snippet.Lines[0])
+	}
+	want := strings.Repeat("ж", maxLineLen-3) + "..."
+	if got := snippet.Lines[1]; got != want {
+		t.Errorf("Lines[1] has %d bytes, want %d characters cut on a rune boundary", len(got), maxLineLen)
+	}
+}
+
+func TestSnippetErrors(t *testing.T) {
+	root := t.TempDir()
+	writeSource(t, root, "a.js", 3)
+	reader := NewReader(root)
+
+	for _, loc := range []model.SourceLocation{
+		{File: "missing.js", Line: 1},
+		{File: "a.js", Line: 10},
+		{File: "../outside.js", Line: 1},
+		{File: "/etc/passwd", Line: 1},
+	} {
+		if _, err := reader.Snippet(loc, DefaultContext); err == nil {
+			t.Errorf("Snippet(%s) expected error, got nil", loc)
+		}
+	}
+}
+
+func TestAttachSnippets(t *testing.T) {
+	root := t.TempDir()
+	writeSource(t, root, "a.js", 5)
+
+	tests := []model.AggregatedTest{{
+		TestID: "a.js::works",
+		FailureClusters: []model.FailureCluster{
+			{Location: &model.SourceLocation{File: "a.js", Line: 2}},
+			{Location: &model.SourceLocation{File: "gone.js", Line: 2}},
+			{},
+		},
+	}}
+
+	AttachSnippets(tests, root, 1)
+
+	clusters := tests[0].FailureClusters
+	if clusters[0].Snippet == nil || len(clusters[0].Snippet.Lines) != 3 {
+		t.Errorf("clusters[0].Snippet = %+v, want 3 lines", clusters[0].Snippet)
+	}
+	if clusters[1].Snippet != nil || clusters[2].Snippet != nil {
+		t.Error("clusters without readable source should have no snippet")
+	}
+}